@@ -2,67 +2,215 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
-	"net"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/zeon-code/tiny-url/internal/http/handler"
+	"github.com/zeon-code/tiny-url/internal/http/server"
+	"github.com/zeon-code/tiny-url/internal/job"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/outbox"
 	"github.com/zeon-code/tiny-url/internal/pkg/config"
 	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/pkg/pagination"
 	"github.com/zeon-code/tiny-url/internal/repository"
 	"github.com/zeon-code/tiny-url/internal/service"
+	"github.com/zeon-code/tiny-url/internal/webhook"
 )
 
 var version string = "0.0.1"
+var commit string = "unknown"
 
 func main() {
+	shouldMigrate := flag.Bool("migrate-on-start", false, "apply pending database migrations before serving")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage:\n  app [--migrate-on-start]\n%s\n", migrateUsage)
+	}
+	flag.Parse()
+
+	build := model.Build{Version: version, Commit: commit, StartedAt: time.Now()}
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
 	conf := config.NewConfiguration()
 	observer := observability.NewObserver(version, conf)
 
+	if flag.Arg(0) == "migrate" {
+		err := migrate(ctx, os.Stdout, conf, observer, flag.Args()[1:])
+		observer.Shutdown(ctx)
+
+		if errors.Is(err, errMigrateUsage) {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			os.Exit(2)
+		}
+
+		if err != nil {
+			observer.Logger().Error(ctx, "Error running migrations", slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
 	if err := observer.Startup(ctx); err != nil {
 		observer.Logger().Error(ctx, "Error initializing observer", slog.Any("error", err))
 	}
 
+	if *shouldMigrate {
+		if err := migrateOnStart(ctx, conf, observer); err != nil {
+			observer.Logger().Error(ctx, "Error applying migrations", slog.Any("error", err))
+			observer.Shutdown(ctx)
+			os.Exit(1)
+		}
+	}
+
 	repo := repository.NewRepositoriesFromConfig(conf, observer)
 	svc := service.NewServices(repo, observer)
 
-	server := &http.Server{
-		Addr:        ":8080",
-		BaseContext: func(net.Listener) context.Context { return ctx },
-		Handler:     handler.NewRouter(svc, observer),
+	dispatcher, err := webhook.NewDispatcherFromConfig(conf.Webhook(), repo.Webhook, observer)
+
+	if err != nil {
+		observer.Logger().Error(ctx, "Error configuring webhook dispatcher", slog.Any("error", err))
+		repo.Shutdown()
+		observer.Shutdown(ctx)
+		os.Exit(1)
 	}
 
-	go func(ctx context.Context, stop context.CancelFunc, server *http.Server, observer observability.Observer) {
-		observer.Logger().Info(ctx, "Starting server", slog.Any("version", version))
+	relay, err := outbox.NewRelayFromConfig(conf.Outbox(), repo.Outbox, observer, webhook.NewSink(repo.Webhook))
 
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			observer.Logger().Error(ctx, "Error starting server", slog.Any("error", err))
-			stop()
-		}
-	}(ctx, stop, server, observer)
+	if err != nil {
+		observer.Logger().Error(ctx, "Error configuring outbox relay", slog.Any("error", err))
+		repo.Shutdown()
+		observer.Shutdown(ctx)
+		os.Exit(1)
+	}
+
+	scheduler, err := job.NewSchedulerFromConfig(conf.Jobs(), repo, svc, observer)
+
+	if err != nil {
+		observer.Logger().Error(ctx, "Error configuring job scheduler", slog.Any("error", err))
+		relay.Close()
+		dispatcher.Close()
+		repo.Shutdown()
+		observer.Shutdown(ctx)
+		os.Exit(1)
+	}
+
+	pager, err := pagination.NewPagerFromConfig(conf.Pagination(), observer)
+
+	if err != nil {
+		observer.Logger().Error(ctx, "Error configuring pagination", slog.Any("error", err))
+		relay.Close()
+		dispatcher.Close()
+		repo.Shutdown()
+		observer.Shutdown(ctx)
+		os.Exit(1)
+	}
+
+	apiServer, err := server.NewServer(ctx, "api", conf.Server(), handler.NewRouter(svc, pager, observer), observer)
+
+	if err != nil {
+		observer.Logger().Error(ctx, "Error configuring server", slog.Any("error", err))
+		relay.Close()
+		dispatcher.Close()
+		repo.Shutdown()
+		observer.Shutdown(ctx)
+		os.Exit(1)
+	}
+
+	adminServer, err := server.NewServer(ctx, "admin", conf.Admin(), handler.NewAdminRouter(svc, observer, build), observer)
+
+	if err != nil {
+		observer.Logger().Error(ctx, "Error configuring admin server", slog.Any("error", err))
+		relay.Close()
+		dispatcher.Close()
+		repo.Shutdown()
+		observer.Shutdown(ctx)
+		os.Exit(1)
+	}
+
+	relay.Start()
+	dispatcher.Start()
+	scheduler.Start()
+	svc.Clicks.Start()
+
+	for _, srv := range []*server.Server{apiServer, adminServer} {
+		srv.ReloadOn(ctx, syscall.SIGHUP)
+
+		go func(ctx context.Context, stop context.CancelFunc, server *server.Server, observer observability.Observer) {
+			observer.Logger().Info(ctx, "Starting server", slog.String("server", server.Name()), slog.Any("version", version), slog.String("addr", server.Addr()))
+
+			if err := server.ListenAndServe(); err != nil {
+				observer.Logger().Error(ctx, "Error starting server", slog.String("server", server.Name()), slog.Any("error", err))
+				stop()
+			}
+		}(ctx, stop, srv, observer)
+	}
 
 	<-ctx.Done()
 
 	observer.Logger().Info(ctx, "Shutdown initiated")
 
 	hasShutdownErr := false
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), apiServer.ShutdownTimeout())
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
+	if err := apiServer.Shutdown(ctx); err != nil {
 		hasShutdownErr = true
 		observer.Logger().Error(ctx, "error Failed to gracefully shut down server", slog.Any("error", err))
 	}
 
 	observer.Logger().Info(ctx, "Server shut down gracefully")
 
+	adminCtx, adminCancel := context.WithTimeout(context.Background(), adminServer.ShutdownTimeout())
+	defer adminCancel()
+
+	if err := adminServer.Shutdown(adminCtx); err != nil {
+		hasShutdownErr = true
+		observer.Logger().Error(ctx, "error Failed to gracefully shut down admin server", slog.Any("error", err))
+	}
+
+	observer.Logger().Info(ctx, "Admin server shut down gracefully")
+
+	if err := scheduler.Close(); err != nil {
+		hasShutdownErr = true
+		observer.Logger().Error(ctx, "error Failed to gracefully shut down job scheduler", slog.Any("error", err))
+	}
+
+	observer.Logger().Info(ctx, "Job scheduler shut down gracefully")
+
+	if err := svc.Clicks.Close(); err != nil {
+		hasShutdownErr = true
+		observer.Logger().Error(ctx, "error Failed to flush link clicks", slog.Any("error", err))
+	}
+
+	observer.Logger().Info(ctx, "Click counter shut down gracefully")
+
+	if err := relay.Close(); err != nil {
+		hasShutdownErr = true
+		observer.Logger().Error(ctx, "error Failed to gracefully shut down outbox relay", slog.Any("error", err))
+	}
+
+	observer.Logger().Info(ctx, "Outbox relay shut down gracefully")
+
+	if err := dispatcher.Close(); err != nil {
+		hasShutdownErr = true
+		observer.Logger().Error(ctx, "error Failed to gracefully shut down webhook dispatcher", slog.Any("error", err))
+	}
+
+	observer.Logger().Info(ctx, "Webhook dispatcher shut down gracefully")
+
 	if err := repo.Shutdown(); err != nil {
 		hasShutdownErr = true
 		observer.Logger().Error(ctx, "error Failed to gracefully shut down repositories", slog.Any("error", err))
@@ -70,7 +218,12 @@ func main() {
 
 	observer.Logger().Info(ctx, "Repositories shut down gracefully")
 
-	if err := observer.Shutdown(ctx); err != nil {
+	// The observer flushes telemetry recorded during the shutdown above, so
+	// it gets a deadline of its own rather than what is left of the API's.
+	observerCtx, observerCancel := context.WithTimeout(context.Background(), apiServer.ShutdownTimeout())
+	defer observerCancel()
+
+	if err := observer.Shutdown(observerCtx); err != nil {
 		hasShutdownErr = true
 		observer.Logger().Error(ctx, "error failed to gracefully shut down observer", slog.Any("error", err))
 	}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+const migrateUsage = `Usage:
+  app migrate up              apply every pending migration
+  app migrate down [N]        revert the last N migrations (all when omitted)
+  app migrate status          show applied and pending migrations
+  app migrate force <V>       mark version V as applied and clean (-1 clears it)`
+
+var errMigrateUsage = errors.New("invalid migrate usage")
+
+// migrate runs a migration subcommand against the primary database using
+// the migration credentials.
+func migrate(ctx context.Context, out io.Writer, conf config.Configuration, observer observability.Observer, args []string) error {
+	if !validMigrateArgs(args) {
+		return errMigrateUsage
+	}
+
+	migrator, err := db.NewMigratorFromConfig(conf.MigrationDatabase(), observer)
+
+	if err != nil {
+		return err
+	}
+
+	defer migrator.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		fmt.Fprintf(out, "applied %d migration(s)\n", applied)
+		return err
+	case "down":
+		steps := 0
+
+		if len(args) == 2 {
+			steps, _ = strconv.Atoi(args[1])
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		fmt.Fprintf(out, "reverted %d migration(s)\n", reverted)
+		return err
+	case "status":
+		status, err := migrator.Status(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		printMigrationStatus(out, status)
+		return nil
+	case "force":
+		version, _ := strconv.ParseInt(args[1], 10, 64)
+
+		if err := migrator.Force(ctx, version); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "forced version %d\n", version)
+		return nil
+	}
+
+	return errMigrateUsage
+}
+
+func validMigrateArgs(args []string) bool {
+	switch {
+	case len(args) == 1:
+		return args[0] == "up" || args[0] == "down" || args[0] == "status"
+	case len(args) == 2 && args[0] == "down":
+		steps, err := strconv.Atoi(args[1])
+		return err == nil && steps > 0
+	case len(args) == 2 && args[0] == "force":
+		_, err := strconv.ParseInt(args[1], 10, 64)
+		return err == nil
+	}
+
+	return false
+}
+
+// migrateOnStart applies pending migrations before the servers start.
+// The advisory lock makes concurrent replicas wait for the first one.
+func migrateOnStart(ctx context.Context, conf config.Configuration, observer observability.Observer) error {
+	migrator, err := db.NewMigratorFromConfig(conf.MigrationDatabase(), observer)
+
+	if err != nil {
+		return err
+	}
+
+	defer migrator.Close()
+
+	_, err = migrator.Up(ctx)
+	return err
+}
+
+func printMigrationStatus(out io.Writer, status db.MigrationStatus) {
+	fmt.Fprintf(out, "version: %d", status.Version)
+
+	if status.Dirty {
+		fmt.Fprint(out, " (dirty)")
+	}
+
+	fmt.Fprint(out, "\n\n")
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE")
+
+	for _, m := range status.Applied {
+		state := "applied"
+
+		if status.Dirty && m.Version == status.Version {
+			state = "dirty"
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\n", m.Version, m.Name, state)
+	}
+
+	for _, m := range status.Pending {
+		fmt.Fprintf(w, "%d\t%s\tpending\n", m.Version, m.Name)
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/cache"
+)
+
+func (c *cli) cacheCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "purge" {
+		return fmt.Errorf("%w: cache expects the purge subcommand", errUsage)
+	}
+
+	flags := newFlagSet("cache purge")
+	key := flags.String("key", "", "exact cache key to evict")
+	family := flags.String("family", "", "key family to evict, e.g. url-service/id")
+
+	if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 0 {
+		return fmt.Errorf("%w: cache purge accepts only --key or --family", errUsage)
+	}
+
+	if (*key == "") == (*family == "") {
+		return fmt.Errorf("%w: cache purge expects exactly one of --key or --family", errUsage)
+	}
+
+	client, err := c.cacheClient()
+
+	if err != nil {
+		return err
+	}
+
+	if *key != "" {
+		err := client.Del(ctx, *key)
+
+		if err != nil && !errors.Is(err, db.ErrCacheNotFound) {
+			return err
+		}
+
+		return c.printResult(map[string]any{"key": *key, "status": "purged"})
+	}
+
+	purged, err := client.Purge(ctx, cache.FamilyPrefix(*family))
+
+	if err != nil {
+		return err
+	}
+
+	return c.printResult(map[string]any{"family": *family, "purged": purged})
+}
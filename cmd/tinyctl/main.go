@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/repository"
+)
+
+var version string = "0.0.1"
+
+const usage = `tinyctl manages tiny-url links directly against the service storage.
+
+Usage:
+  tinyctl [--json] <command> [arguments]
+
+Commands:
+  create [--owner O] [--expires-in D] <target>
+                                         shorten a new target URL
+  get <id> | get --code <code>           show a link by ID or short code
+  list [--limit N] [--cursor C] [--order asc|desc] [--q T] [--domain D] [--code P] [--owner O] [--status S]
+                                         list links, newest first
+  disable <id>                           stop a link from redirecting
+  delete <id>                            soft-delete a link
+  encode <id>                            print the short code for an ID
+  decode <code>                          print the ID for a short code
+  cache purge --key K | --family F       evict a cache key or key family
+
+Configuration is read from the same environment variables as the API.
+`
+
+// errUsage reports invalid command line arguments. The usage text is
+// printed and the process exits with status 2.
+var errUsage = errors.New("invalid usage")
+
+type cli struct {
+	stdout io.Writer
+	json   bool
+
+	conf     config.Configuration
+	observer observability.Observer
+	repo     *repository.Repositories
+	cache    db.CacheClient
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	flags := flag.NewFlagSet("tinyctl", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	asJSON := flags.Bool("json", false, "print output as JSON")
+
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+
+	conf := config.NewConfiguration()
+	c := &cli{
+		stdout:   os.Stdout,
+		json:     *asJSON,
+		conf:     conf,
+		observer: observability.NewObserver(version, conf),
+	}
+	defer c.close()
+
+	if err := c.run(ctx, flags.Args()); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "%s\n\n%s", err, usage)
+			c.close()
+			os.Exit(2)
+		}
+
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		c.close()
+		os.Exit(1)
+	}
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing command", errUsage)
+	}
+
+	command, args := args[0], args[1:]
+
+	switch command {
+	case "create":
+		return c.create(ctx, args)
+	case "get":
+		return c.get(ctx, args)
+	case "list":
+		return c.list(ctx, args)
+	case "disable":
+		return c.disable(ctx, args)
+	case "delete":
+		return c.delete(ctx, args)
+	case "encode":
+		return c.encode(args)
+	case "decode":
+		return c.decode(args)
+	case "cache":
+		return c.cacheCommand(ctx, args)
+	case "help":
+		fmt.Fprint(c.stdout, usage)
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
+}
+
+// repositories lazily connects to the database so commands that do not
+// need storage, such as encode and decode, work without configuration.
+func (c *cli) repositories() (repo repository.Repositories, err error) {
+	if c.repo != nil {
+		return *c.repo, nil
+	}
+
+	// NewRepositoriesFromConfig panics when the storage is unreachable,
+	// which is the right call for the API but not for a CLI.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	repo = repository.NewRepositoriesFromConfig(c.conf, c.observer)
+	c.repo = &repo
+
+	return repo, nil
+}
+
+func (c *cli) cacheClient() (db.CacheClient, error) {
+	if c.cache != nil {
+		return c.cache, nil
+	}
+
+	client, err := db.NewCacheClient(c.conf.Cache(), c.observer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache = client
+	return client, nil
+}
+
+func (c *cli) close() {
+	if c.repo != nil {
+		c.repo.Shutdown()
+		c.repo = nil
+	}
+
+	if c.cache != nil {
+		c.cache.Close()
+		c.cache = nil
+	}
+}
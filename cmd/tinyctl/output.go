@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/zeon-code/tiny-url/internal/model"
+)
+
+type urlList struct {
+	Items []model.URL `json:"items"`
+	Next  *string     `json:"next,omitempty"`
+}
+
+// printURLs writes links as a table, or as {"items": [...], "next": "..."}
+// when --json is set. The next cursor is omitted on the last page.
+func (c *cli) printURLs(urls []model.URL, next *string) error {
+	if c.json {
+		return c.printJSON(urlList{Items: urls, Next: next})
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCODE\tSTATUS\tTARGET\tCREATED")
+
+	for _, url := range urls {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", url.ID, url.Code, status(url), url.Target, formatTime(url.CreatedAt))
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if next != nil {
+		fmt.Fprintf(c.stdout, "\nnext cursor: %s\n", *next)
+	}
+
+	return nil
+}
+
+// printResult writes a flat result as "key: value" lines, sorted by key,
+// or as a JSON object when --json is set.
+func (c *cli) printResult(result map[string]any) error {
+	if c.json {
+		return c.printJSON(result)
+	}
+
+	keys := make([]string, 0, len(result))
+
+	for key := range result {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 1, ' ', 0)
+
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s:\t%v\n", key, result[key])
+	}
+
+	return w.Flush()
+}
+
+func (c *cli) printJSON(value any) error {
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func status(url model.URL) string {
+	return string(url.Status(time.Now()))
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
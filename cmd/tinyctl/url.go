@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/base62"
+	"github.com/zeon-code/tiny-url/internal/pkg/cache"
+	"github.com/zeon-code/tiny-url/internal/pkg/pagination"
+	"github.com/zeon-code/tiny-url/internal/service"
+)
+
+func (c *cli) create(ctx context.Context, args []string) error {
+	flags := newFlagSet("create")
+	owner := flags.String("owner", "", "owner notified through its webhook subscriptions")
+	expiresIn := flags.Duration("expires-in", 0, "disable the link after this duration, e.g. 72h")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return fmt.Errorf("%w: create expects a target URL", errUsage)
+	}
+
+	if *expiresIn < 0 {
+		return fmt.Errorf("%w: --expires-in must be a positive duration", errUsage)
+	}
+
+	var expiresAt *time.Time
+
+	if *expiresIn > 0 {
+		at := time.Now().Add(*expiresIn)
+		expiresAt = &at
+	}
+
+	repo, err := c.repositories()
+
+	if err != nil {
+		return err
+	}
+
+	url, err := repo.Url.Create(ctx, flags.Arg(0), *owner, expiresAt)
+
+	if err != nil {
+		return err
+	}
+
+	return c.printURLs([]model.URL{*url}, nil)
+}
+
+func (c *cli) get(ctx context.Context, args []string) error {
+	flags := newFlagSet("get")
+	code := flags.String("code", "", "look the link up by short code")
+
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %s", errUsage, err)
+	}
+
+	var id int64
+	var err error
+
+	switch {
+	case *code != "" && flags.NArg() == 0:
+		if !base62.IsValid(*code) {
+			return fmt.Errorf("%w: invalid short code %q", errUsage, *code)
+		}
+
+		id = base62.Decode(*code)
+	case *code == "" && flags.NArg() == 1:
+		if id, err = parseID(flags.Arg(0)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: get expects an ID or --code", errUsage)
+	}
+
+	repo, err := c.repositories()
+
+	if err != nil {
+		return err
+	}
+
+	url, err := repo.Url.GetByID(ctx, id)
+
+	if errors.Is(err, db.ErrDBResourceNotFound) {
+		return fmt.Errorf("link %d not found", id)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return c.printURLs([]model.URL{*url}, nil)
+}
+
+// list pages through links with keyset cursors: "<code" for links older
+// than code and ">code" for newer ones. Unlike the API's, they are not
+// signed, as tinyctl reads the storage directly.
+func (c *cli) list(ctx context.Context, args []string) error {
+	flags := newFlagSet("list")
+	limit := flags.Int("limit", 50, "maximum number of links to print")
+	cursor := flags.String("cursor", "", "page cursor printed by a previous list")
+	order := flags.String("order", "desc", "list newest (desc) or oldest (asc) links first")
+	filter := model.URLFilter{}
+	flags.StringVar(&filter.Query, "q", "", "only links whose target contains this text")
+	flags.StringVar(&filter.Domain, "domain", "", "only links to this domain or its subdomains")
+	flags.StringVar(&filter.CodePrefix, "code", "", "only links whose code starts with this prefix")
+	flags.StringVar(&filter.Owner, "owner", "", "only links of this owner")
+	status := flags.String("status", "", "only active, disabled or expired links")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return fmt.Errorf("%w: list accepts only flags", errUsage)
+	}
+
+	filter.Status = model.URLStatus(*status)
+
+	if filter.Status != "" && !slices.Contains(model.URLStatuses, filter.Status) {
+		return fmt.Errorf("%w: --status must be active, disabled or expired", errUsage)
+	}
+
+	if *limit <= 0 {
+		return fmt.Errorf("%w: --limit must be positive", errUsage)
+	}
+
+	page := pagination.Query{Limit: *limit, Order: pagination.Order(*order), Direction: pagination.Forward}
+
+	if page.Order != pagination.Ascending && page.Order != pagination.Descending {
+		return fmt.Errorf("%w: --order must be asc or desc", errUsage)
+	}
+
+	operator, after, err := parseCursor(*cursor)
+
+	if err != nil {
+		return err
+	}
+
+	if after != nil {
+		page.Key = after
+
+		if operator != page.Operator() {
+			page.Direction = pagination.Backward
+		}
+	}
+
+	repo, err := c.repositories()
+
+	if err != nil {
+		return err
+	}
+
+	urls, err := repo.Url.List(ctx, page, filter)
+
+	if err != nil {
+		return err
+	}
+
+	var next *string
+	listing := pagination.NewPagination(urls, page, "")
+
+	if listing.HasNext() {
+		forward := pagination.Query{Order: page.Order, Direction: pagination.Forward}
+		value := forward.Operator() + base62.Encode(listing.Items[len(listing.Items)-1].ID)
+		next = &value
+	}
+
+	return c.printURLs(listing.Items, next)
+}
+
+func (c *cli) disable(ctx context.Context, args []string) error {
+	return c.mutate(ctx, "disable", args, func(ctx context.Context, id int64) error {
+		repo, err := c.repositories()
+
+		if err != nil {
+			return err
+		}
+
+		return repo.Url.Disable(ctx, id)
+	})
+}
+
+func (c *cli) delete(ctx context.Context, args []string) error {
+	return c.mutate(ctx, "delete", args, func(ctx context.Context, id int64) error {
+		repo, err := c.repositories()
+
+		if err != nil {
+			return err
+		}
+
+		return repo.Url.Delete(ctx, id)
+	})
+}
+
+// mutate applies a state change to a single link and evicts the cached
+// entries that could still serve the previous state.
+func (c *cli) mutate(ctx context.Context, name string, args []string, apply func(context.Context, int64) error) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: %s expects an ID", errUsage, name)
+	}
+
+	id, err := parseID(args[0])
+
+	if err != nil {
+		return err
+	}
+
+	if err := apply(ctx, id); errors.Is(err, db.ErrDBResourceNotFound) {
+		return fmt.Errorf("link %d not found", id)
+	} else if err != nil {
+		return err
+	}
+
+	client, err := c.cacheClient()
+
+	if err != nil {
+		return fmt.Errorf("link %d updated but cache was not evicted: %w", id, err)
+	}
+
+	key := service.URLCacheKey.With("id", id)
+
+	if err := client.Del(ctx, key.String()); err != nil && !errors.Is(err, db.ErrCacheNotFound) {
+		return fmt.Errorf("link %d updated but cache was not evicted: %w", id, err)
+	}
+
+	if _, err := client.Purge(ctx, cache.FamilyPrefix(service.URLCacheKey.With("list").Family())); err != nil {
+		return fmt.Errorf("link %d updated but cache was not evicted: %w", id, err)
+	}
+
+	return c.printResult(map[string]any{"id": id, "status": name + "d"})
+}
+
+func (c *cli) encode(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: encode expects an ID", errUsage)
+	}
+
+	id, err := parseID(args[0])
+
+	if err != nil {
+		return err
+	}
+
+	return c.printResult(map[string]any{"id": id, "code": base62.Encode(id)})
+}
+
+func (c *cli) decode(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: decode expects a short code", errUsage)
+	}
+
+	if !base62.IsValid(args[0]) {
+		return fmt.Errorf("%w: invalid short code %q", errUsage, args[0])
+	}
+
+	return c.printResult(map[string]any{"id": base62.Decode(args[0]), "code": args[0]})
+}
+
+func parseID(value string) (int64, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: invalid ID %q", errUsage, value)
+	}
+
+	return id, nil
+}
+
+func parseCursor(value string) (string, *int64, error) {
+	if value == "" {
+		return "<", nil, nil
+	}
+
+	direction, code := value[0:1], value[1:]
+
+	if (direction != "<" && direction != ">") || !base62.IsValid(code) {
+		return "", nil, fmt.Errorf("%w: invalid cursor %q", errUsage, value)
+	}
+
+	id := base62.Decode(code)
+	return direction, &id, nil
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
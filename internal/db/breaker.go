@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+// CircuitBreakerPolicy controls when the cache circuit trips and how long
+// it stays open before probing the cache again.
+type CircuitBreakerPolicy struct {
+	// Threshold is the number of consecutive failures that opens the circuit.
+	Threshold int
+	// Cooldown is how long the circuit stays open before a probe is allowed.
+	Cooldown time.Duration
+}
+
+// DefaultCircuitBreakerPolicy trips after five consecutive failures and
+// probes the cache again after five seconds.
+var DefaultCircuitBreakerPolicy = CircuitBreakerPolicy{Threshold: 5, Cooldown: 5 * time.Second}
+
+// CircuitBreakerCacheClient decorates a CacheClient with a circuit breaker,
+// so a slow or failing cache stops adding its timeout to every request.
+//
+// Consecutive errors and timeouts open the circuit; while open, every
+// operation returns ErrCacheUnavailable without reaching the cache. Once
+// the cooldown elapses a single half-open probe is let through: its success
+// closes the circuit and its failure opens it again. Misses, invalid values
+// and canceled requests say nothing about the cache health and are ignored.
+type CircuitBreakerCacheClient struct {
+	client CacheClient
+	policy CircuitBreakerPolicy
+
+	mu       sync.Mutex
+	state    observability.CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+
+	metric observability.MetricClient
+	logger observability.Logger
+}
+
+func NewCircuitBreakerCacheClient(client CacheClient, policy CircuitBreakerPolicy, observer observability.Observer) *CircuitBreakerCacheClient {
+	logger := observer.Logger().With("client", "cache-breaker")
+	metric, err := observer.Metric()
+
+	if err != nil {
+		logger.Error(context.Background(), "error building metric client", slog.Any("error", err))
+		metric = observability.NewNoopMetricClient()
+	}
+
+	return &CircuitBreakerCacheClient{
+		client: client,
+		policy: policy,
+		state:  observability.CircuitClosed,
+		metric: metric,
+		logger: logger,
+	}
+}
+
+// State returns the current state of the circuit.
+func (c *CircuitBreakerCacheClient) State() observability.CircuitState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.state
+}
+
+func (c *CircuitBreakerCacheClient) Ping(ctx context.Context) error {
+	_, err := guard(c, ctx, func() (struct{}, error) { return struct{}{}, c.client.Ping(ctx) })
+	return err
+}
+
+func (c *CircuitBreakerCacheClient) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := guard(c, ctx, func() ([]byte, error) { return c.client.Get(ctx, key) })
+
+	if data == nil {
+		data = []byte{}
+	}
+
+	return data, err
+}
+
+func (c *CircuitBreakerCacheClient) Set(ctx context.Context, value any, key string, ttl time.Duration) error {
+	_, err := guard(c, ctx, func() (struct{}, error) { return struct{}{}, c.client.Set(ctx, value, key, ttl) })
+	return err
+}
+
+func (c *CircuitBreakerCacheClient) SetNX(ctx context.Context, value any, key string, ttl time.Duration) (bool, error) {
+	return guard(c, ctx, func() (bool, error) { return c.client.SetNX(ctx, value, key, ttl) })
+}
+
+func (c *CircuitBreakerCacheClient) Del(ctx context.Context, key string) error {
+	_, err := guard(c, ctx, func() (struct{}, error) { return struct{}{}, c.client.Del(ctx, key) })
+	return err
+}
+
+func (c *CircuitBreakerCacheClient) Incr(ctx context.Context, key string) (int64, error) {
+	return guard(c, ctx, func() (int64, error) { return c.client.Incr(ctx, key) })
+}
+
+func (c *CircuitBreakerCacheClient) Purge(ctx context.Context, prefix string) (int64, error) {
+	return guard(c, ctx, func() (int64, error) { return c.client.Purge(ctx, prefix) })
+}
+
+// Close closes the underlying client regardless of the circuit state.
+func (c *CircuitBreakerCacheClient) Close() error {
+	return c.client.Close()
+}
+
+// guard runs fn when the circuit allows it and records its outcome.
+func guard[T any](c *CircuitBreakerCacheClient, ctx context.Context, fn func() (T, error)) (T, error) {
+	var zero T
+	allowed, probe := c.allow(ctx)
+
+	if !allowed {
+		return zero, ErrCacheUnavailable
+	}
+
+	value, err := fn()
+	c.record(ctx, err, probe)
+
+	return value, err
+}
+
+// allow reports whether a call may reach the cache and whether it is the
+// half-open probe, moving an open circuit to half-open once the cooldown
+// elapsed. Only one probe runs at a time.
+func (c *CircuitBreakerCacheClient) allow(ctx context.Context) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case observability.CircuitOpen:
+		if time.Since(c.openedAt) < c.policy.Cooldown {
+			return false, false
+		}
+
+		c.transition(ctx, observability.CircuitHalfOpen)
+		c.probing = true
+
+		return true, true
+	case observability.CircuitHalfOpen:
+		if c.probing {
+			return false, false
+		}
+
+		c.probing = true
+		return true, true
+	}
+
+	return true, false
+}
+
+func (c *CircuitBreakerCacheClient) record(ctx context.Context, err error, probe bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if probe {
+		c.probing = false
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	failed := cacheFailure(err)
+
+	switch {
+	case probe && failed:
+		c.open(ctx)
+	case probe:
+		c.failures = 0
+		c.transition(ctx, observability.CircuitClosed)
+	case c.state != observability.CircuitClosed:
+		// Outcome of a call started before the circuit opened.
+	case failed:
+		c.failures++
+
+		if c.failures >= c.policy.Threshold {
+			c.open(ctx)
+		}
+	default:
+		c.failures = 0
+	}
+}
+
+func (c *CircuitBreakerCacheClient) open(ctx context.Context) {
+	c.openedAt = time.Now()
+	c.transition(ctx, observability.CircuitOpen)
+}
+
+// transition changes the state, exporting and logging it. Callers must
+// hold the lock.
+func (c *CircuitBreakerCacheClient) transition(ctx context.Context, state observability.CircuitState) {
+	if c.state == state {
+		return
+	}
+
+	previous := c.state
+	c.state = state
+	c.metric.CacheCircuit(ctx, state)
+
+	attrs := []any{slog.String("from", string(previous)), slog.String("to", string(state))}
+
+	if state == observability.CircuitOpen {
+		c.logger.Warn(ctx, "cache circuit opened", append(attrs, slog.Int("failures", c.failures))...)
+		return
+	}
+
+	c.logger.Info(ctx, "cache circuit state changed", attrs...)
+}
+
+// cacheFailure reports whether err means the cache itself is unhealthy.
+func cacheFailure(err error) bool {
+	return errors.Is(err, ErrCacheUnavailable) || errors.Is(err, context.DeadlineExceeded)
+}
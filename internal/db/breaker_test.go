@@ -0,0 +1,137 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+)
+
+func TestCircuitBreakerCacheClient(t *testing.T) {
+	ctx := context.Background()
+	errDown := errors.New("connection refused")
+
+	newBreaker := func(backend *test.FakeRedis, cooldown time.Duration) (*db.CircuitBreakerCacheClient, *test.FakeMetric) {
+		metric := test.NewFakeMetric()
+		policy := db.CircuitBreakerPolicy{Threshold: 3, Cooldown: cooldown}
+
+		return db.NewCircuitBreakerCacheClient(backend.Client(), policy, test.NewFakeObserver(metric)), metric
+	}
+
+	t.Run("stays closed on misses", func(t *testing.T) {
+		backend := test.NewFakeRedisBackend()
+		breaker, metric := newBreaker(backend, time.Minute)
+		backend.Err = redis.Nil
+
+		for range 5 {
+			_, err := breaker.Get(ctx, "key")
+			assert.ErrorIs(t, err, db.ErrCacheNotFound)
+		}
+
+		assert.Equal(t, observability.CircuitClosed, breaker.State())
+		assert.Empty(t, metric.CacheCircuitStates)
+	})
+
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		backend := test.NewFakeRedisBackend()
+		breaker, metric := newBreaker(backend, time.Minute)
+		backend.Err = errDown
+
+		for range 3 {
+			_, err := breaker.Get(ctx, "key")
+			assert.ErrorIs(t, err, db.ErrCacheUnavailable)
+		}
+
+		assert.Equal(t, observability.CircuitOpen, breaker.State())
+		assert.Equal(t, []observability.CircuitState{observability.CircuitOpen}, metric.CacheCircuitStates)
+	})
+
+	t.Run("success resets the failure count", func(t *testing.T) {
+		backend := test.NewFakeRedisBackend()
+		breaker, _ := newBreaker(backend, time.Minute)
+
+		backend.Err = errDown
+		breaker.Del(ctx, "key")
+		breaker.Del(ctx, "key")
+
+		backend.Err = nil
+		breaker.Del(ctx, "key")
+
+		backend.Err = errDown
+		breaker.Del(ctx, "key")
+		breaker.Del(ctx, "key")
+
+		assert.Equal(t, observability.CircuitClosed, breaker.State())
+	})
+
+	t.Run("short circuits while open", func(t *testing.T) {
+		backend := test.NewFakeRedisBackend()
+		breaker, _ := newBreaker(backend, time.Minute)
+		backend.Err = errDown
+
+		for range 3 {
+			breaker.Set(ctx, "value", "key", time.Minute)
+		}
+
+		backend.Err = nil
+		backend.LastSetKey = ""
+
+		assert.ErrorIs(t, breaker.Set(ctx, "value", "other", time.Minute), db.ErrCacheUnavailable)
+		assert.Equal(t, "", backend.LastSetKey)
+	})
+
+	t.Run("successful probe closes the circuit", func(t *testing.T) {
+		backend := test.NewFakeRedisBackend()
+		breaker, metric := newBreaker(backend, time.Millisecond)
+		backend.Err = errDown
+
+		for range 3 {
+			breaker.Ping(ctx)
+		}
+
+		time.Sleep(2 * time.Millisecond)
+		backend.Err = nil
+
+		assert.NoError(t, breaker.Ping(ctx))
+		assert.Equal(t, observability.CircuitClosed, breaker.State())
+		assert.Equal(t, []observability.CircuitState{
+			observability.CircuitOpen,
+			observability.CircuitHalfOpen,
+			observability.CircuitClosed,
+		}, metric.CacheCircuitStates)
+	})
+
+	t.Run("failed probe opens the circuit again", func(t *testing.T) {
+		backend := test.NewFakeRedisBackend()
+		breaker, _ := newBreaker(backend, time.Millisecond)
+		backend.Err = errDown
+
+		for range 3 {
+			breaker.Ping(ctx)
+		}
+
+		time.Sleep(2 * time.Millisecond)
+
+		assert.ErrorIs(t, breaker.Ping(ctx), db.ErrCacheUnavailable)
+		assert.Equal(t, observability.CircuitOpen, breaker.State())
+	})
+
+	t.Run("timeouts count as failures", func(t *testing.T) {
+		backend := test.NewFakeRedisBackend()
+		breaker, _ := newBreaker(backend, time.Minute)
+		backend.Err = context.DeadlineExceeded
+
+		for range 3 {
+			_, err := breaker.Incr(ctx, "key")
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		}
+
+		assert.Equal(t, observability.CircuitOpen, breaker.State())
+	})
+}
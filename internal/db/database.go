@@ -3,6 +3,8 @@ package db
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/zeon-code/tiny-url/internal/pkg/config"
@@ -17,7 +19,16 @@ type SQLReader interface {
 	Ping(context.Context) error
 }
 
+// Transactor runs functions atomically. On a SQLClient each call is a new
+// transaction; on a SQLTX it is a savepoint nested in the open transaction,
+// so helpers accepting a Transactor compose with any caller.
+type Transactor interface {
+	WithTx(context.Context, *sql.TxOptions, func(SQLTX) error) error
+}
+
 type SQLTX interface {
+	Transactor
+
 	Commit() error
 	Rollback() error
 
@@ -28,13 +39,83 @@ type SQLTX interface {
 
 type SQLClient interface {
 	SQLReader
+	Transactor
 
 	Exec(context.Context, string, ...any) error
 	BeginTx(context.Context, *sql.TxOptions) (SQLTX, error)
+	Dialect() Dialect
 }
 
+// NewDBClient builds the SQLClient matching the configured driver.
 func NewDBClient(conf config.DatabaseConfiguration, observer observability.Observer) (SQLClient, error) {
-	return NewPostgresClientFromConfig(conf, observer)
+	switch conf.Driver() {
+	case "sqlite":
+		return NewSQLiteClientFromConfig(conf, observer)
+	default:
+		return NewPostgresClientFromConfig(conf, observer)
+	}
+}
+
+// Dialect identifies the SQL flavour spoken by a SQLClient, so repositories
+// can adapt the few expressions that differ between backends. Both dialects
+// accept $N placeholders and RETURNING clauses.
+type Dialect string
+
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// Now returns the expression for the current timestamp.
+func (d Dialect) Now() string {
+	if d == DialectSQLite {
+		return "CURRENT_TIMESTAMP"
+	}
+
+	return "now()"
+}
+
+// Contains returns the condition matching rows whose column contains the
+// text bound to placeholder, ignoring ASCII case. The text must be escaped
+// with EscapeLike.
+func (d Dialect) Contains(column string, placeholder string) string {
+	operator := "ILIKE"
+
+	if d == DialectSQLite {
+		operator = "LIKE"
+	}
+
+	return fmt.Sprintf(`%s %s '%%' || %s || '%%' ESCAPE '\'`, column, operator, placeholder)
+}
+
+// HasPrefix returns the condition matching rows whose column starts with
+// the text bound to placeholder, respecting case. The text must be
+// alphanumeric, since SQLite matches it as a GLOB pattern.
+func (d Dialect) HasPrefix(column string, placeholder string) string {
+	if d == DialectSQLite {
+		return fmt.Sprintf("%s GLOB %s || '*'", column, placeholder)
+	}
+
+	return fmt.Sprintf("%s LIKE %s || '%%'", column, placeholder)
+}
+
+// EscapeLike escapes the wildcards of text, so Contains matches it
+// literally.
+func EscapeLike(text string) string {
+	return likeEscaper.Replace(text)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// ForUpdate returns the clause locking the selected rows until the
+// transaction ends. SQLite locks the whole database on write instead, so it
+// has none.
+func (d Dialect) ForUpdate() string {
+	if d == DialectSQLite {
+		return ""
+	}
+
+	return " FOR UPDATE"
 }
 
 type CacheClient interface {
@@ -42,10 +123,25 @@ type CacheClient interface {
 	Del(context.Context, string) error
 	Get(context.Context, string) ([]byte, error)
 	Set(context.Context, any, string, time.Duration) error
+	SetNX(context.Context, any, string, time.Duration) (bool, error)
 	Incr(context.Context, string) (int64, error)
+	Purge(context.Context, string) (int64, error)
 	Close() error
 }
 
+// NewCacheClient builds the CacheClient matching the configured driver.
+// Redis is guarded by a circuit breaker so an unhealthy server is skipped
+// instead of costing its timeout on every call.
 func NewCacheClient(conf config.DatabaseConfiguration, observer observability.Observer) (CacheClient, error) {
-	return NewRedisClientFromConfig(conf, observer)
+	if local, ok := conf.(config.LocalCacheConfiguration); ok && conf.Driver() == "local" {
+		return NewLocalCacheClientFromConfig(local, observer)
+	}
+
+	client, err := NewRedisClientFromConfig(conf, observer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCircuitBreakerCacheClient(client, DefaultCircuitBreakerPolicy, observer), nil
 }
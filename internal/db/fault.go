@@ -3,14 +3,37 @@ package db
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
 
+	"github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
 var (
 	ErrDBInvalidBackend   = errors.New("error db invalid backend instance")
 	ErrDBResourceNotFound = errors.New("error db resource not found")
+
+	// ErrDBTransient marks failures expected to go away on their own, such
+	// as serialization failures, deadlocks, dropped connections and a
+	// primary failing over. Retrying the operation may succeed.
+	ErrDBTransient = errors.New("error db transient failure")
+	// ErrDBConflict marks writes colliding with an existing row, such as a
+	// duplicated code. Returned errors are *ConstraintError values.
+	ErrDBConflict = errors.New("error db conflict")
+	// ErrDBConstraint marks writes rejected by any other integrity
+	// constraint. Returned errors are *ConstraintError values.
+	ErrDBConstraint = errors.New("error db constraint violation")
+
+	ErrMigrationDirty          = errors.New("error migration database is dirty, fix it and force a version")
+	ErrMigrationUnknownVersion = errors.New("error migration version not found")
 )
 
 func mapDBError(err error) error {
@@ -23,12 +46,140 @@ func mapDBError(err error) error {
 		return err
 	}
 
-	return err
+	switch class := classifyDBError(err); class {
+	case nil:
+		return err
+	case ErrDBConflict, ErrDBConstraint:
+		return &ConstraintError{Kind: class, Constraint: constraintName(err), err: err}
+	default:
+		return fmt.Errorf("%w: %w", class, err)
+	}
+}
+
+// ConstraintError reports a write rejected by an integrity constraint. It
+// matches its Kind, ErrDBConflict or ErrDBConstraint, with errors.Is and
+// keeps the driver error reachable through errors.As.
+type ConstraintError struct {
+	Kind error
+	// Constraint names the violated constraint, or the column for SQLite,
+	// when the driver reports it.
+	Constraint string
+
+	err error
+}
+
+func (e *ConstraintError) Error() string {
+	if e.Constraint == "" {
+		return fmt.Sprintf("%s: %s", e.Kind, e.err)
+	}
+
+	return fmt.Sprintf("%s on %s: %s", e.Kind, e.Constraint, e.err)
+}
+
+func (e *ConstraintError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.err
+}
+
+// constraintName returns the constraint reported by the driver. SQLite only
+// names the column, as in "UNIQUE constraint failed: urls.code (2067)".
+func constraintName(err error) string {
+	var pqErr *pq.Error
+
+	if errors.As(err, &pqErr) {
+		return pqErr.Constraint
+	}
+
+	var sqliteErr *sqlite.Error
+
+	if errors.As(err, &sqliteErr) {
+		message := sqliteErr.Error()
+		marker := "constraint failed: "
+
+		if i := strings.LastIndex(message, marker); i >= 0 {
+			name, _, _ := strings.Cut(message[i+len(marker):], " (")
+			return name
+		}
+	}
+
+	return ""
+}
+
+// classifyDBError returns the domain error describing err, or nil for
+// permanent errors, which are returned unchanged. The driver error stays
+// reachable through errors.As.
+func classifyDBError(err error) error {
+	var pqErr *pq.Error
+
+	if errors.As(err, &pqErr) {
+		return classifySQLState(pqErr.Code)
+	}
+
+	var sqliteErr *sqlite.Error
+
+	if errors.As(err, &sqliteErr) {
+		switch code := sqliteErr.Code(); {
+		case code&0xff == sqlite3.SQLITE_BUSY, code&0xff == sqlite3.SQLITE_LOCKED:
+			return ErrDBTransient
+		case code == sqlite3.SQLITE_CONSTRAINT_UNIQUE, code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return ErrDBConflict
+		case code&0xff == sqlite3.SQLITE_CONSTRAINT:
+			return ErrDBConstraint
+		}
+
+		return nil
+	}
+
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.As(err, &netErr):
+		return ErrDBTransient
+	}
+
+	return nil
+}
+
+// classifySQLState maps a PostgreSQL SQLSTATE to a domain error, see
+// https://www.postgresql.org/docs/current/errcodes-appendix.html.
+func classifySQLState(code pq.ErrorCode) error {
+	switch code {
+	case "40001", // serialization_failure
+		"40P01", // deadlock_detected
+		"55P03", // lock_not_available
+		"57P01", // admin_shutdown
+		"57P02", // crash_shutdown
+		"57P03", // cannot_connect_now
+		"25006": // read_only_sql_transaction, writing to a demoted primary
+		return ErrDBTransient
+	case "23505", // unique_violation
+		"23P01": // exclusion_violation
+		return ErrDBConflict
+	}
+
+	switch code.Class() {
+	case "08", // connection_exception
+		"53": // insufficient_resources
+		return ErrDBTransient
+	case "23": // integrity_constraint_violation
+		return ErrDBConstraint
+	}
+
+	return nil
 }
 
 var (
-	ErrCacheNotFound    = errors.New("error cache not found")
-	ErrCacheUnavailable = errors.New("error cache unavailable")
+	ErrCacheNotFound     = errors.New("error cache not found")
+	ErrCacheUnavailable  = errors.New("error cache unavailable")
+	ErrCacheInvalidValue = errors.New("error cache value is not an integer")
 )
 
 func mapCacheError(err error) error {
@@ -0,0 +1,151 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+)
+
+func TestDBErrorClassification(t *testing.T) {
+	ctx := context.Background()
+	query := "SELECT name FROM anything WHERE id = $1"
+
+	cases := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"serialization failure", &pq.Error{Code: "40001"}, db.ErrDBTransient},
+		{"deadlock", &pq.Error{Code: "40P01"}, db.ErrDBTransient},
+		{"connection failure", &pq.Error{Code: "08006"}, db.ErrDBTransient},
+		{"too many connections", &pq.Error{Code: "53300"}, db.ErrDBTransient},
+		{"admin shutdown", &pq.Error{Code: "57P01"}, db.ErrDBTransient},
+		{"read only transaction after failover", &pq.Error{Code: "25006"}, db.ErrDBTransient},
+		{"connection lost mid-query", io.ErrUnexpectedEOF, db.ErrDBTransient},
+		{"unique violation", &pq.Error{Code: "23505"}, db.ErrDBConflict},
+		{"exclusion violation", &pq.Error{Code: "23P01"}, db.ErrDBConflict},
+		{"foreign key violation", &pq.Error{Code: "23503"}, db.ErrDBConstraint},
+		{"not null violation", &pq.Error{Code: "23502"}, db.ErrDBConstraint},
+		{"check violation", &pq.Error{Code: "23514"}, db.ErrDBConstraint},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake := test.NewFakeDependencies(t)
+			fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnError(c.err)
+
+			var name string
+			err := fake.DB().Get(ctx, &name, query, 1)
+
+			assert.ErrorIs(t, err, c.expected)
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+
+	t.Run("permanent errors are returned unchanged", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		syntax := &pq.Error{Code: "42601"}
+		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnError(syntax)
+
+		var name string
+		err := fake.DB().Get(ctx, &name, query, 1)
+
+		assert.Equal(t, syntax, err)
+
+		for _, class := range []error{db.ErrDBTransient, db.ErrDBConflict, db.ErrDBConstraint} {
+			assert.False(t, errors.Is(err, class))
+		}
+	})
+
+	constraints := []struct {
+		name       string
+		err        *pq.Error
+		expected   error
+		constraint string
+	}{
+		{"unique violation carries its constraint", &pq.Error{Code: "23505", Constraint: "urls_code_key"}, db.ErrDBConflict, "urls_code_key"},
+		{"exclusion violation carries its constraint", &pq.Error{Code: "23P01", Constraint: "urls_alias_excl"}, db.ErrDBConflict, "urls_alias_excl"},
+		{"foreign key violation carries its constraint", &pq.Error{Code: "23503", Constraint: "events_url_id_fkey"}, db.ErrDBConstraint, "events_url_id_fkey"},
+		{"check violation carries its constraint", &pq.Error{Code: "23514", Constraint: "urls_target_check"}, db.ErrDBConstraint, "urls_target_check"},
+		{"not null violation without constraint", &pq.Error{Code: "23502", Column: "target"}, db.ErrDBConstraint, ""},
+	}
+
+	for _, c := range constraints {
+		t.Run(c.name, func(t *testing.T) {
+			fake := test.NewFakeDependencies(t)
+			query := "INSERT INTO urls (target, code) VALUES ($1, $2)"
+			fake.DBMock.ExpectExec(query).WithArgs("target", "1").WillReturnError(c.err)
+
+			var constraintErr *db.ConstraintError
+			err := fake.DB().Exec(ctx, query, "target", "1")
+
+			assert.ErrorIs(t, err, c.expected)
+			assert.ErrorAs(t, err, &constraintErr)
+			assert.Equal(t, c.constraint, constraintErr.Constraint)
+		})
+	}
+
+	t.Run("transaction errors are classified", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		query := "INSERT INTO urls (target, code) VALUES ($1, $2)"
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectExec(query).WithArgs("target", "1").WillReturnError(&pq.Error{Code: "23505", Constraint: "urls_code_key"})
+
+		tx, err := fake.DB().BeginTx(ctx, nil)
+		assert.NoError(t, err)
+
+		err = tx.Exec(ctx, query, "target", "1")
+
+		assert.ErrorIs(t, err, db.ErrDBConflict)
+		assert.ErrorContains(t, err, "urls_code_key")
+	})
+
+	t.Run("driver error stays reachable", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnError(&pq.Error{Code: "23505", Constraint: "urls_code_key"})
+
+		var name string
+		var pqErr *pq.Error
+		err := fake.DB().Get(ctx, &name, query, 1)
+
+		assert.ErrorAs(t, err, &pqErr)
+		assert.Equal(t, "urls_code_key", pqErr.Constraint)
+	})
+}
+
+func TestDBErrorClassificationOnSQLite(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("duplicated key is a conflict", func(t *testing.T) {
+		client := test.NewSQLiteClient(t)
+		query := "INSERT INTO urls (id, target, code) VALUES ($1, $2, $3)"
+
+		require.NoError(t, client.Exec(ctx, query, 1, "target", "1"))
+		err := client.Exec(ctx, query, 1, "target", "1")
+
+		var constraintErr *db.ConstraintError
+
+		assert.ErrorIs(t, err, db.ErrDBConflict)
+		assert.ErrorAs(t, err, &constraintErr)
+		assert.Equal(t, "urls.id", constraintErr.Constraint)
+	})
+
+	t.Run("missing value is a constraint violation", func(t *testing.T) {
+		client := test.NewSQLiteClient(t)
+		err := client.Exec(ctx, "INSERT INTO urls (target, code) VALUES ($1, $2)", nil, "1")
+
+		var constraintErr *db.ConstraintError
+
+		assert.ErrorIs(t, err, db.ErrDBConstraint)
+		assert.ErrorAs(t, err, &constraintErr)
+		assert.Equal(t, "urls.target", constraintErr.Constraint)
+	})
+}
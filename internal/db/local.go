@@ -0,0 +1,332 @@
+package db
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+// localSweepInterval is how often expired entries are removed in the
+// background. Expired entries are never returned, even before a sweep.
+const localSweepInterval = 1 * time.Minute
+
+type localEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func (e *localEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// LocalCacheClient is an in-process CacheClient for single-instance
+// deployments and tests, mirroring the Redis semantics the service relies
+// on: Set only writes missing keys, entries expire after their TTL and Incr
+// counts on decimal values.
+//
+// The cache is bounded by entry count and total value size; when either
+// bound is exceeded the least recently used entries are evicted. It is safe
+// for concurrent use.
+type LocalCacheClient struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	size    int64
+	closed  bool
+
+	maxEntries int
+	maxBytes   int64
+
+	stop   chan struct{}
+	logger observability.Logger
+}
+
+func NewLocalCacheClientFromConfig(conf config.LocalCacheConfiguration, observer observability.Observer) (*LocalCacheClient, error) {
+	maxEntries, err := conf.MaxEntries()
+
+	if err != nil {
+		return nil, err
+	}
+
+	maxBytes, err := conf.MaxBytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLocalCacheClient(maxEntries, maxBytes, observer), nil
+}
+
+func NewLocalCacheClient(maxEntries int, maxBytes int64, observer observability.Observer) *LocalCacheClient {
+	c := &LocalCacheClient{
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		stop:       make(chan struct{}),
+		logger:     observer.Logger().With("client", "local-cache"),
+	}
+
+	go c.sweep(localSweepInterval)
+	return c
+}
+
+// Ping reports whether the cache is still open.
+func (c *LocalCacheClient) Ping(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrCacheUnavailable
+	}
+
+	return nil
+}
+
+// Get returns a copy of the value stored under key, or ErrCacheNotFound
+// when the key is missing or expired.
+func (c *LocalCacheClient) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return []byte{}, ErrCacheUnavailable
+	}
+
+	entry, ok := c.lookup(key, time.Now())
+
+	if !ok {
+		return []byte{}, ErrCacheNotFound
+	}
+
+	return append([]byte(nil), entry.value...), nil
+}
+
+// Set stores value under key with the given TTL, unless the key already
+// holds a live value, matching the SETNX strategy of RedisClient. A zero
+// TTL keeps the entry until it is evicted or deleted.
+//
+// Values larger than the byte bound are silently not cached.
+func (c *LocalCacheClient) Set(ctx context.Context, value any, key string, ttl time.Duration) error {
+	_, err := c.SetNX(ctx, value, key, ttl)
+	return err
+}
+
+// SetNX stores value like Set and reports whether it was stored. It is
+// atomic within the process only, so leases taken on a local cache are not
+// shared with other instances.
+func (c *LocalCacheClient) SetNX(ctx context.Context, value any, key string, ttl time.Duration) (bool, error) {
+	data := localValue(value)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false, ErrCacheUnavailable
+	}
+
+	now := time.Now()
+
+	if _, ok := c.lookup(key, now); ok {
+		return false, nil
+	}
+
+	entry := &localEntry{key: key, value: data}
+
+	if ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
+	}
+
+	c.store(entry)
+	return true, nil
+}
+
+// Del removes key. Deleting a missing key is not an error.
+func (c *LocalCacheClient) Del(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrCacheUnavailable
+	}
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+
+	return nil
+}
+
+// Incr atomically increments the integer stored at key and returns the
+// new value. A missing key is initialized to zero first; the TTL of an
+// existing key is kept.
+func (c *LocalCacheClient) Incr(ctx context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return 0, ErrCacheUnavailable
+	}
+
+	var current int64
+	entry := &localEntry{key: key}
+
+	if existing, ok := c.lookup(key, time.Now()); ok {
+		value, err := strconv.ParseInt(string(existing.value), 10, 64)
+
+		if err != nil {
+			return 0, ErrCacheInvalidValue
+		}
+
+		current = value
+		entry.expiresAt = existing.expiresAt
+	}
+
+	current++
+	entry.value = []byte(strconv.FormatInt(current, 10))
+
+	c.store(entry)
+	return current, nil
+}
+
+// Purge deletes every key starting with prefix and returns how many keys
+// were removed.
+func (c *LocalCacheClient) Purge(ctx context.Context, prefix string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return 0, ErrCacheUnavailable
+	}
+
+	var purged int64
+
+	for key, element := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(element)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// Close releases every entry and stops the background sweep. Further
+// operations return ErrCacheUnavailable.
+func (c *LocalCacheClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	c.closed = true
+	close(c.stop)
+
+	c.entries = map[string]*list.Element{}
+	c.lru.Init()
+	c.size = 0
+
+	return nil
+}
+
+// Len returns the number of entries currently held, including expired
+// entries not swept yet.
+func (c *LocalCacheClient) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// lookup returns the live entry for key, marking it as recently used.
+// Expired entries are removed on access. Callers must hold the lock.
+func (c *LocalCacheClient) lookup(key string, now time.Time) (*localEntry, bool) {
+	element, ok := c.entries[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*localEntry)
+
+	if entry.expired(now) {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(element)
+	return entry, true
+}
+
+// store inserts or replaces entry and evicts the least recently used
+// entries until both bounds hold. Callers must hold the lock.
+func (c *LocalCacheClient) store(entry *localEntry) {
+	if element, ok := c.entries[entry.key]; ok {
+		c.remove(element)
+	}
+
+	if int64(len(entry.value)) > c.maxBytes {
+		return
+	}
+
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	c.size += int64(len(entry.value))
+
+	for c.lru.Len() > c.maxEntries || c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *LocalCacheClient) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*localEntry)
+	delete(c.entries, entry.key)
+	c.size -= int64(len(entry.value))
+}
+
+func (c *LocalCacheClient) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case now := <-ticker.C:
+			c.mu.Lock()
+
+			for element := c.lru.Back(); element != nil; {
+				previous := element.Prev()
+
+				if element.Value.(*localEntry).expired(now) {
+					c.remove(element)
+				}
+
+				element = previous
+			}
+
+			c.mu.Unlock()
+		}
+	}
+}
+
+// localValue converts a value to bytes the way the Redis client writes it.
+func localValue(value any) []byte {
+	switch v := value.(type) {
+	case []byte:
+		return append([]byte(nil), v...)
+	case string:
+		return []byte(v)
+	default:
+		return fmt.Append(nil, v)
+	}
+}
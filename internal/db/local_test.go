@@ -0,0 +1,184 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+)
+
+func TestLocalCacheClient(t *testing.T) {
+	ctx := context.Background()
+
+	newClient := func(t *testing.T, maxEntries int, maxBytes int64) *db.LocalCacheClient {
+		client := db.NewLocalCacheClient(maxEntries, maxBytes, test.NewFakeObserver(test.NewFakeMetric()))
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+
+	t.Run("get returns stored value", func(t *testing.T) {
+		client := newClient(t, 10, 1024)
+
+		assert.NoError(t, client.Set(ctx, []byte("hit"), "key", time.Minute))
+		buffer, err := client.Get(ctx, "key")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "hit", string(buffer))
+	})
+
+	t.Run("get with miss", func(t *testing.T) {
+		client := newClient(t, 10, 1024)
+
+		_, err := client.Get(ctx, "key")
+
+		assert.ErrorIs(t, err, db.ErrCacheNotFound)
+	})
+
+	t.Run("set does not overwrite live values", func(t *testing.T) {
+		client := newClient(t, 10, 1024)
+
+		assert.NoError(t, client.Set(ctx, "first", "key", time.Minute))
+		assert.NoError(t, client.Set(ctx, "second", "key", time.Minute))
+		buffer, _ := client.Get(ctx, "key")
+
+		assert.Equal(t, "first", string(buffer))
+	})
+
+	t.Run("setnx reports whether the value was stored", func(t *testing.T) {
+		client := newClient(t, 10, 1024)
+
+		stored, err := client.SetNX(ctx, "first", "key", time.Minute)
+		assert.NoError(t, err)
+		assert.True(t, stored)
+
+		stored, err = client.SetNX(ctx, "second", "key", time.Minute)
+		assert.NoError(t, err)
+		assert.False(t, stored)
+	})
+
+	t.Run("entries expire after their ttl", func(t *testing.T) {
+		client := newClient(t, 10, 1024)
+
+		assert.NoError(t, client.Set(ctx, "value", "key", 10*time.Millisecond))
+		time.Sleep(20 * time.Millisecond)
+
+		_, err := client.Get(ctx, "key")
+		assert.ErrorIs(t, err, db.ErrCacheNotFound)
+
+		assert.NoError(t, client.Set(ctx, "fresh", "key", time.Minute))
+		buffer, _ := client.Get(ctx, "key")
+		assert.Equal(t, "fresh", string(buffer))
+	})
+
+	t.Run("evicts least recently used entries over the entry bound", func(t *testing.T) {
+		client := newClient(t, 2, 1024)
+
+		client.Set(ctx, "a", "a", time.Minute)
+		client.Set(ctx, "b", "b", time.Minute)
+		client.Get(ctx, "a")
+		client.Set(ctx, "c", "c", time.Minute)
+
+		_, err := client.Get(ctx, "b")
+		assert.ErrorIs(t, err, db.ErrCacheNotFound)
+		assert.Equal(t, 2, client.Len())
+
+		_, err = client.Get(ctx, "a")
+		assert.NoError(t, err)
+	})
+
+	t.Run("evicts entries over the byte bound", func(t *testing.T) {
+		client := newClient(t, 10, 8)
+
+		client.Set(ctx, "12345", "a", time.Minute)
+		client.Set(ctx, "12345", "b", time.Minute)
+
+		_, err := client.Get(ctx, "a")
+		assert.ErrorIs(t, err, db.ErrCacheNotFound)
+
+		assert.NoError(t, client.Set(ctx, "123456789", "large", time.Minute))
+		_, err = client.Get(ctx, "large")
+		assert.ErrorIs(t, err, db.ErrCacheNotFound)
+
+		_, err = client.Get(ctx, "b")
+		assert.NoError(t, err)
+	})
+
+	t.Run("incr initializes and increments counters", func(t *testing.T) {
+		client := newClient(t, 10, 1024)
+
+		value, err := client.Incr(ctx, "counter")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), value)
+
+		value, err = client.Incr(ctx, "counter")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), value)
+
+		buffer, _ := client.Get(ctx, "counter")
+		assert.Equal(t, "2", string(buffer))
+	})
+
+	t.Run("incr keeps the ttl of existing counters", func(t *testing.T) {
+		client := newClient(t, 10, 1024)
+
+		client.Set(ctx, int64(5), "counter", 10*time.Millisecond)
+		value, err := client.Incr(ctx, "counter")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(6), value)
+
+		time.Sleep(20 * time.Millisecond)
+		_, err = client.Get(ctx, "counter")
+		assert.ErrorIs(t, err, db.ErrCacheNotFound)
+	})
+
+	t.Run("incr rejects non integer values", func(t *testing.T) {
+		client := newClient(t, 10, 1024)
+
+		client.Set(ctx, "text", "counter", time.Minute)
+		_, err := client.Incr(ctx, "counter")
+
+		assert.ErrorIs(t, err, db.ErrCacheInvalidValue)
+	})
+
+	t.Run("del removes keys", func(t *testing.T) {
+		client := newClient(t, 10, 1024)
+
+		client.Set(ctx, "value", "key", time.Minute)
+
+		assert.NoError(t, client.Del(ctx, "key"))
+		assert.NoError(t, client.Del(ctx, "missing"))
+
+		_, err := client.Get(ctx, "key")
+		assert.ErrorIs(t, err, db.ErrCacheNotFound)
+	})
+
+	t.Run("purge removes keys by prefix", func(t *testing.T) {
+		client := newClient(t, 10, 1024)
+
+		client.Set(ctx, "1", "url-service:id:1", time.Minute)
+		client.Set(ctx, "2", "url-service:id:2", time.Minute)
+		client.Set(ctx, "[]", "url-service:list:<", time.Minute)
+
+		purged, err := client.Purge(ctx, "url-service:id:")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), purged)
+		assert.Equal(t, 1, client.Len())
+	})
+
+	t.Run("closed client is unavailable", func(t *testing.T) {
+		client := newClient(t, 10, 1024)
+
+		assert.NoError(t, client.Ping(ctx))
+		assert.NoError(t, client.Close())
+
+		assert.ErrorIs(t, client.Ping(ctx), db.ErrCacheUnavailable)
+		assert.ErrorIs(t, client.Set(ctx, "value", "key", time.Minute), db.ErrCacheUnavailable)
+
+		_, err := client.Get(ctx, "key")
+		assert.ErrorIs(t, err, db.ErrCacheUnavailable)
+	})
+}
@@ -3,7 +3,6 @@ package db
 import (
 	"context"
 	"errors"
-	"log/slog"
 	"time"
 
 	json "github.com/json-iterator/go"
@@ -23,13 +22,13 @@ type dbFetch func(context.Context, any, string, ...any) error
 //
 // Values passed to this client must be pointers to JSON-marshalable types.
 type MemoryDatabaseClient struct {
-	db     SQLClient
+	db     SQLReader
 	cache  CacheClient
 	metric observability.MetricClient
 	logger observability.Logger
 }
 
-func NewMemoryDatabase(db SQLClient, cache CacheClient, observer observability.Observer) (SQLReader, error) {
+func NewMemoryDatabase(db SQLReader, cache CacheClient, observer observability.Observer) (SQLReader, error) {
 	metrics, err := observer.Metric()
 
 	if err != nil {
@@ -72,21 +71,32 @@ func (c MemoryDatabaseClient) Get(ctx context.Context, value any, query string,
 	return c.load(ctx, c.db.Get, value, query, args...)
 }
 
-// Get executes a database get operation with optional caching.
-//
-// Behavior mirrors Select, but is intended for single-row or primary-key
-// lookups. When caching is enabled, Get attempts to resolve the value from
-// cache before querying the database, falling back to the database on cache
-// misses or decode failures.
-//
-// Cache errors do not affect the database execution path.
+// Ping checks the underlying database only. The cache is optional and its
+// availability is reported separately, so an unavailable cache never makes
+// the reader look unhealthy.
 func (c MemoryDatabaseClient) Ping(ctx context.Context) error {
-	if err := c.cache.Ping(ctx); err != nil {
-		c.logger.Warn(ctx, "error cache is not available", slog.Any("error", err))
-		observability.TraceError(ctx, "cache is not available", err)
+	return c.db.Ping(ctx)
+}
+
+// Token returns a consistency token when the underlying reader is a
+// ConsistentReader, and an empty token otherwise: a reader without replicas
+// is always consistent.
+func (c MemoryDatabaseClient) Token(ctx context.Context) (string, error) {
+	if reader, ok := c.db.(ConsistentReader); ok {
+		return reader.Token(ctx)
 	}
 
-	return c.db.Ping(ctx)
+	return "", nil
+}
+
+// Replicas returns the status of the replicas behind the underlying
+// reader, or nil when it does not read from replicas.
+func (c MemoryDatabaseClient) Replicas() []ReplicaStatus {
+	if reader, ok := c.db.(interface{ Replicas() []ReplicaStatus }); ok {
+		return reader.Replicas()
+	}
+
+	return nil
 }
 
 // Close closes the underlying database connection and cache client,
@@ -98,23 +108,43 @@ func (c MemoryDatabaseClient) Close() error {
 	return errors.Join(c.cache.Close(), c.db.Close())
 }
 
+// cacheAvailable reports whether the cache should be used at all. Caches
+// able to tell they are down, such as ReconnectingCacheClient, are bypassed
+// instead of failing every operation.
+func (c MemoryDatabaseClient) cacheAvailable() bool {
+	if cache, ok := c.cache.(interface{ Available() bool }); ok {
+		return cache.Available()
+	}
+
+	return true
+}
+
 func (c MemoryDatabaseClient) load(ctx context.Context, fetch dbFetch, value any, query string, args ...any) error {
 	startAt := time.Now()
 	memory := cache.CacheFromContext(ctx)
+	family := memory.Policy.Family
 
-	if !memory.IsEnabled {
+	// Reads carrying a consistency token must observe a recent write, which
+	// a cached entry may predate.
+	if !memory.IsEnabled || !c.cacheAvailable() || ConsistencyTokenFromContext(ctx) != "" {
 		c.metric.MemoryBypassed(ctx)
 		return fetch(ctx, value, query, args...)
 	}
 
 	if data, err := c.cache.Get(ctx, memory.Policy.Key); err == nil {
 		if err := json.Unmarshal(data, value); err == nil {
-			c.metric.MemoryHit(ctx, memory.Policy.Key, time.Since(startAt))
+			c.metric.MemoryHit(ctx, family, time.Since(startAt))
+			c.metric.MemoryPayload(ctx, family, len(data))
 			return nil
 		}
 
-		c.metric.MemoryInvalid(ctx, memory.Policy.Key)
-		c.cache.Del(ctx, memory.Policy.Key)
+		c.metric.MemoryInvalid(ctx, family)
+
+		if err := c.cache.Del(ctx, memory.Policy.Key); err != nil {
+			c.metric.MemoryError(ctx, family, "del")
+		}
+	} else if !errors.Is(err, ErrCacheNotFound) {
+		c.metric.MemoryError(ctx, family, "get")
 	}
 
 	if err := fetch(ctx, value, query, args...); err != nil {
@@ -122,9 +152,13 @@ func (c MemoryDatabaseClient) load(ctx context.Context, fetch dbFetch, value any
 	}
 
 	if data, err := json.Marshal(value); err == nil {
-		c.cache.Set(ctx, data, memory.Policy.Key, memory.Policy.TTL)
+		if err := c.cache.Set(ctx, data, memory.Policy.Key, memory.Policy.TTL); err != nil {
+			c.metric.MemoryError(ctx, family, "set")
+		} else {
+			c.metric.MemoryPayload(ctx, family, len(data))
+		}
 	}
 
-	c.metric.MemoryMiss(ctx, memory.Policy.Key, time.Since(startAt))
+	c.metric.MemoryMiss(ctx, family, time.Since(startAt))
 	return nil
 }
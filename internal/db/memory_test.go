@@ -6,8 +6,8 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
-	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/db"
 	"github.com/zeon-code/tiny-url/internal/pkg/cache"
 	"github.com/zeon-code/tiny-url/internal/pkg/test"
 )
@@ -18,7 +18,7 @@ func TestMemoryClient(t *testing.T) {
 	}
 
 	t.Run("get by default should bypass cache", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		query := "SELECT * FROM anything WHERE id = $1"
 		rows := sqlmock.NewRows([]string{"name"}).AddRow("diego")
 
@@ -30,15 +30,16 @@ func TestMemoryClient(t *testing.T) {
 	})
 
 	t.Run("get by default should bypass cache with policy", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		query := "SELECT * FROM anything WHERE id = $1"
 		rows := sqlmock.NewRows([]string{"name"}).AddRow("diego")
 
 		ctx := cache.WithCachePolicy(
 			context.Background(),
 			cache.CachePolicy{
-				TTL: 1 * time.Minute,
-				Key: "get-policy-key",
+				TTL:    1 * time.Minute,
+				Key:    "get-policy-key",
+				Family: "get-policy",
 			},
 		)
 
@@ -50,70 +51,75 @@ func TestMemoryClient(t *testing.T) {
 	})
 
 	t.Run("get should cache", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		ctx := cache.WithCachePolicy(
 			cache.WithCache(context.Background()),
 			cache.CachePolicy{
-				TTL: 1 * time.Minute,
-				Key: "get-policy-key",
+				TTL:    1 * time.Minute,
+				Key:    "get-policy-key",
+				Family: "get-policy",
 			},
 		)
 
-		fake.CacheBackend.Value = `{"name": "diego"}`
+		fake.CacheClient.Set(ctx, `{"name": "diego"}`, "get-policy-key", time.Minute)
 		err := fake.Memory().Get(ctx, &Row{}, "SELECT * FROM anything WHERE id = $1", 1)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, fake.MemoryMetric.LastMemoryHitLatency)
-		assert.Equal(t, "get-policy-key", fake.MemoryMetric.LastMemoryHitKey)
+		assert.Equal(t, "get-policy", fake.MemoryMetric.LastMemoryHitFamily)
 	})
 
 	t.Run("get should invalidate the cache when the value is invalid.", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		query := "SELECT * FROM anything WHERE id = $1"
 		rows := sqlmock.NewRows([]string{"name"}).AddRow("diego")
 
 		ctx := cache.WithCachePolicy(
 			cache.WithCache(context.Background()),
 			cache.CachePolicy{
-				TTL: 1 * time.Minute,
-				Key: "select-policy-key",
+				TTL:    1 * time.Minute,
+				Key:    "select-policy-key",
+				Family: "select-policy",
 			},
 		)
 
-		fake.CacheBackend.Value = "[}"
+		fake.CacheClient.Set(ctx, "[}", "select-policy-key", time.Minute)
 		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
 		err := fake.Memory().Get(ctx, &Row{}, query, 1)
 
 		assert.NoError(t, err)
 		assert.True(t, fake.MemoryMetric.LastMemoryInvalid)
+
+		cached, err := fake.CacheClient.Get(ctx, "select-policy-key")
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"Name": "diego"}`, string(cached))
 	})
 
 	t.Run("get should fetch from DB when cache does not exists", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		query := "SELECT * FROM anything WHERE id = $1"
 		rows := sqlmock.NewRows([]string{"name"}).AddRow("diego")
 
 		ctx := cache.WithCachePolicy(
 			cache.WithCache(context.Background()),
 			cache.CachePolicy{
-				TTL: 1 * time.Minute,
-				Key: "select-policy-key",
+				TTL:    1 * time.Minute,
+				Key:    "select-policy-key",
+				Family: "select-policy",
 			},
 		)
 
 		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
-		fake.CacheBackend.Err = redis.Nil
-
 		err := fake.Memory().Get(ctx, &Row{}, query, 1)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, fake.MemoryMetric.LastMemoryMissLatency)
-		assert.Equal(t, "select-policy-key", fake.MemoryMetric.LastMemoryMissKey)
+		assert.Equal(t, "select-policy", fake.MemoryMetric.LastMemoryMissFamily)
 	})
 
 	t.Run("select by default should bypass cache", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		query := "SELECT * FROM anything WHERE id > $1"
 		rows := sqlmock.NewRows([]string{"name"}).AddRow("diego").AddRow("maria")
 
@@ -125,15 +131,16 @@ func TestMemoryClient(t *testing.T) {
 	})
 
 	t.Run("select by default should bypass cache with policy", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		query := "SELECT * FROM anything WHERE id > $1"
 		rows := sqlmock.NewRows([]string{"name"}).AddRow("diego").AddRow("maria")
 
 		ctx := cache.WithCachePolicy(
 			context.Background(),
 			cache.CachePolicy{
-				TTL: 1 * time.Minute,
-				Key: "select-policy-key",
+				TTL:    1 * time.Minute,
+				Key:    "select-policy-key",
+				Family: "select-policy",
 			},
 		)
 
@@ -145,38 +152,40 @@ func TestMemoryClient(t *testing.T) {
 	})
 
 	t.Run("select should cache", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		ctx := cache.WithCachePolicy(
 			cache.WithCache(context.Background()),
 			cache.CachePolicy{
-				TTL: 1 * time.Minute,
-				Key: "select-policy-key",
+				TTL:    1 * time.Minute,
+				Key:    "select-policy-key",
+				Family: "select-policy",
 			},
 		)
 
-		fake.CacheBackend.Value = "[]"
+		fake.CacheClient.Set(ctx, "[]", "select-policy-key", time.Minute)
 		err := fake.Memory().Select(ctx, &[]Row{}, "SELECT * FROM anything WHERE id > $1", 1)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, fake.MemoryMetric.LastMemoryHitLatency)
-		assert.Equal(t, "select-policy-key", fake.MemoryMetric.LastMemoryHitKey)
+		assert.Equal(t, "select-policy", fake.MemoryMetric.LastMemoryHitFamily)
 	})
 
 	t.Run("select should invalidate the cache when the value is invalid.", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		query := "SELECT * FROM anything WHERE id > $1"
 		rows := sqlmock.NewRows([]string{"name"}).AddRow("diego").AddRow("maria")
 
 		ctx := cache.WithCachePolicy(
 			cache.WithCache(context.Background()),
 			cache.CachePolicy{
-				TTL: 1 * time.Minute,
-				Key: "select-policy-key",
+				TTL:    1 * time.Minute,
+				Key:    "select-policy-key",
+				Family: "select-policy",
 			},
 		)
 
-		fake.CacheBackend.Value = "[}"
+		fake.CacheClient.Set(ctx, "[}", "select-policy-key", time.Minute)
 		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
 		err := fake.Memory().Select(ctx, &[]Row{}, query, 1)
 
@@ -185,25 +194,77 @@ func TestMemoryClient(t *testing.T) {
 	})
 
 	t.Run("select should fetch from DB when cache does not exists", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		query := "SELECT * FROM anything WHERE id > $1"
 		rows := sqlmock.NewRows([]string{"name"}).AddRow("diego").AddRow("maria")
 
 		ctx := cache.WithCachePolicy(
 			cache.WithCache(context.Background()),
 			cache.CachePolicy{
-				TTL: 1 * time.Minute,
-				Key: "select-policy-key",
+				TTL:    1 * time.Minute,
+				Key:    "select-policy-key",
+				Family: "select-policy",
 			},
 		)
 
 		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
-		fake.CacheBackend.Err = redis.Nil
-
 		err := fake.Memory().Select(ctx, &[]Row{}, query, 1)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, fake.MemoryMetric.LastMemoryMissLatency)
-		assert.Equal(t, "select-policy-key", fake.MemoryMetric.LastMemoryMissKey)
+		assert.Equal(t, "select-policy", fake.MemoryMetric.LastMemoryMissFamily)
+	})
+	t.Run("get should record payload size on hit", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		ctx := cache.WithCachePolicy(
+			cache.WithCache(context.Background()),
+			cache.NewCachePolicy(cache.NewCacheKey("url", "service").With("id", 1), time.Minute),
+		)
+
+		fake.CacheClient.Set(ctx, `{"name": "diego"}`, "url-service:id:1", time.Minute)
+		err := fake.Memory().Get(ctx, &Row{}, "SELECT * FROM anything WHERE id = $1", 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "url-service/id", fake.MemoryMetric.LastMemoryHitFamily)
+		assert.Equal(t, len(`{"name": "diego"}`), fake.MemoryMetric.LastMemoryPayloadSize)
+	})
+
+	t.Run("get should record cache errors and fall back to DB", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		query := "SELECT * FROM anything WHERE id = $1"
+		rows := sqlmock.NewRows([]string{"name"}).AddRow("diego")
+		ctx := cache.WithCachePolicy(
+			cache.WithCache(context.Background()),
+			cache.NewCachePolicy(cache.NewCacheKey("url", "service").With("id", 1), time.Minute),
+		)
+
+		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
+		fake.CacheClient.Close()
+		err := fake.Memory().Get(ctx, &Row{}, query, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "set", fake.MemoryMetric.LastMemoryErrorOperation)
+		assert.Equal(t, "url-service/id", fake.MemoryMetric.LastMemoryMissFamily)
+	})
+
+	t.Run("get should bypass cache for reads carrying a consistency token", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		query := "SELECT * FROM anything WHERE id = $1"
+		rows := sqlmock.NewRows([]string{"name"}).AddRow("diego")
+		key := cache.NewCacheKey("url", "service").With("id", 1)
+		ctx := cache.WithCachePolicy(
+			db.WithConsistencyToken(cache.WithCache(context.Background()), "0/16B3748"),
+			cache.NewCachePolicy(key, time.Minute),
+		)
+
+		fake.CacheClient.Set(ctx, `{"name": "stale"}`, key.String(), time.Minute)
+		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
+
+		var row Row
+		err := fake.Memory().Get(ctx, &row, query, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "diego", row.Name)
+		assert.True(t, fake.MemoryMetric.LastMemoryBypass)
 	})
 }
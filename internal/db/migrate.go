@@ -0,0 +1,397 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/migration"
+)
+
+// NilVersion is the schema version of a database without any migration applied.
+const NilVersion int64 = -1
+
+// migrationLockID identifies the session-level advisory lock held while
+// migrating, so concurrent instances never apply migrations at the same time.
+const migrationLockID int64 = 0x74696e7975726c
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version int64
+	Dirty   bool
+	Applied []Migration
+	Pending []Migration
+}
+
+// Migrator applies the SQL migrations to the database.
+//
+// The schema version is tracked in the schema_migrations table using the
+// same layout as golang-migrate, so databases migrated with the external
+// tool can be managed by the service binary and vice versa. A migration is
+// recorded as dirty before it runs and marked clean once it succeeds; a
+// dirty database refuses further migrations until a version is forced.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+	logger     observability.Logger
+}
+
+// NewMigratorFromConfig opens a dedicated connection pool to the database
+// described by conf, applying the migrations written for its driver.
+// Callers must Close the migrator when done.
+func NewMigratorFromConfig(conf config.DatabaseConfiguration, observer observability.Observer) (*Migrator, error) {
+	var db *sql.DB
+	var err error
+
+	dialect, files := DialectPostgres, fs.FS(migration.Postgres)
+
+	if conf.Driver() == "sqlite" {
+		dialect, files = DialectSQLite, migration.SQLite
+		db, err = newSQLiteDB(conf, observer)
+	} else if _, err = conf.DSN(); err == nil {
+		db, err = observability.NewInstrumentedDB(observer, "postgresql", postgresConnector{conf: conf})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := NewMigrator(db, dialect, files, observer)
+
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return migrator, nil
+}
+
+func NewMigrator(db *sql.DB, dialect Dialect, migrations fs.FS, observer observability.Observer) (*Migrator, error) {
+
+	loaded, err := LoadMigrations(migrations)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: loaded,
+		logger:     observer.Logger().With("client", "migrator"),
+	}, nil
+}
+
+// LoadMigrations reads every <version>_<name>.(up|down).sql file at the
+// root of fsys, ordered by version. Every version must have an up file.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		body, err := fs.ReadFile(fsys, entry.Name())
+
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[version]
+
+		if !exists {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return migrations, nil
+}
+
+// Up applies every pending migration and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := m.version(ctx, conn)
+
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			return fmt.Errorf("%w: version %d", ErrMigrationDirty, version)
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+
+			if err := m.apply(ctx, conn, migration.Up, migration.Version, migration); err != nil {
+				return err
+			}
+
+			applied++
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts up to steps migrations, newest first, and returns how many
+// were reverted. A non-positive steps reverts every applied migration.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := m.version(ctx, conn)
+
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			return fmt.Errorf("%w: version %d", ErrMigrationDirty, version)
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && (steps <= 0 || reverted < steps); i-- {
+			migration := m.migrations[i]
+
+			if migration.Version > version {
+				continue
+			}
+
+			previous := NilVersion
+
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+
+			if err := m.apply(ctx, conn, migration.Down, previous, migration); err != nil {
+				return err
+			}
+
+			reverted++
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Force records version as the current clean schema version without
+// running any migration. It is used to recover a dirty database after the
+// failed migration was fixed by hand. NilVersion clears the version.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != NilVersion && !slices.ContainsFunc(m.migrations, func(mig Migration) bool { return mig.Version == version }) {
+		return fmt.Errorf("%w: %d", ErrMigrationUnknownVersion, version)
+	}
+
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		return m.setVersion(ctx, conn, version, false)
+	})
+}
+
+// Status reports the current schema version and which migrations are
+// applied or still pending.
+func (m *Migrator) Status(ctx context.Context) (MigrationStatus, error) {
+	var status MigrationStatus
+
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := m.version(ctx, conn)
+
+		if err != nil {
+			return err
+		}
+
+		status = MigrationStatus{Version: version, Dirty: dirty, Applied: []Migration{}, Pending: []Migration{}}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				status.Applied = append(status.Applied, migration)
+			} else {
+				status.Pending = append(status.Pending, migration)
+			}
+		}
+
+		return nil
+	})
+
+	return status, err
+}
+
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// apply runs a single migration body, recording target as dirty while it
+// executes and clean once it succeeds.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, body string, target int64, migration Migration) error {
+	if err := m.setVersion(ctx, conn, target, true); err != nil {
+		return err
+	}
+
+	if body != "" {
+		if _, err := conn.ExecContext(ctx, body); err != nil {
+			return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	if err := m.setVersion(ctx, conn, target, false); err != nil {
+		return err
+	}
+
+	m.logger.Info(ctx, "migration applied",
+		slog.Int64("version", migration.Version),
+		slog.String("name", migration.Name),
+		slog.Int64("schema_version", target),
+	)
+
+	return nil
+}
+
+// locked runs fn on a single connection holding the migration advisory
+// lock. Advisory locks belong to the session, so every statement must go
+// through the same connection. SQLite serializes writers on its own and
+// takes no lock.
+func (m *Migrator) locked(ctx context.Context, fn func(*sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+
+	if err != nil {
+		return mapDBError(err)
+	}
+
+	defer conn.Close()
+
+	if m.dialect == DialectPostgres {
+		if err := m.lock(ctx, conn); err != nil {
+			return err
+		}
+
+		defer m.unlock(ctx, conn)
+	}
+
+	query := "CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)"
+
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return mapDBError(err)
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) error {
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return mapDBError(err)
+	}
+
+	return nil
+}
+
+// unlock releases the advisory lock. If it fails, the lock is still
+// released when the session ends.
+func (m *Migrator) unlock(ctx context.Context, conn *sql.Conn) {
+	if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+		m.logger.Warn(ctx, "error releasing migration lock", slog.Any("error", err))
+	}
+}
+
+func (m *Migrator) version(ctx context.Context, conn *sql.Conn) (int64, bool, error) {
+	var version int64
+	var dirty bool
+
+	err := conn.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return NilVersion, false, nil
+	}
+
+	if err != nil {
+		return 0, false, mapDBError(err)
+	}
+
+	return version, dirty, nil
+}
+
+func (m *Migrator) setVersion(ctx context.Context, conn *sql.Conn, version int64, dirty bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+
+	if err != nil {
+		return mapDBError(err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		tx.Rollback()
+		return mapDBError(err)
+	}
+
+	// A dirty NilVersion is kept so a failed first migration is still detected.
+	if version != NilVersion || dirty {
+		query := "INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)"
+
+		if _, err := tx.ExecContext(ctx, query, version, dirty); err != nil {
+			tx.Rollback()
+			return mapDBError(err)
+		}
+	}
+
+	return mapDBError(tx.Commit())
+}
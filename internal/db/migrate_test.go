@@ -0,0 +1,184 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+	"github.com/zeon-code/tiny-url/migration"
+)
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	lockID := int64(0x74696e7975726c)
+
+	files := fstest.MapFS{
+		"000001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id int)")},
+		"000001_create_a.down.sql": {Data: []byte("DROP TABLE a")},
+		"000002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id int)")},
+		"000002_create_b.down.sql": {Data: []byte("DROP TABLE b")},
+		"README.md":                {Data: []byte("ignored")},
+	}
+
+	expectLock := func(fake test.FakeDependencies) {
+		fake.DBMock.ExpectExec("SELECT pg_advisory_lock($1)").WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+		fake.DBMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	expectUnlock := func(fake test.FakeDependencies) {
+		fake.DBMock.ExpectExec("SELECT pg_advisory_unlock($1)").WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	expectVersion := func(fake test.FakeDependencies, version int64, dirty bool) {
+		rows := sqlmock.NewRows([]string{"version", "dirty"})
+
+		if version != db.NilVersion {
+			rows.AddRow(version, dirty)
+		}
+
+		fake.DBMock.ExpectQuery("SELECT version, dirty FROM schema_migrations LIMIT 1").WillReturnRows(rows)
+	}
+
+	expectSetVersion := func(fake test.FakeDependencies, version int64, dirty bool) {
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectExec("DELETE FROM schema_migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if version != db.NilVersion || dirty {
+			fake.DBMock.ExpectExec("INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)").
+				WithArgs(version, dirty).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+
+		fake.DBMock.ExpectCommit()
+	}
+
+	t.Run("load embedded migrations", func(t *testing.T) {
+		migrations, err := db.LoadMigrations(migration.Postgres)
+
+		assert.NoError(t, err)
+		assert.Len(t, migrations, 10)
+
+		for i, m := range migrations {
+			assert.Equal(t, int64(i+1), m.Version)
+			assert.NotEmpty(t, m.Up)
+			assert.NotEmpty(t, m.Down)
+		}
+	})
+
+	t.Run("load rejects migration without up file", func(t *testing.T) {
+		_, err := db.LoadMigrations(fstest.MapFS{
+			"000001_create_a.down.sql": {Data: []byte("DROP TABLE a")},
+		})
+
+		assert.ErrorContains(t, err, "has no up file")
+	})
+
+	t.Run("up applies pending migrations in order", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		migrator, err := db.NewMigrator(fake.DBBackend.DB, db.DialectPostgres, files, fake.Observer())
+		assert.NoError(t, err)
+
+		expectLock(fake)
+		expectVersion(fake, 1, false)
+		expectSetVersion(fake, 2, true)
+		fake.DBMock.ExpectExec("CREATE TABLE b (id int)").WillReturnResult(sqlmock.NewResult(0, 0))
+		expectSetVersion(fake, 2, false)
+		expectUnlock(fake)
+
+		applied, err := migrator.Up(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, applied)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("up leaves the version dirty when a migration fails", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		migrator, _ := db.NewMigrator(fake.DBBackend.DB, db.DialectPostgres, files, fake.Observer())
+
+		expectLock(fake)
+		expectVersion(fake, db.NilVersion, false)
+		expectSetVersion(fake, 1, true)
+		fake.DBMock.ExpectExec("CREATE TABLE a (id int)").WillReturnError(context.Canceled)
+		expectUnlock(fake)
+
+		applied, err := migrator.Up(ctx)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 0, applied)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("up refuses a dirty database", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		migrator, _ := db.NewMigrator(fake.DBBackend.DB, db.DialectPostgres, files, fake.Observer())
+
+		expectLock(fake)
+		expectVersion(fake, 2, true)
+		expectUnlock(fake)
+
+		_, err := migrator.Up(ctx)
+
+		assert.ErrorIs(t, err, db.ErrMigrationDirty)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("down reverts the requested steps", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		migrator, _ := db.NewMigrator(fake.DBBackend.DB, db.DialectPostgres, files, fake.Observer())
+
+		expectLock(fake)
+		expectVersion(fake, 2, false)
+		expectSetVersion(fake, 1, true)
+		fake.DBMock.ExpectExec("DROP TABLE b").WillReturnResult(sqlmock.NewResult(0, 0))
+		expectSetVersion(fake, 1, false)
+		expectUnlock(fake)
+
+		reverted, err := migrator.Down(ctx, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, reverted)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("force clears the dirty flag", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		migrator, _ := db.NewMigrator(fake.DBBackend.DB, db.DialectPostgres, files, fake.Observer())
+
+		expectLock(fake)
+		expectSetVersion(fake, 1, false)
+		expectUnlock(fake)
+
+		assert.NoError(t, migrator.Force(ctx, 1))
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("force rejects unknown versions", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		migrator, _ := db.NewMigrator(fake.DBBackend.DB, db.DialectPostgres, files, fake.Observer())
+
+		assert.ErrorIs(t, migrator.Force(ctx, 9), db.ErrMigrationUnknownVersion)
+	})
+
+	t.Run("status splits applied and pending migrations", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		migrator, _ := db.NewMigrator(fake.DBBackend.DB, db.DialectPostgres, files, fake.Observer())
+
+		expectLock(fake)
+		expectVersion(fake, 1, false)
+		expectUnlock(fake)
+
+		status, err := migrator.Status(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), status.Version)
+		assert.False(t, status.Dirty)
+		assert.Len(t, status.Applied, 1)
+		assert.Len(t, status.Pending, 1)
+		assert.Equal(t, "create_b", status.Pending[0].Name)
+	})
+}
@@ -3,8 +3,9 @@ package db
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/zeon-code/tiny-url/internal/pkg/config"
 	"github.com/zeon-code/tiny-url/internal/pkg/observability"
 
@@ -80,13 +81,11 @@ type PostgresClient struct {
 }
 
 func NewPostgresClientFromConfig(conf config.DatabaseConfiguration, observer observability.Observer) (SQLClient, error) {
-	dns, err := conf.DSN()
-
-	if err != nil {
+	if _, err := conf.DSN(); err != nil {
 		return nil, err
 	}
 
-	db, err := observability.NewInstrumentedDB(observer, conf.Driver(), dns)
+	db, err := observability.NewInstrumentedDB(observer, "postgresql", postgresConnector{conf: conf})
 
 	if err != nil {
 		return nil, mapDBError(err)
@@ -142,6 +141,10 @@ func (p PostgresClient) Ping(ctx context.Context) error {
 	)
 }
 
+func (p PostgresClient) Dialect() Dialect {
+	return DialectPostgres
+}
+
 // Close closes the underlying PostgreSQL backend connection.
 //
 // Returns a mapped error using mapDBError for consistent error handling.
@@ -154,8 +157,38 @@ func (p PostgresClient) Close() error {
 	return nil
 }
 
+// postgresConnector opens PostgreSQL connections resolving the DSN from
+// configuration on every dial, so credentials rotated on disk take effect
+// the next time the pool reconnects without restarting the process.
+type postgresConnector struct {
+	conf config.DatabaseConfiguration
+}
+
+func (c postgresConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dsn, err := c.conf.DSN()
+
+	if err != nil {
+		return nil, err
+	}
+
+	connector, err := pq.NewConnector(dsn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return connector.Connect(ctx)
+}
+
+func (c postgresConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
 type PostgresTX struct {
 	PostgresProxy
+
+	// depth counts the savepoints this value is nested in.
+	depth int
 }
 
 func newPostgresTx(tx PostgresTxBackend, logger observability.Logger) SQLTX {
@@ -17,7 +17,7 @@ func TestPostgresClient(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("proxy select query", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		query := "SELECT * FROM anything WHERE id > $1"
 		rows := sqlmock.NewRows([]string{"name"}).AddRow("diego").AddRow("maria")
 
@@ -28,7 +28,7 @@ func TestPostgresClient(t *testing.T) {
 	})
 
 	t.Run("proxy select query with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		query := "SELECT * FROM anything WHERE id > $1"
 
 		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnError(context.Canceled)
@@ -38,7 +38,7 @@ func TestPostgresClient(t *testing.T) {
 	})
 
 	t.Run("proxy get query", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		query := "SELECT * FROM anything WHERE id = $1"
 		rows := sqlmock.NewRows([]string{"name"}).AddRow("diego")
 
@@ -49,7 +49,7 @@ func TestPostgresClient(t *testing.T) {
 	})
 
 	t.Run("proxy get query with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		query := "SELECT * FROM anything WHERE id = $1"
 
 		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnError(context.Canceled)
@@ -59,7 +59,7 @@ func TestPostgresClient(t *testing.T) {
 	})
 
 	t.Run("proxy exec query", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		query := "UPDATE anything SET code = $1 WHERE id = $2"
 		result := sqlmock.NewResult(1, 1)
 
@@ -70,7 +70,7 @@ func TestPostgresClient(t *testing.T) {
 	})
 
 	t.Run("proxy exec query with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		query := "UPDATE anything SET code = $1 WHERE id = $2"
 
 		fake.DBMock.ExpectExec(query).WithArgs("code", 1).WillReturnError(context.Canceled)
@@ -80,7 +80,7 @@ func TestPostgresClient(t *testing.T) {
 	})
 
 	t.Run("proxy start transaction with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		fake.DBMock.ExpectBegin()
 		_, err := fake.DB().BeginTx(ctx, nil)
@@ -89,7 +89,7 @@ func TestPostgresClient(t *testing.T) {
 	})
 
 	t.Run("proxy start transaction with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		fake.DBMock.ExpectBegin().WillReturnError(context.Canceled)
 		_, err := fake.DB().BeginTx(ctx, nil)
@@ -106,7 +106,7 @@ func TestPostgresTx(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("proxy select query", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		fake.DBMock.ExpectBegin()
 		tx, err := fake.DB().BeginTx(ctx, nil)
@@ -122,7 +122,7 @@ func TestPostgresTx(t *testing.T) {
 	})
 
 	t.Run("proxy select query with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		fake.DBMock.ExpectBegin()
 		tx, err := fake.DB().BeginTx(ctx, nil)
@@ -137,7 +137,7 @@ func TestPostgresTx(t *testing.T) {
 	})
 
 	t.Run("proxy get query", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		fake.DBMock.ExpectBegin()
 		tx, err := fake.DB().BeginTx(ctx, nil)
@@ -153,7 +153,7 @@ func TestPostgresTx(t *testing.T) {
 	})
 
 	t.Run("proxy get query with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		fake.DBMock.ExpectBegin()
 		tx, err := fake.DB().BeginTx(ctx, nil)
@@ -168,7 +168,7 @@ func TestPostgresTx(t *testing.T) {
 	})
 
 	t.Run("proxy exec query", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		fake.DBMock.ExpectBegin()
 		tx, err := fake.DB().BeginTx(ctx, nil)
@@ -184,7 +184,7 @@ func TestPostgresTx(t *testing.T) {
 	})
 
 	t.Run("proxy exec query with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		fake.DBMock.ExpectBegin()
 		tx, err := fake.DB().BeginTx(ctx, nil)
@@ -199,7 +199,7 @@ func TestPostgresTx(t *testing.T) {
 	})
 
 	t.Run("proxy commit query", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		fake.DBMock.ExpectBegin()
 		tx, err := fake.DB().BeginTx(ctx, nil)
@@ -210,7 +210,7 @@ func TestPostgresTx(t *testing.T) {
 	})
 
 	t.Run("proxy commit query with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		fake.DBMock.ExpectBegin()
 		tx, err := fake.DB().BeginTx(ctx, nil)
@@ -221,7 +221,7 @@ func TestPostgresTx(t *testing.T) {
 	})
 
 	t.Run("proxy rollback query", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		fake.DBMock.ExpectBegin()
 		tx, err := fake.DB().BeginTx(ctx, nil)
@@ -232,7 +232,7 @@ func TestPostgresTx(t *testing.T) {
 	})
 
 	t.Run("proxy rollback query with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		fake.DBMock.ExpectBegin()
 		tx, err := fake.DB().BeginTx(ctx, nil)
@@ -0,0 +1,270 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+const (
+	// cacheCheckInterval is how often an available cache is pinged, and the
+	// first delay before retrying an unavailable one.
+	cacheCheckInterval = 5 * time.Second
+
+	// cacheMaxBackoff bounds the delay between reconnection attempts.
+	cacheMaxBackoff = 30 * time.Second
+
+	// cachePingTimeout bounds each background ping.
+	cachePingTimeout = 200 * time.Millisecond
+)
+
+// CacheConnector builds a CacheClient. It is called again on every
+// reconnection attempt until it succeeds.
+type CacheConnector func() (CacheClient, error)
+
+// ReconnectingCacheClient keeps the service running while the cache is
+// down. Until the underlying client can be built and answers a ping, every
+// operation fails fast with ErrCacheUnavailable and MemoryDatabaseClient
+// bypasses the cache entirely.
+//
+// A background loop builds the client, pings it while it is available and
+// retries with exponential backoff while it is not, so the cache is picked
+// up again as soon as it comes back.
+type ReconnectingCacheClient struct {
+	mu        sync.RWMutex
+	client    CacheClient
+	available bool
+	checked   bool
+	closed    bool
+
+	connect    CacheConnector
+	interval   time.Duration
+	maxBackoff time.Duration
+
+	stop   chan struct{}
+	done   chan struct{}
+	logger observability.Logger
+}
+
+// NewReconnectingCacheClientFromConfig wraps the CacheClient described by
+// conf, so a cache that is down or misconfigured at startup never prevents
+// the service from starting.
+func NewReconnectingCacheClientFromConfig(conf config.DatabaseConfiguration, observer observability.Observer) *ReconnectingCacheClient {
+	connect := func() (CacheClient, error) { return NewCacheClient(conf, observer) }
+	return NewReconnectingCacheClient(connect, cacheCheckInterval, observer)
+}
+
+// NewReconnectingCacheClient makes a first connection attempt before
+// returning, then keeps checking the cache every interval in the background.
+// Callers must Close the client to stop the loop.
+func NewReconnectingCacheClient(connect CacheConnector, interval time.Duration, observer observability.Observer) *ReconnectingCacheClient {
+	c := &ReconnectingCacheClient{
+		connect:    connect,
+		interval:   interval,
+		maxBackoff: max(interval, cacheMaxBackoff),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+		logger:     observer.Logger().With("client", "reconnecting-cache"),
+	}
+
+	c.check()
+	go c.monitor()
+
+	return c
+}
+
+// Available reports whether the last check found the cache reachable.
+func (c *ReconnectingCacheClient) Available() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.available
+}
+
+// Ping reports ErrCacheUnavailable while the cache is down and pings the
+// underlying client otherwise.
+func (c *ReconnectingCacheClient) Ping(ctx context.Context) error {
+	client, err := c.current()
+
+	if err != nil {
+		return err
+	}
+
+	return client.Ping(ctx)
+}
+
+func (c *ReconnectingCacheClient) Get(ctx context.Context, key string) ([]byte, error) {
+	client, err := c.current()
+
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return client.Get(ctx, key)
+}
+
+func (c *ReconnectingCacheClient) Set(ctx context.Context, value any, key string, ttl time.Duration) error {
+	client, err := c.current()
+
+	if err != nil {
+		return err
+	}
+
+	return client.Set(ctx, value, key, ttl)
+}
+
+func (c *ReconnectingCacheClient) SetNX(ctx context.Context, value any, key string, ttl time.Duration) (bool, error) {
+	client, err := c.current()
+
+	if err != nil {
+		return false, err
+	}
+
+	return client.SetNX(ctx, value, key, ttl)
+}
+
+func (c *ReconnectingCacheClient) Del(ctx context.Context, key string) error {
+	client, err := c.current()
+
+	if err != nil {
+		return err
+	}
+
+	return client.Del(ctx, key)
+}
+
+func (c *ReconnectingCacheClient) Incr(ctx context.Context, key string) (int64, error) {
+	client, err := c.current()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return client.Incr(ctx, key)
+}
+
+func (c *ReconnectingCacheClient) Purge(ctx context.Context, prefix string) (int64, error) {
+	client, err := c.current()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return client.Purge(ctx, prefix)
+}
+
+// Close stops the reconnection loop and closes the underlying client, if
+// it was ever built.
+func (c *ReconnectingCacheClient) Close() error {
+	c.mu.Lock()
+
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+
+	c.closed = true
+	c.available = false
+	close(c.stop)
+	c.mu.Unlock()
+
+	<-c.done
+
+	if c.client != nil {
+		return c.client.Close()
+	}
+
+	return nil
+}
+
+func (c *ReconnectingCacheClient) current() (CacheClient, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.available {
+		return nil, ErrCacheUnavailable
+	}
+
+	return c.client, nil
+}
+
+func (c *ReconnectingCacheClient) monitor() {
+	defer close(c.done)
+
+	delay := c.interval
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-timer.C:
+		}
+
+		if c.check() {
+			delay = c.interval
+		} else {
+			delay = min(delay*2, c.maxBackoff)
+		}
+
+		timer.Reset(delay)
+	}
+}
+
+// check builds the client when missing, pings it and records whether the
+// cache is available.
+func (c *ReconnectingCacheClient) check() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), cachePingTimeout)
+	defer cancel()
+
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		built, err := c.connect()
+
+		if err != nil {
+			c.setAvailable(ctx, false, err)
+			return false
+		}
+
+		c.mu.Lock()
+		c.client = built
+		c.mu.Unlock()
+
+		client = built
+	}
+
+	err := client.Ping(ctx)
+	c.setAvailable(ctx, err == nil, err)
+
+	return err == nil
+}
+
+// setAvailable records the cache state, logging only transitions so an
+// outage is reported once rather than on every attempt.
+func (c *ReconnectingCacheClient) setAvailable(ctx context.Context, available bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	if available && !c.available && c.checked {
+		c.logger.Info(ctx, "cache reconnected")
+	}
+
+	if !available && (c.available || !c.checked) {
+		c.logger.Warn(ctx, "cache unavailable, bypassing it until it reconnects", slog.Any("error", err))
+	}
+
+	c.available = available
+	c.checked = true
+}
@@ -0,0 +1,113 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/cache"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+)
+
+func TestReconnectingCacheClient(t *testing.T) {
+	ctx := context.Background()
+	observer := test.NewFakeObserver(test.NewFakeMetric())
+	errDown := errors.New("cache is down")
+
+	newClient := func(t *testing.T, connect db.CacheConnector) *db.ReconnectingCacheClient {
+		client := db.NewReconnectingCacheClient(connect, 5*time.Millisecond, observer)
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+
+	t.Run("delegates to an available cache", func(t *testing.T) {
+		local := db.NewLocalCacheClient(10, 1024, observer)
+		client := newClient(t, func() (db.CacheClient, error) { return local, nil })
+
+		assert.True(t, client.Available())
+		assert.NoError(t, client.Ping(ctx))
+		assert.NoError(t, client.Set(ctx, "value", "key", time.Minute))
+
+		buffer, err := client.Get(ctx, "key")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "value", string(buffer))
+	})
+
+	t.Run("fails fast while the cache cannot be built", func(t *testing.T) {
+		client := newClient(t, func() (db.CacheClient, error) { return nil, errDown })
+
+		assert.False(t, client.Available())
+		assert.ErrorIs(t, client.Ping(ctx), db.ErrCacheUnavailable)
+		assert.ErrorIs(t, client.Set(ctx, "value", "key", time.Minute), db.ErrCacheUnavailable)
+
+		_, err := client.Get(ctx, "key")
+		assert.ErrorIs(t, err, db.ErrCacheUnavailable)
+
+		_, err = client.Incr(ctx, "key")
+		assert.ErrorIs(t, err, db.ErrCacheUnavailable)
+	})
+
+	t.Run("reconnects in the background", func(t *testing.T) {
+		var attempts atomic.Int32
+		local := db.NewLocalCacheClient(10, 1024, observer)
+
+		client := newClient(t, func() (db.CacheClient, error) {
+			if attempts.Add(1) < 3 {
+				return nil, errDown
+			}
+
+			return local, nil
+		})
+
+		assert.False(t, client.Available())
+		assert.Eventually(t, client.Available, time.Second, time.Millisecond)
+		assert.NoError(t, client.Set(ctx, "value", "key", time.Minute))
+	})
+
+	t.Run("marks the cache unavailable when pings fail", func(t *testing.T) {
+		local := db.NewLocalCacheClient(10, 1024, observer)
+		client := newClient(t, func() (db.CacheClient, error) { return local, nil })
+
+		local.Close()
+
+		assert.Eventually(t, func() bool { return !client.Available() }, time.Second, time.Millisecond)
+		assert.ErrorIs(t, client.Del(ctx, "key"), db.ErrCacheUnavailable)
+	})
+
+	t.Run("close closes the underlying cache", func(t *testing.T) {
+		local := db.NewLocalCacheClient(10, 1024, observer)
+		client := db.NewReconnectingCacheClient(func() (db.CacheClient, error) { return local, nil }, time.Minute, observer)
+
+		assert.NoError(t, client.Close())
+		assert.False(t, client.Available())
+		assert.ErrorIs(t, local.Ping(ctx), db.ErrCacheUnavailable)
+	})
+
+	t.Run("memory client bypasses an unavailable cache", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		query := "SELECT * FROM anything WHERE id = $1"
+		rows := sqlmock.NewRows([]string{"name"}).AddRow("diego")
+		ctx := cache.WithCachePolicy(
+			cache.WithCache(context.Background()),
+			cache.NewCachePolicy(cache.NewCacheKey("url", "service").With("id", 1), time.Minute),
+		)
+
+		client := newClient(t, func() (db.CacheClient, error) { return nil, errDown })
+		memory, _ := db.NewMemoryDatabase(fake.DB(), client, test.NewFakeObserver(fake.MemoryMetric))
+
+		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
+		err := memory.Get(ctx, &struct {
+			Name string `db:"name"`
+		}{}, query, 1)
+
+		assert.NoError(t, err)
+		assert.True(t, fake.MemoryMetric.LastMemoryBypass)
+		assert.Empty(t, fake.MemoryMetric.LastMemoryErrorOperation)
+	})
+}
@@ -15,6 +15,7 @@ type RedisBackend interface {
 	Del(context.Context, ...string) *redis.IntCmd
 	Incr(context.Context, string) *redis.IntCmd
 	SetNX(context.Context, string, interface{}, time.Duration) *redis.BoolCmd
+	Scan(context.Context, uint64, string, int64) *redis.ScanCmd
 	Close() error
 }
 
@@ -41,6 +42,24 @@ func NewRedisClientFromConfig(conf config.DatabaseConfiguration, observer observ
 		return nil, err
 	}
 
+	// Credentials are resolved again on every new connection so rotated
+	// secrets mounted from files are used on reconnect.
+	opt.CredentialsProviderContext = func(ctx context.Context) (string, string, error) {
+		dsn, err := conf.DSN()
+
+		if err != nil {
+			return "", "", err
+		}
+
+		current, err := redis.ParseURL(dsn)
+
+		if err != nil {
+			return "", "", err
+		}
+
+		return current.Username, current.Password, nil
+	}
+
 	opt.DialerRetries = 3
 	opt.DialTimeout = 50 * time.Millisecond
 	opt.ReadTimeout = 100 * time.Millisecond
@@ -80,13 +99,23 @@ func (p RedisClient) Get(ctx context.Context, key string) ([]byte, error) {
 //
 // Returns a mapped cache error for consistent error handling.
 func (p RedisClient) Set(ctx context.Context, value any, key string, ttl time.Duration) error {
-	err := p.backend.SetNX(ctx, key, value, ttl).Err()
+	_, err := p.SetNX(ctx, value, key, ttl)
+	return err
+}
+
+// SetNX stores value under key with the given TTL only when the key does
+// not exist, and reports whether it was stored. Being atomic, it lets a
+// single caller acquire a lease shared by every instance.
+//
+// Returns a mapped cache error for consistent error handling.
+func (p RedisClient) SetNX(ctx context.Context, value any, key string, ttl time.Duration) (bool, error) {
+	stored, err := p.backend.SetNX(ctx, key, value, ttl).Result()
 
 	if err != nil {
-		return mapCacheError(err)
+		return false, mapCacheError(err)
 	}
 
-	return nil
+	return stored, nil
 }
 
 // Set stores the given value in the cache under the provided key with
@@ -119,9 +148,42 @@ func (p RedisClient) Incr(ctx context.Context, key string) (int64, error) {
 	return current, nil
 }
 
-// Incr atomically increments the integer value stored at the given key
-// and returns the updated value. If the key does not exist, it is
-// initialized before being incremented.
+// Purge deletes every key starting with the given prefix and returns how
+// many keys were removed. Keys are discovered incrementally with SCAN, so
+// the operation does not block Redis on large keyspaces, but it is not
+// atomic: keys written while purging may survive.
+//
+// Returns a mapped cache error for consistent error handling.
+func (p RedisClient) Purge(ctx context.Context, prefix string) (int64, error) {
+	var purged int64
+	var cursor uint64
+
+	for {
+		keys, next, err := p.backend.Scan(ctx, cursor, prefix+"*", 500).Result()
+
+		if err != nil {
+			return purged, mapCacheError(err)
+		}
+
+		if len(keys) > 0 {
+			deleted, err := p.backend.Del(ctx, keys...).Result()
+
+			if err != nil {
+				return purged, mapCacheError(err)
+			}
+
+			purged += deleted
+		}
+
+		if next == 0 {
+			return purged, nil
+		}
+
+		cursor = next
+	}
+}
+
+// Close closes the underlying Redis connection pool.
 //
 // Returns a mapped cache error for consistent error handling.
 func (p RedisClient) Close() error {
@@ -16,102 +16,143 @@ func TestRedisCacheClient(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("proxy get command", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
-		fake.CacheBackend.Value = "hit"
+		fake := test.NewFakeRedisBackend()
+		fake.Value = "hit"
 
-		buffer, err := fake.Cache().Get(ctx, key)
+		buffer, err := fake.Client().Get(ctx, key)
 
 		assert.NoError(t, err)
 		assert.Equal(t, "hit", string(buffer))
 	})
 
 	t.Run("proxy get command with miss", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
-		fake.CacheBackend.Err = redis.Nil
+		fake := test.NewFakeRedisBackend()
+		fake.Err = redis.Nil
 
-		buffer, err := fake.Cache().Get(ctx, key)
+		buffer, err := fake.Client().Get(ctx, key)
 
 		assert.Equal(t, []byte{}, buffer)
 		assert.Equal(t, db.ErrCacheNotFound, err)
 	})
 
 	t.Run("proxy get command with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
-		fake.CacheBackend.Err = redis.ErrClosed
+		fake := test.NewFakeRedisBackend()
+		fake.Err = redis.ErrClosed
 
-		buffer, err := fake.Cache().Get(ctx, key)
+		buffer, err := fake.Client().Get(ctx, key)
 
 		assert.Equal(t, []byte{}, buffer)
 		assert.Equal(t, db.ErrCacheUnavailable, err)
 	})
 
 	t.Run("proxy set command", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeRedisBackend()
 
-		err := fake.Cache().Set(ctx, "value", key, 1*time.Minute)
+		err := fake.Client().Set(ctx, "value", key, 1*time.Minute)
 
 		assert.NoError(t, err)
 	})
 
 	t.Run("proxy set command with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
-		fake.CacheBackend.Err = redis.ErrClosed
+		fake := test.NewFakeRedisBackend()
+		fake.Err = redis.ErrClosed
 
-		err := fake.Cache().Set(ctx, "value", key, 1*time.Minute)
+		err := fake.Client().Set(ctx, "value", key, 1*time.Minute)
 
 		assert.Equal(t, db.ErrCacheUnavailable, err)
 	})
 
+	t.Run("proxy setnx command", func(t *testing.T) {
+		fake := test.NewFakeRedisBackend()
+		fake.Value = true
+
+		stored, err := fake.Client().SetNX(ctx, "value", key, 1*time.Minute)
+
+		assert.NoError(t, err)
+		assert.True(t, stored)
+		assert.Equal(t, key, fake.LastSetKey)
+		assert.Equal(t, 1*time.Minute, fake.LastSetExpiration)
+	})
+
+	t.Run("proxy setnx command with error", func(t *testing.T) {
+		fake := test.NewFakeRedisBackend()
+		fake.Err = redis.ErrClosed
+
+		stored, err := fake.Client().SetNX(ctx, "value", key, 1*time.Minute)
+
+		assert.False(t, stored)
+		assert.Equal(t, db.ErrCacheUnavailable, err)
+	})
+
 	t.Run("proxy del command", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeRedisBackend()
 
-		err := fake.Cache().Del(ctx, key)
+		err := fake.Client().Del(ctx, key)
 
 		assert.NoError(t, err)
 	})
 
 	t.Run("proxy del command with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
-		fake.CacheBackend.Err = redis.ErrClosed
+		fake := test.NewFakeRedisBackend()
+		fake.Err = redis.ErrClosed
 
-		err := fake.Cache().Del(ctx, key)
+		err := fake.Client().Del(ctx, key)
 
 		assert.Equal(t, db.ErrCacheUnavailable, err)
 	})
 
 	t.Run("proxy incr command", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
-		fake.CacheBackend.Value = int64(1)
+		fake := test.NewFakeRedisBackend()
+		fake.Value = int64(1)
 
-		value, err := fake.Cache().Incr(ctx, key)
+		value, err := fake.Client().Incr(ctx, key)
 
 		assert.NoError(t, err)
 		assert.Equal(t, int64(1), value)
 	})
 
 	t.Run("proxy incr command with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
-		fake.CacheBackend.Err = redis.ErrClosed
+		fake := test.NewFakeRedisBackend()
+		fake.Err = redis.ErrClosed
 
-		_, err := fake.Cache().Incr(ctx, key)
+		_, err := fake.Client().Incr(ctx, key)
 
 		assert.Equal(t, db.ErrCacheUnavailable, err)
 	})
 
 	t.Run("proxy ping command", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
-		fake.CacheBackend.Value = int64(1)
+		fake := test.NewFakeRedisBackend()
+		fake.Value = int64(1)
 
-		err := fake.Cache().Ping(ctx)
+		err := fake.Client().Ping(ctx)
 
 		assert.NoError(t, err)
 	})
 
 	t.Run("proxy ping command with error", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
-		fake.CacheBackend.Err = redis.ErrClosed
+		fake := test.NewFakeRedisBackend()
+		fake.Err = redis.ErrClosed
+
+		err := fake.Client().Ping(ctx)
+
+		assert.Equal(t, db.ErrCacheUnavailable, err)
+	})
+	t.Run("proxy purge command", func(t *testing.T) {
+		fake := test.NewFakeRedisBackend()
+		fake.Value = []string{"url-service:id:1", "url-service:id:2"}
+
+		_, err := fake.Client().Purge(ctx, "url-service:id:")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "url-service:id:*", fake.LastScanMatch)
+		assert.Equal(t, []string{"url-service:id:1", "url-service:id:2"}, fake.LastDelKey)
+	})
+
+	t.Run("proxy purge command with error", func(t *testing.T) {
+		fake := test.NewFakeRedisBackend()
+		fake.Err = redis.ErrClosed
 
-		err := fake.Cache().Ping(ctx)
+		_, err := fake.Client().Purge(ctx, "url-service:id:")
 
 		assert.Equal(t, db.ErrCacheUnavailable, err)
 	})
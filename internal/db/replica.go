@@ -0,0 +1,383 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+// replicaCheckTimeout bounds each background lag measurement.
+const replicaCheckTimeout = 1 * time.Second
+
+type consistencyTokenKey struct{}
+
+// WithConsistencyToken returns a context whose reads must observe every
+// write up to token, a position in the primary's write-ahead log returned
+// by ConsistentReader.Token after a write.
+func WithConsistencyToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, consistencyTokenKey{}, token)
+}
+
+// ConsistencyTokenFromContext returns the token set by WithConsistencyToken,
+// or an empty string.
+func ConsistencyTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(consistencyTokenKey{}).(string)
+	return token
+}
+
+// ConsistentReader is a SQLReader able to honor read-your-writes
+// consistency tokens.
+type ConsistentReader interface {
+	SQLReader
+
+	// Token returns the current write position of the primary. Reads
+	// carrying it through WithConsistencyToken observe every write
+	// committed before it was taken.
+	Token(context.Context) (string, error)
+}
+
+// Balance selects how reads are spread across healthy replicas.
+type Balance string
+
+const (
+	// BalanceRoundRobin sends reads to each replica in turn.
+	BalanceRoundRobin Balance = "round_robin"
+	// BalanceLeastConnections sends reads to the replica with the fewest
+	// reads in flight.
+	BalanceLeastConnections Balance = "least_connections"
+)
+
+// ReplicaState is the health of a replica as seen by the last check.
+type ReplicaState string
+
+const (
+	ReplicaHealthy     ReplicaState = "healthy"
+	ReplicaLagging     ReplicaState = "lagging"
+	ReplicaUnavailable ReplicaState = "unavailable"
+)
+
+// Replica is a named read replica.
+type Replica struct {
+	Name   string
+	Client SQLClient
+}
+
+// ReplicaStatus reports the health of a replica and its last measured lag.
+type ReplicaStatus struct {
+	Name  string
+	State ReplicaState
+	Lag   time.Duration
+}
+
+// ReplicaPolicy controls how replicas are monitored and balanced.
+type ReplicaPolicy struct {
+	// MaxLag is how far behind the primary a replica may fall before it is
+	// taken out of rotation.
+	MaxLag time.Duration
+	// CheckInterval is how often every replica is checked.
+	CheckInterval time.Duration
+	Balance       Balance
+}
+
+type replicaMember struct {
+	Replica
+
+	inflight atomic.Int64
+	status   ReplicaStatus
+}
+
+// ReplicaReader balances reads across PostgreSQL streaming replicas and
+// falls back to the primary whenever no replica can be trusted:
+//
+//   - every replica is out of rotation, because it is unreachable, lags
+//     behind the primary by more than the configured maximum or its lag
+//     cannot be measured;
+//   - the read carries a consistency token the chosen replica has not
+//     replayed yet, so a client reading its own write never sees it
+//     missing.
+//
+// Every replica is pinged and its lag measured once on construction, then
+// periodically in the background; replicas return to rotation as soon as
+// a check succeeds. Closing the reader closes the replicas only; the
+// primary is owned by the caller.
+type ReplicaReader struct {
+	primary SQLClient
+	members []*replicaMember
+	policy  ReplicaPolicy
+	next    atomic.Uint64
+
+	mu sync.RWMutex
+
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+	metric observability.MetricClient
+	logger observability.Logger
+}
+
+func NewReplicaReaderFromConfig(primary SQLClient, replicas []Replica, conf config.ReplicationConfiguration, observer observability.Observer) (*ReplicaReader, error) {
+	maxLag, err := conf.MaxLag()
+
+	if err != nil {
+		return nil, err
+	}
+
+	interval, err := conf.CheckInterval()
+
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := conf.Balance()
+
+	if err != nil {
+		return nil, err
+	}
+
+	policy := ReplicaPolicy{MaxLag: maxLag, CheckInterval: interval, Balance: Balance(balance)}
+	return NewReplicaReader(primary, replicas, policy, observer)
+}
+
+func NewReplicaReader(primary SQLClient, replicas []Replica, policy ReplicaPolicy, observer observability.Observer) (*ReplicaReader, error) {
+	metric, err := observer.Metric()
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := &ReplicaReader{
+		primary: primary,
+		policy:  policy,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		metric:  metric,
+		logger:  observer.Logger().With("client", "replica-reader"),
+	}
+
+	for _, replica := range replicas {
+		r.members = append(r.members, &replicaMember{
+			Replica: replica,
+			status:  ReplicaStatus{Name: replica.Name, State: ReplicaUnavailable},
+		})
+	}
+
+	r.checkAll()
+	go r.monitor()
+
+	return r, nil
+}
+
+func (r *ReplicaReader) Select(ctx context.Context, value any, query string, args ...any) error {
+	return r.read(ctx, func(reader SQLReader) error { return reader.Select(ctx, value, query, args...) })
+}
+
+func (r *ReplicaReader) Get(ctx context.Context, value any, query string, args ...any) error {
+	return r.read(ctx, func(reader SQLReader) error { return reader.Get(ctx, value, query, args...) })
+}
+
+// Ping checks every replica, whether or not it is in rotation.
+func (r *ReplicaReader) Ping(ctx context.Context) error {
+	var err error
+
+	for _, member := range r.members {
+		err = errors.Join(err, member.Client.Ping(ctx))
+	}
+
+	return err
+}
+
+// Token returns the current write-ahead log position of the primary.
+func (r *ReplicaReader) Token(ctx context.Context) (string, error) {
+	var token string
+
+	if err := r.primary.Get(ctx, &token, "SELECT pg_current_wal_lsn()::text"); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Replicas returns the status of every replica as of its last check.
+func (r *ReplicaReader) Replicas() []ReplicaStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := make([]ReplicaStatus, 0, len(r.members))
+
+	for _, member := range r.members {
+		statuses = append(statuses, member.status)
+	}
+
+	return statuses
+}
+
+// Close stops the monitor and closes every replica.
+func (r *ReplicaReader) Close() error {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+
+	var err error
+
+	for _, member := range r.members {
+		err = errors.Join(err, member.Client.Close())
+	}
+
+	return err
+}
+
+// read runs fn against the replica picked for ctx, or the primary when
+// none qualifies.
+func (r *ReplicaReader) read(ctx context.Context, fn func(SQLReader) error) error {
+	member := r.pick(ctx)
+
+	if member == nil {
+		return fn(r.primary)
+	}
+
+	member.inflight.Add(1)
+	defer member.inflight.Add(-1)
+
+	return fn(member.Client)
+}
+
+// pick chooses a healthy replica according to the balance policy. It
+// returns nil when every replica is out of rotation or the chosen one has
+// not replayed the consistency token of ctx.
+func (r *ReplicaReader) pick(ctx context.Context) *replicaMember {
+	r.mu.RLock()
+	healthy := make([]*replicaMember, 0, len(r.members))
+
+	for _, member := range r.members {
+		if member.status.State == ReplicaHealthy {
+			healthy = append(healthy, member)
+		}
+	}
+
+	r.mu.RUnlock()
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	// Rotating the starting point also spreads ties between replicas with
+	// the same number of reads in flight.
+	offset := int(r.next.Add(1) % uint64(len(healthy)))
+	chosen := healthy[offset]
+
+	if r.policy.Balance == BalanceLeastConnections {
+		for i := range healthy {
+			candidate := healthy[(offset+i)%len(healthy)]
+
+			if candidate.inflight.Load() < chosen.inflight.Load() {
+				chosen = candidate
+			}
+		}
+	}
+
+	if token := ConsistencyTokenFromContext(ctx); token != "" && !r.replayed(ctx, chosen, token) {
+		return nil
+	}
+
+	return chosen
+}
+
+// replayed reports whether the replica has replayed the primary's log up to
+// token. Any doubt, including a malformed token, sends the read to the
+// primary.
+func (r *ReplicaReader) replayed(ctx context.Context, member *replicaMember, token string) bool {
+	var replayed bool
+	query := "SELECT COALESCE(pg_last_wal_replay_lsn() >= $1::pg_lsn, false)"
+
+	if err := member.Client.Get(ctx, &replayed, query, token); err != nil {
+		r.logger.Debug(ctx, "error checking replica position", slog.String("replica", member.Name), slog.Any("error", err))
+		return false
+	}
+
+	return replayed
+}
+
+func (r *ReplicaReader) monitor() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.policy.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.checkAll()
+		}
+	}
+}
+
+// checkAll checks every replica concurrently, so one unreachable replica
+// does not delay the others.
+func (r *ReplicaReader) checkAll() {
+	var wg sync.WaitGroup
+
+	for _, member := range r.members {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			r.check(member)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// check pings the replica, measures its lag and updates whether it is in
+// rotation. A replica with nothing left to replay has no lag, even when the
+// primary has been idle for a while.
+func (r *ReplicaReader) check(member *replicaMember) {
+	ctx, cancel := context.WithTimeout(context.Background(), replicaCheckTimeout)
+	defer cancel()
+
+	if err := member.Client.Ping(ctx); err != nil {
+		r.setStatus(ctx, member, ReplicaStatus{Name: member.Name, State: ReplicaUnavailable}, slog.Any("error", err))
+		return
+	}
+
+	var seconds float64
+	query := "SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0 " +
+		"ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END"
+
+	if err := member.Client.Get(ctx, &seconds, query); err != nil {
+		r.setStatus(ctx, member, ReplicaStatus{Name: member.Name, State: ReplicaUnavailable}, slog.Any("error", err))
+		return
+	}
+
+	status := ReplicaStatus{Name: member.Name, State: ReplicaHealthy, Lag: time.Duration(seconds * float64(time.Second))}
+
+	if status.Lag > r.policy.MaxLag {
+		status.State = ReplicaLagging
+	}
+
+	r.metric.ReplicaLag(ctx, member.Name, status.Lag)
+	r.setStatus(ctx, member, status, slog.Duration("lag", status.Lag))
+}
+
+func (r *ReplicaReader) setStatus(ctx context.Context, member *replicaMember, status ReplicaStatus, reason slog.Attr) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := member.status.State
+	member.status = status
+
+	switch {
+	case status.State == previous:
+	case status.State == ReplicaHealthy:
+		r.logger.Info(ctx, "replica in rotation", slog.String("replica", member.Name), reason)
+	case previous == ReplicaHealthy:
+		r.logger.Warn(ctx, "replica taken out of rotation", slog.String("replica", member.Name), slog.String("state", string(status.State)), reason)
+	}
+}
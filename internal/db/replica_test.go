@@ -0,0 +1,154 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+)
+
+func TestReplicaReader(t *testing.T) {
+	query := "SELECT name FROM anything WHERE id = $1"
+	lagQuery := "SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0 " +
+		"ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END"
+	replayQuery := "SELECT COALESCE(pg_last_wal_replay_lsn() >= $1::pg_lsn, false)"
+
+	type Row struct {
+		Name string `db:"name"`
+	}
+
+	// replica describes a fake replica and the lag reported by its first
+	// check; a negative lag makes the check fail.
+	type replica struct {
+		fake test.FakeDependencies
+		lag  float64
+	}
+
+	newReader := func(t *testing.T, primary test.FakeDependencies, balance db.Balance, replicas ...replica) *db.ReplicaReader {
+		members := []db.Replica{}
+
+		for i, r := range replicas {
+			if r.lag < 0 {
+				r.fake.DBMock.ExpectQuery(lagQuery).WillReturnError(errors.New("connection refused"))
+			} else {
+				r.fake.DBMock.ExpectQuery(lagQuery).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(r.lag))
+			}
+
+			members = append(members, db.Replica{Name: []string{"replica-a", "replica-b"}[i], Client: r.fake.DB()})
+		}
+
+		policy := db.ReplicaPolicy{MaxLag: 10 * time.Second, CheckInterval: time.Minute, Balance: balance}
+		reader, err := db.NewReplicaReader(primary.DB(), members, policy, test.NewFakeObserver(primary.DBMetric))
+		require.NoError(t, err)
+
+		t.Cleanup(func() { reader.Close() })
+		return reader
+	}
+
+	expectRow := func(fake test.FakeDependencies, name string) {
+		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow(name))
+	}
+
+	read := func(t *testing.T, reader *db.ReplicaReader, ctx context.Context) string {
+		var row Row
+		require.NoError(t, reader.Get(ctx, &row, query, 1))
+		return row.Name
+	}
+
+	t.Run("reads from a healthy replica", func(t *testing.T) {
+		primary, a := test.NewFakeDependencies(t), test.NewFakeDependencies(t)
+		reader := newReader(t, primary, db.BalanceRoundRobin, replica{a, 0.5})
+
+		expectRow(a, "replica-a")
+
+		assert.Equal(t, "replica-a", read(t, reader, context.Background()))
+		assert.Equal(t, []db.ReplicaStatus{{Name: "replica-a", State: db.ReplicaHealthy, Lag: 500 * time.Millisecond}}, reader.Replicas())
+		assert.Equal(t, 500*time.Millisecond, primary.DBMetric.LastReplicaLag)
+	})
+
+	t.Run("balances reads round robin", func(t *testing.T) {
+		primary, a, b := test.NewFakeDependencies(t), test.NewFakeDependencies(t), test.NewFakeDependencies(t)
+		reader := newReader(t, primary, db.BalanceRoundRobin, replica{a, 0}, replica{b, 0})
+
+		expectRow(a, "replica-a")
+		expectRow(b, "replica-b")
+
+		first := read(t, reader, context.Background())
+		second := read(t, reader, context.Background())
+
+		assert.ElementsMatch(t, []string{"replica-a", "replica-b"}, []string{first, second})
+	})
+
+	t.Run("ejects lagging replicas", func(t *testing.T) {
+		primary, a, b := test.NewFakeDependencies(t), test.NewFakeDependencies(t), test.NewFakeDependencies(t)
+		reader := newReader(t, primary, db.BalanceLeastConnections, replica{a, 30}, replica{b, 0})
+
+		expectRow(b, "replica-b")
+		expectRow(b, "replica-b")
+
+		assert.Equal(t, "replica-b", read(t, reader, context.Background()))
+		assert.Equal(t, "replica-b", read(t, reader, context.Background()))
+		assert.Equal(t, db.ReplicaLagging, reader.Replicas()[0].State)
+	})
+
+	t.Run("ejects replicas whose lag cannot be measured", func(t *testing.T) {
+		primary, a, b := test.NewFakeDependencies(t), test.NewFakeDependencies(t), test.NewFakeDependencies(t)
+		reader := newReader(t, primary, db.BalanceRoundRobin, replica{a, -1}, replica{b, 0})
+
+		expectRow(b, "replica-b")
+
+		assert.Equal(t, "replica-b", read(t, reader, context.Background()))
+		assert.Equal(t, db.ReplicaUnavailable, reader.Replicas()[0].State)
+		assert.Equal(t, db.ReplicaHealthy, reader.Replicas()[1].State)
+	})
+
+	t.Run("reads from the primary when every replica is ejected", func(t *testing.T) {
+		var rows []Row
+		primary, a, b := test.NewFakeDependencies(t), test.NewFakeDependencies(t), test.NewFakeDependencies(t)
+		reader := newReader(t, primary, db.BalanceRoundRobin, replica{a, -1}, replica{b, 30})
+
+		primary.DBMock.ExpectQuery(query).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("primary"))
+
+		assert.NoError(t, reader.Select(context.Background(), &rows, query, 1))
+		assert.Equal(t, []Row{{Name: "primary"}}, rows)
+	})
+
+	t.Run("reads from the replica once it replayed the token", func(t *testing.T) {
+		primary, a := test.NewFakeDependencies(t), test.NewFakeDependencies(t)
+		reader := newReader(t, primary, db.BalanceRoundRobin, replica{a, 0})
+		ctx := db.WithConsistencyToken(context.Background(), "0/16B3748")
+
+		a.DBMock.ExpectQuery(replayQuery).WithArgs("0/16B3748").WillReturnRows(sqlmock.NewRows([]string{"replayed"}).AddRow(true))
+		expectRow(a, "replica-a")
+
+		assert.Equal(t, "replica-a", read(t, reader, ctx))
+	})
+
+	t.Run("reads from the primary until the replica replayed the token", func(t *testing.T) {
+		primary, a := test.NewFakeDependencies(t), test.NewFakeDependencies(t)
+		reader := newReader(t, primary, db.BalanceRoundRobin, replica{a, 0})
+		ctx := db.WithConsistencyToken(context.Background(), "0/16B3748")
+
+		a.DBMock.ExpectQuery(replayQuery).WithArgs("0/16B3748").WillReturnRows(sqlmock.NewRows([]string{"replayed"}).AddRow(false))
+		expectRow(primary, "primary")
+
+		assert.Equal(t, "primary", read(t, reader, ctx))
+	})
+
+	t.Run("token reads the primary write position", func(t *testing.T) {
+		primary, a := test.NewFakeDependencies(t), test.NewFakeDependencies(t)
+		reader := newReader(t, primary, db.BalanceRoundRobin, replica{a, 0})
+
+		primary.DBMock.ExpectQuery("SELECT pg_current_wal_lsn()::text").WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/16B3748"))
+		token, err := reader.Token(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, "0/16B3748", token)
+	})
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+// RetryPolicy controls how operations failing with ErrDBTransient are
+// retried.
+type RetryPolicy struct {
+	// Attempts is the total number of tries, including the first one.
+	Attempts int
+	// BaseDelay is the upper bound of the first backoff; it doubles on every
+	// retry up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultRetryPolicy tries three times, waiting up to 50ms then 100ms.
+var DefaultRetryPolicy = RetryPolicy{Attempts: 3, BaseDelay: 50 * time.Millisecond, MaxDelay: 1 * time.Second}
+
+// Retry runs fn until it succeeds, fails with an error other than
+// ErrDBTransient or the policy is exhausted, returning the last error.
+//
+// Each backoff is drawn uniformly between zero and the current bound, so
+// instances failing together do not retry together. Retries never outlive
+// ctx: when it is done, or its deadline would pass before the next attempt,
+// the last error is returned right away. Only idempotent operations may be
+// retried, since a transient error can hide a write that was applied.
+func Retry(ctx context.Context, policy RetryPolicy, fn func() error) error {
+	return retry(ctx, policy, func(err error) bool { return errors.Is(err, ErrDBTransient) }, fn)
+}
+
+// retry runs fn like Retry, retrying the errors for which retryable holds.
+func retry(ctx context.Context, policy RetryPolicy, retryable func(error) bool, fn func() error) error {
+	bound := policy.BaseDelay
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+
+		if err == nil || !retryable(err) || attempt >= policy.Attempts {
+			return err
+		}
+
+		delay := time.Duration(rand.Int64N(int64(bound) + 1))
+		bound = min(bound*2, policy.MaxDelay)
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// RetryingReader decorates a SQLReader, retrying reads that fail with
+// ErrDBTransient according to its policy. Reads are idempotent, so a
+// dropped connection or a replica restarting costs a short backoff instead
+// of a failed request.
+type RetryingReader struct {
+	SQLReader
+
+	policy RetryPolicy
+	logger observability.Logger
+}
+
+func NewRetryingReader(reader SQLReader, policy RetryPolicy, observer observability.Observer) *RetryingReader {
+	return &RetryingReader{
+		SQLReader: reader,
+		policy:    policy,
+		logger:    observer.Logger().With("client", "retrying-reader"),
+	}
+}
+
+func (r *RetryingReader) Select(ctx context.Context, value any, query string, args ...any) error {
+	return Retry(ctx, r.policy, func() error { return r.SQLReader.Select(ctx, value, query, args...) })
+}
+
+func (r *RetryingReader) Get(ctx context.Context, value any, query string, args ...any) error {
+	return Retry(ctx, r.policy, func() error { return r.SQLReader.Get(ctx, value, query, args...) })
+}
+
+// Token forwards to the wrapped reader when it is a ConsistentReader, and
+// returns an empty token otherwise.
+func (r *RetryingReader) Token(ctx context.Context) (string, error) {
+	consistent, ok := r.SQLReader.(ConsistentReader)
+
+	if !ok {
+		return "", nil
+	}
+
+	var token string
+
+	err := Retry(ctx, r.policy, func() error {
+		var err error
+		token, err = consistent.Token(ctx)
+		return err
+	})
+
+	return token, err
+}
+
+// Replicas forwards to the wrapped reader, or returns nil when it does not
+// read from replicas.
+func (r *RetryingReader) Replicas() []ReplicaStatus {
+	if balanced, ok := r.SQLReader.(interface{ Replicas() []ReplicaStatus }); ok {
+		return balanced.Replicas()
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+)
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	policy := db.RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	transient := errors.Join(db.ErrDBTransient, errors.New("connection reset"))
+
+	t.Run("retries transient errors until success", func(t *testing.T) {
+		calls := 0
+
+		err := db.Retry(ctx, policy, func() error {
+			calls++
+
+			if calls < 3 {
+				return transient
+			}
+
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("returns the last error once attempts are exhausted", func(t *testing.T) {
+		calls := 0
+
+		err := db.Retry(ctx, policy, func() error {
+			calls++
+			return transient
+		})
+
+		assert.ErrorIs(t, err, db.ErrDBTransient)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		calls := 0
+
+		err := db.Retry(ctx, policy, func() error {
+			calls++
+			return db.ErrDBResourceNotFound
+		})
+
+		assert.ErrorIs(t, err, db.ErrDBResourceNotFound)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		calls := 0
+		canceled, cancel := context.WithCancel(ctx)
+		slow := db.RetryPolicy{Attempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+
+		err := db.Retry(canceled, slow, func() error {
+			calls++
+			return transient
+		})
+
+		assert.ErrorIs(t, err, db.ErrDBTransient)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("does not back off past the context deadline", func(t *testing.T) {
+		calls := 0
+		short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		started := time.Now()
+		slow := db.RetryPolicy{Attempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+		err := db.Retry(short, slow, func() error {
+			calls++
+			return transient
+		})
+
+		assert.ErrorIs(t, err, db.ErrDBTransient)
+		assert.Less(t, time.Since(started), 50*time.Millisecond)
+	})
+}
+
+func TestRetryingReader(t *testing.T) {
+	ctx := context.Background()
+	query := "SELECT name FROM anything WHERE id = $1"
+	policy := db.RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	t.Run("retries reads failing with transient errors", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		reader := db.NewRetryingReader(fake.DB(), policy, fake.Observer())
+
+		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnError(&pq.Error{Code: "57P01"})
+		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("diego"))
+
+		var name string
+		err := reader.Get(ctx, &name, query, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "diego", name)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("does not retry reads failing with permanent errors", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		reader := db.NewRetryingReader(fake.DB(), policy, fake.Observer())
+
+		fake.DBMock.ExpectQuery(query).WithArgs(1).WillReturnError(&pq.Error{Code: "42P01"})
+
+		var names []string
+		err := reader.Select(ctx, &names, query, 1)
+
+		assert.Error(t, err)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("returns an empty token without replicas", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		reader := db.NewRetryingReader(fake.DB(), policy, fake.Observer())
+
+		token, err := reader.Token(ctx)
+
+		assert.NoError(t, err)
+		assert.Empty(t, token)
+		assert.Nil(t, reader.Replicas())
+	})
+}
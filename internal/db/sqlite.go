@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/migration"
+	"modernc.org/sqlite"
+)
+
+// SQLiteClient is a SQLClient backed by an embedded SQLite database, meant
+// for local development and tests. It shares the sqlx query proxy with
+// PostgresClient and only differs in how connections are opened and in its
+// dialect.
+type SQLiteClient struct {
+	PostgresClient
+}
+
+// NewSQLiteClientFromConfig opens the database and applies pending
+// migrations, so a fresh file or in-memory database is ready to serve.
+func NewSQLiteClientFromConfig(conf config.DatabaseConfiguration, observer observability.Observer) (SQLClient, error) {
+	db, err := newSQLiteDB(conf, observer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := NewMigrator(db, DialectSQLite, migration.SQLite, observer)
+
+	if err == nil {
+		_, err = migrator.Up(context.Background())
+	}
+
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return NewSQLiteClient(sqlx.NewDb(db, "sqlite"), observer), nil
+}
+
+func NewSQLiteClient(backend PostgresClientBackend, observer observability.Observer) *SQLiteClient {
+	return &SQLiteClient{
+		PostgresClient: PostgresClient{
+			PostgresProxy: PostgresProxy{
+				backend: backend,
+				logger:  observer.Logger().With("client", "sqlite"),
+			},
+		},
+	}
+}
+
+func (c SQLiteClient) Dialect() Dialect {
+	return DialectSQLite
+}
+
+// sqliteConnector opens connections to the database file described by the
+// configuration.
+type sqliteConnector struct {
+	conf config.DatabaseConfiguration
+}
+
+func (c sqliteConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dsn, err := c.conf.DSN()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Driver().Open(dsn)
+}
+
+func (c sqliteConnector) Driver() driver.Driver {
+	return &sqlite.Driver{}
+}
+
+// newSQLiteDB opens a pool limited to a single connection: SQLite allows a
+// single writer, and an in-memory database only lives as long as the
+// connection that created it.
+func newSQLiteDB(conf config.DatabaseConfiguration, observer observability.Observer) (*sql.DB, error) {
+	if _, err := conf.DSN(); err != nil {
+		return nil, err
+	}
+
+	db, err := observability.NewInstrumentedDB(observer, "sqlite", sqliteConnector{conf: conf})
+
+	if err != nil {
+		return nil, mapDBError(err)
+	}
+
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	return db, nil
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// WithTx runs fn in a transaction, committing it when fn returns nil and
+// rolling it back when fn returns an error or panics; a panic is re-raised
+// once the transaction is rolled back. fn must not commit or roll back the
+// transaction itself.
+//
+// Transient failures before the commit, and serialization failures and
+// deadlocks reported by the commit, run fn again in a new transaction, so fn
+// must not have side effects outside of it. Other commit failures are never
+// retried, since the transaction may have been applied. The outcome is
+// recorded on the active span.
+func (p PostgresClient) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(SQLTX) error) error {
+	attempts := 0
+	committing := false
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			observability.TraceTransaction(ctx, "transaction", observability.TxRolledBack, attempts, fmt.Errorf("panic: %v", recovered))
+			panic(recovered)
+		}
+	}()
+
+	retryable := func(err error) bool {
+		return errors.Is(err, ErrDBTransient) && (!committing || serializationFailure(err))
+	}
+
+	err := retry(ctx, DefaultRetryPolicy, retryable, func() error {
+		attempts++
+
+		var err error
+		committing, err = p.runTx(ctx, opts, fn)
+
+		return err
+	})
+
+	if err != nil {
+		observability.TraceTransaction(ctx, "transaction", observability.TxRolledBack, attempts, err)
+		return err
+	}
+
+	observability.TraceTransaction(ctx, "transaction", observability.TxCommitted, attempts, nil)
+	return nil
+}
+
+// runTx runs a single attempt of WithTx and reports whether it failed while
+// committing.
+func (p PostgresClient) runTx(ctx context.Context, opts *sql.TxOptions, fn func(SQLTX) error) (committing bool, err error) {
+	tx, err := p.BeginTx(ctx, opts)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			tx.Rollback()
+			panic(recovered)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// WithTx runs fn in a savepoint of the open transaction, releasing it when
+// fn returns nil and rolling back to it when fn returns an error or panics,
+// leaving the rest of the transaction intact. The options are ignored, as a
+// savepoint shares the isolation of its transaction, and nothing is
+// retried: a serialization failure aborts the whole transaction, which only
+// the outermost WithTx can run again.
+func (p PostgresTX) WithTx(ctx context.Context, _ *sql.TxOptions, fn func(SQLTX) error) error {
+	nested := PostgresTX{PostgresProxy: p.PostgresProxy, depth: p.depth + 1}
+	savepoint := fmt.Sprintf("tx_savepoint_%d", nested.depth)
+
+	if err := p.Exec(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return err
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			p.Exec(ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+			observability.TraceTransaction(ctx, "savepoint", observability.TxRolledBack, 1, fmt.Errorf("panic: %v", recovered))
+			panic(recovered)
+		}
+	}()
+
+	if err := fn(nested); err != nil {
+		observability.TraceTransaction(ctx, "savepoint", observability.TxRolledBack, 1, err)
+
+		if rollbackErr := p.Exec(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+
+		return err
+	}
+
+	if err := p.Exec(ctx, "RELEASE SAVEPOINT "+savepoint); err != nil {
+		return err
+	}
+
+	observability.TraceTransaction(ctx, "savepoint", observability.TxCommitted, 1, nil)
+	return nil
+}
+
+// serializationFailure reports whether err guarantees the transaction was
+// rolled back by the database, so running it again is always safe.
+func serializationFailure(err error) bool {
+	var pqErr *pq.Error
+
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "40001" || pqErr.Code == "40P01"
+	}
+
+	var sqliteErr *sqlite.Error
+
+	if errors.As(err, &sqliteErr) {
+		code := sqliteErr.Code() & 0xff
+		return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+	}
+
+	return false
+}
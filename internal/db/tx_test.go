@@ -0,0 +1,215 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	query := "UPDATE urls SET code = $1 WHERE id = $2"
+	failure := errors.New("failure")
+
+	t.Run("commits when the function succeeds", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectExec(query).WithArgs("1", 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		fake.DBMock.ExpectCommit()
+
+		err := fake.DB().WithTx(ctx, nil, func(tx db.SQLTX) error {
+			return tx.Exec(ctx, query, "1", 1)
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("rolls back when the function fails", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectRollback()
+
+		err := fake.DB().WithTx(ctx, nil, func(tx db.SQLTX) error { return failure })
+
+		assert.Equal(t, failure, err)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("rolls back and re-raises panics", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectRollback()
+
+		assert.PanicsWithValue(t, "boom", func() {
+			fake.DB().WithTx(ctx, nil, func(tx db.SQLTX) error { panic("boom") })
+		})
+
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("retries serialization failures", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		calls := 0
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectExec(query).WithArgs("1", 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		fake.DBMock.ExpectCommit().WillReturnError(&pq.Error{Code: "40001"})
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectExec(query).WithArgs("1", 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		fake.DBMock.ExpectCommit()
+
+		err := fake.DB().WithTx(ctx, nil, func(tx db.SQLTX) error {
+			calls++
+			return tx.Exec(ctx, query, "1", 1)
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("does not retry a commit that may have been applied", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		calls := 0
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectCommit().WillReturnError(io.ErrUnexpectedEOF)
+
+		err := fake.DB().WithTx(ctx, nil, func(tx db.SQLTX) error {
+			calls++
+			return nil
+		})
+
+		assert.ErrorIs(t, err, db.ErrDBTransient)
+		assert.Equal(t, 1, calls)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("does not retry permanent errors", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		calls := 0
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectExec(query).WithArgs("1", 1).WillReturnError(&pq.Error{Code: "23505"})
+		fake.DBMock.ExpectRollback()
+
+		err := fake.DB().WithTx(ctx, nil, func(tx db.SQLTX) error {
+			calls++
+			return tx.Exec(ctx, query, "1", 1)
+		})
+
+		assert.ErrorIs(t, err, db.ErrDBConflict)
+		assert.Equal(t, 1, calls)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("releases a nested savepoint", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectExec("SAVEPOINT tx_savepoint_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		fake.DBMock.ExpectExec("SAVEPOINT tx_savepoint_2").WillReturnResult(sqlmock.NewResult(0, 0))
+		fake.DBMock.ExpectExec(query).WithArgs("1", 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		fake.DBMock.ExpectExec("RELEASE SAVEPOINT tx_savepoint_2").WillReturnResult(sqlmock.NewResult(0, 0))
+		fake.DBMock.ExpectExec("RELEASE SAVEPOINT tx_savepoint_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		fake.DBMock.ExpectCommit()
+
+		err := fake.DB().WithTx(ctx, nil, func(tx db.SQLTX) error {
+			return tx.WithTx(ctx, nil, func(tx db.SQLTX) error {
+				return tx.WithTx(ctx, nil, func(tx db.SQLTX) error {
+					return tx.Exec(ctx, query, "1", 1)
+				})
+			})
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("rolls back to a failed savepoint and keeps the transaction", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectExec("SAVEPOINT tx_savepoint_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		fake.DBMock.ExpectExec("ROLLBACK TO SAVEPOINT tx_savepoint_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		fake.DBMock.ExpectExec(query).WithArgs("1", 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		fake.DBMock.ExpectCommit()
+
+		err := fake.DB().WithTx(ctx, nil, func(tx db.SQLTX) error {
+			nested := tx.WithTx(ctx, nil, func(tx db.SQLTX) error { return failure })
+			assert.Equal(t, failure, nested)
+
+			return tx.Exec(ctx, query, "1", 1)
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("records the outcome on the active span", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		recorder := tracetest.NewSpanRecorder()
+		spanCtx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(ctx, "request")
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectCommit().WillReturnError(&pq.Error{Code: "40P01"})
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectCommit()
+
+		err := fake.DB().WithTx(spanCtx, nil, func(tx db.SQLTX) error { return nil })
+		span.End()
+
+		require.NoError(t, err)
+		require.Len(t, recorder.Ended(), 1)
+
+		events := recorder.Ended()[0].Events()
+		require.Len(t, events, 1)
+
+		attributes := map[string]string{}
+
+		for _, attribute := range events[0].Attributes {
+			attributes[string(attribute.Key)] = attribute.Value.Emit()
+		}
+
+		assert.Equal(t, "db.transaction", events[0].Name)
+		assert.Equal(t, map[string]string{"db.transaction.outcome": "committed", "db.transaction.attempts": "2"}, attributes)
+	})
+}
+
+func TestWithTxOnSQLite(t *testing.T) {
+	ctx := context.Background()
+	client := test.NewSQLiteClient(t)
+	query := "INSERT INTO urls (id, target, code) VALUES ($1, $2, $3)"
+
+	err := client.WithTx(ctx, nil, func(tx db.SQLTX) error {
+		require.NoError(t, tx.Exec(ctx, query, 1, "first", "1"))
+
+		nested := tx.WithTx(ctx, nil, func(tx db.SQLTX) error {
+			require.NoError(t, tx.Exec(ctx, query, 2, "second", "2"))
+			return tx.Exec(ctx, query, 1, "duplicated", "1")
+		})
+
+		assert.ErrorIs(t, nested, db.ErrDBConflict)
+		return nil
+	})
+
+	require.NoError(t, err)
+
+	var targets []string
+	require.NoError(t, client.Select(ctx, &targets, "SELECT target FROM urls ORDER BY id"))
+	assert.Equal(t, []string{"first"}, targets)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/service"
+)
+
+// ConsistencyTokenHeader carries read-your-writes tokens. Successful writes
+// return it; clients send it back on the reads that must observe the write.
+const ConsistencyTokenHeader = "X-Consistency-Token"
+
+// consistencyToken matches a PostgreSQL log sequence number such as 0/16B3748.
+var consistencyToken = regexp.MustCompile(`^[0-9A-Fa-f]{1,8}/[0-9A-Fa-f]{1,8}$`)
+
+// ConsistencyMiddleware attaches the consistency token sent by the client to
+// the request context, and returns a fresh token on successful writes.
+// Malformed tokens are ignored.
+type ConsistencyMiddleware struct {
+	ConsistencySvc service.ConsistencyService
+	logger         observability.Logger
+}
+
+func NewConsistencyMiddleware(services service.Services, observer observability.Observer) ConsistencyMiddleware {
+	return ConsistencyMiddleware{
+		ConsistencySvc: services.Consistency,
+		logger:         observer.Logger().With("middleware", "consistency"),
+	}
+}
+
+func (m ConsistencyMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.Header.Get(ConsistencyTokenHeader); consistencyToken.MatchString(token) {
+			r = r.WithContext(db.WithConsistencyToken(r.Context(), token))
+		}
+
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(&consistencyWriter{ResponseWriter: w, request: r, middleware: m}, r)
+	})
+}
+
+// consistencyWriter adds the token header right before a successful write
+// response is sent, once the write is committed.
+type consistencyWriter struct {
+	http.ResponseWriter
+
+	request     *http.Request
+	middleware  ConsistencyMiddleware
+	wroteHeader bool
+}
+
+func (w *consistencyWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+
+	if statusCode >= 200 && statusCode < 300 {
+		ctx := w.request.Context()
+		token, err := w.middleware.ConsistencySvc.Token(ctx)
+
+		if err != nil {
+			w.middleware.logger.Warn(ctx, "error reading consistency token", slog.Any("error", err))
+		} else if token != "" {
+			w.Header().Set(ConsistencyTokenHeader, token)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *consistencyWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(data)
+}
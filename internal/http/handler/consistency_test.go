@@ -0,0 +1,95 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/http/handler"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+)
+
+type fakeConsistencySvc struct {
+	token string
+	err   error
+}
+
+func (s fakeConsistencySvc) Token(ctx context.Context) (string, error) {
+	return s.token, s.err
+}
+
+func TestConsistencyMiddleware(t *testing.T) {
+	newMiddleware := func(svc fakeConsistencySvc) handler.ConsistencyMiddleware {
+		fake := test.NewFakeDependencies(t)
+		middleware := handler.NewConsistencyMiddleware(fake.Services(), fake.Observer())
+		middleware.ConsistencySvc = svc
+
+		return middleware
+	}
+
+	respond := func(statusCode int, seen *string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if seen != nil {
+				*seen = db.ConsistencyTokenFromContext(r.Context())
+			}
+
+			w.WriteHeader(statusCode)
+		})
+	}
+
+	t.Run("returns a token on successful writes", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/url/", nil)
+
+		newMiddleware(fakeConsistencySvc{token: "0/16B3748"}).Handler(respond(http.StatusCreated, nil)).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "0/16B3748", rec.Header().Get(handler.ConsistencyTokenHeader))
+	})
+
+	t.Run("does not return a token on failed writes", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/url/", nil)
+
+		newMiddleware(fakeConsistencySvc{token: "0/16B3748"}).Handler(respond(http.StatusBadRequest, nil)).ServeHTTP(rec, req)
+
+		assert.Empty(t, rec.Header().Get(handler.ConsistencyTokenHeader))
+	})
+
+	t.Run("writes succeed when the token cannot be read", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/url/", nil)
+
+		newMiddleware(fakeConsistencySvc{err: errors.New("primary unavailable")}).Handler(respond(http.StatusCreated, nil)).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(handler.ConsistencyTokenHeader))
+	})
+
+	t.Run("attaches the client token to reads", func(t *testing.T) {
+		var seen string
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/url/1", nil)
+		req.Header.Set(handler.ConsistencyTokenHeader, "0/16B3748")
+
+		newMiddleware(fakeConsistencySvc{token: "0/FFFFFFF"}).Handler(respond(http.StatusOK, &seen)).ServeHTTP(rec, req)
+
+		assert.Equal(t, "0/16B3748", seen)
+		assert.Empty(t, rec.Header().Get(handler.ConsistencyTokenHeader))
+	})
+
+	t.Run("ignores malformed tokens", func(t *testing.T) {
+		var seen string
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/url/1", nil)
+		req.Header.Set(handler.ConsistencyTokenHeader, "0/16B3748'; DROP TABLE urls")
+
+		newMiddleware(fakeConsistencySvc{}).Handler(respond(http.StatusOK, &seen)).ServeHTTP(rec, req)
+
+		assert.Empty(t, seen)
+	})
+}
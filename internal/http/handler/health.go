@@ -13,12 +13,14 @@ import (
 
 type HealthHandler struct {
 	HealthSvc service.HealthService
+	observer  observability.Observer
 	logger    observability.Logger
 }
 
 func NewHealthHandler(services service.Services, observer observability.Observer) HealthHandler {
 	return HealthHandler{
 		HealthSvc: services.Health,
+		observer:  observer,
 		logger:    observer.Logger().With("handler", "health"),
 	}
 }
@@ -43,14 +45,19 @@ func (h HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	var statusCode int = http.StatusOK
-	var health model.Health = model.Health{Status: "ready"}
+	var health model.Health = model.Health{Status: "ready", Telemetry: h.observer.TelemetryMode()}
 	reason, err := h.HealthSvc.Ping(ctx)
+	health.Replicas = h.HealthSvc.Replicas(ctx)
 
 	if err != nil {
 		health.Reason = reason
 		health.Status = "not_ready"
 		statusCode = http.StatusServiceUnavailable
 		observability.TraceError(ctx, http.StatusText(http.StatusServiceUnavailable), err)
+	} else if degraded := h.HealthSvc.Degraded(ctx); len(degraded) > 0 {
+		// Optional dependencies are down but requests are still served.
+		health.Status = "degraded"
+		health.Degraded = degraded
 	}
 
 	data, err := json.Marshal(health)
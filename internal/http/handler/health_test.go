@@ -8,7 +8,6 @@ import (
 	json "github.com/json-iterator/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/zeon-code/tiny-url/internal/http/handler"
 	"github.com/zeon-code/tiny-url/internal/model"
 	"github.com/zeon-code/tiny-url/internal/pkg/test"
 )
@@ -16,8 +15,8 @@ import (
 func TestHealthHandler(t *testing.T) {
 	t.Run("healthcheck ready", func(t *testing.T) {
 		var payload model.Health
-		fake := test.NewFakeDependencies()
-		router := handler.NewRouter(fake.Services(), fake.Observer())
+		fake := test.NewFakeDependencies(t)
+		router := fake.AdminRouter()
 
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
@@ -28,13 +27,31 @@ func TestHealthHandler(t *testing.T) {
 		err := json.NewDecoder(rec.Body).Decode(&payload)
 		require.NoError(t, err)
 
-		assert.Equal(t, model.Health{Status: "ready", Reason: ""}, payload)
+		assert.Equal(t, model.Health{Status: "ready", Reason: "", Telemetry: "none"}, payload)
+	})
+
+	t.Run("healthcheck ready is degraded without cache", func(t *testing.T) {
+		var payload model.Health
+		fake := test.NewFakeDependencies(t)
+		router := fake.AdminRouter()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+
+		fake.CacheClient.Close()
+		router.ServeHTTP(rec, req)
+
+		err := json.NewDecoder(rec.Body).Decode(&payload)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, model.Health{Status: "degraded", Degraded: []string{"cache_unavailable"}, Telemetry: "none"}, payload)
 	})
 
 	t.Run("healthcheck live", func(t *testing.T) {
 		var payload model.Health
-		fake := test.NewFakeDependencies()
-		router := handler.NewRouter(fake.Services(), fake.Observer())
+		fake := test.NewFakeDependencies(t)
+		router := fake.AdminRouter()
 
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodGet, "/health/live", nil)
@@ -47,4 +64,15 @@ func TestHealthHandler(t *testing.T) {
 
 		assert.Equal(t, model.Health{Status: "alive", Reason: ""}, payload)
 	})
+	t.Run("healthcheck is not served by the public router", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := fake.Router()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
 }
@@ -2,26 +2,71 @@ package handler
 
 import (
 	"net/http"
+	"net/http/pprof"
 
+	"github.com/zeon-code/tiny-url/internal/model"
 	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/pkg/pagination"
 	"github.com/zeon-code/tiny-url/internal/service"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
-func NewRouter(svc service.Services, observer observability.Observer) http.Handler {
+func NewRouter(svc service.Services, pager pagination.Pager, observer observability.Observer) http.Handler {
 	mux := http.NewServeMux()
 
-	url := NewUrlHandler(svc, observer)
-	health := NewHealthHandler(svc, observer)
+	url := NewUrlHandler(svc, pager, observer)
+	consistency := NewConsistencyMiddleware(svc, observer)
+
+	mux.HandleFunc("GET /r/{code}", cacheControl("no-store", url.Redirect))
+
+	mux.HandleFunc("GET /api/v1/url/", cacheControl("private, no-cache", url.List))
+	mux.HandleFunc("POST /api/v1/url/", cacheControl("no-store", url.Create))
+	mux.HandleFunc("GET /api/v1/url/{id}", cacheControl("private, no-cache", url.GetByID))
 
-	mux.HandleFunc("GET /r/{code}", url.Redirect)
+	return otelhttp.NewHandler(consistency.Handler(mux), "server")
+}
+
+// NewAdminRouter builds the handler served on the internal admin listener.
+// It carries probes, profiling, runtime information, metrics scraping and
+// webhook management, none of which should be reachable from the public
+// listener. Webhook routes trust the owner they are given, so they stay
+// here until owners can authenticate.
+func NewAdminRouter(svc service.Services, observer observability.Observer, build model.Build) http.Handler {
+	mux := http.NewServeMux()
 
-	mux.HandleFunc("GET /api/v1/url/", url.List)
-	mux.HandleFunc("POST /api/v1/url/", url.Create)
-	mux.HandleFunc("GET /api/v1/url/{id}", url.GetByID)
+	health := NewHealthHandler(svc, observer)
+	runtime := NewRuntimeHandler(build, observer)
+	webhook := NewWebhookHandler(svc, observer)
 
 	mux.HandleFunc("GET /health/ready", health.Ready)
 	mux.HandleFunc("GET /health/live", health.Live)
 
-	return otelhttp.NewHandler(mux, "server")
+	mux.HandleFunc("GET /runtime", runtime.Info)
+	mux.Handle("GET /metrics", observer.MetricHandler())
+
+	mux.HandleFunc("GET /api/v1/webhooks/", cacheControl("no-store", webhook.List))
+	mux.HandleFunc("POST /api/v1/webhooks/", cacheControl("no-store", webhook.Subscribe))
+	mux.HandleFunc("DELETE /api/v1/webhooks/{id}", cacheControl("no-store", webhook.Unsubscribe))
+	mux.HandleFunc("GET /api/v1/webhooks/{id}/dead-letters", cacheControl("no-store", webhook.DeadLetters))
+	mux.HandleFunc("POST /api/v1/webhooks/{id}/dead-letters/replay", cacheControl("no-store", webhook.ReplayAll))
+	mux.HandleFunc("POST /api/v1/webhooks/{id}/dead-letters/{delivery}/replay", cacheControl("no-store", webhook.Replay))
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return mux
+}
+
+// cacheControl sets the Cache-Control policy of a route. Link reads must
+// be revalidated, which their validators make cheap; writes and webhook
+// responses, which may carry secrets, are never stored. Handlers may
+// override the policy on specific responses, as redirects do.
+func cacheControl(policy string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", policy)
+		next(w, r)
+	}
 }
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+type RuntimeHandler struct {
+	build  model.Build
+	logger observability.Logger
+}
+
+func NewRuntimeHandler(build model.Build, observer observability.Observer) RuntimeHandler {
+	return RuntimeHandler{
+		build:  build,
+		logger: observer.Logger().With("handler", "runtime"),
+	}
+}
+
+func (h RuntimeHandler) Info(w http.ResponseWriter, r *http.Request) {
+	data, err := json.Marshal(model.Runtime{
+		Version:    h.build.Version,
+		Commit:     h.build.Commit,
+		GoVersion:  runtime.Version(),
+		StartedAt:  h.build.StartedAt.UTC(),
+		Uptime:     time.Since(h.build.StartedAt).Round(time.Second).String(),
+		Goroutines: runtime.NumGoroutine(),
+	})
+
+	if err != nil {
+		observability.TraceError(r.Context(), http.StatusText(http.StatusInternalServerError), err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
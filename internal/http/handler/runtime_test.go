@@ -0,0 +1,47 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	json "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+)
+
+func TestRuntimeHandler(t *testing.T) {
+	t.Run("runtime info", func(t *testing.T) {
+		var payload model.Runtime
+		fake := test.NewFakeDependencies(t)
+		router := fake.AdminRouter()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/runtime", nil)
+
+		router.ServeHTTP(rec, req)
+
+		err := json.NewDecoder(rec.Body).Decode(&payload)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "test", payload.Version)
+		assert.Equal(t, runtime.Version(), payload.GoVersion)
+		assert.NotEmpty(t, payload.Uptime)
+	})
+
+	t.Run("pprof index", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := fake.AdminRouter()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
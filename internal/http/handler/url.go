@@ -1,16 +1,24 @@
 package handler
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	json "github.com/json-iterator/go"
 	"github.com/zeon-code/tiny-url/internal/db"
 	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/base62"
 	"github.com/zeon-code/tiny-url/internal/pkg/cache"
+	"github.com/zeon-code/tiny-url/internal/pkg/conditional"
 	"github.com/zeon-code/tiny-url/internal/pkg/observability"
 	"github.com/zeon-code/tiny-url/internal/pkg/pagination"
 	"github.com/zeon-code/tiny-url/internal/service"
@@ -18,13 +26,27 @@ import (
 
 type UrlHandler struct {
 	UrlSvc service.URLService
+	clicks *service.ClickCounter
+	pager  pagination.Pager
+	metric observability.MetricClient
 	logger observability.Logger
 }
 
-func NewUrlHandler(services service.Services, observer observability.Observer) UrlHandler {
+func NewUrlHandler(services service.Services, pager pagination.Pager, observer observability.Observer) UrlHandler {
+	logger := observer.Logger().With("handler", "url")
+	metric, err := observer.Metric()
+
+	if err != nil {
+		logger.Error(context.Background(), "error building metric client", slog.Any("error", err))
+		metric = observability.NewNoopMetricClient()
+	}
+
 	return UrlHandler{
 		UrlSvc: services.Url,
-		logger: observer.Logger().With("handler", "url"),
+		clicks: services.Clicks,
+		pager:  pager,
+		metric: metric,
+		logger: logger,
 	}
 }
 
@@ -33,14 +55,24 @@ type UrlListResponse struct {
 	Page pagination.Page `json:"page"`
 }
 
+// maxOwnerLength is the size of the owner columns of links and webhook
+// subscriptions.
+const maxOwnerLength = 64
+
 type UrlCreateRequest struct {
 	Target string `json:"target"`
+	// Owner is refused: public callers cannot authenticate, so they could
+	// otherwise trigger another owner's webhooks. Owned links are created
+	// with tinyctl.
+	Owner     string     `json:"owner,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 type UrlCreateResponse struct {
-	ID     int64  `json:"id"`
-	Code   string `json:"code"`
-	Target string `json:"target"`
+	ID        int64      `json:"id"`
+	Code      string     `json:"code"`
+	Target    string     `json:"target"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 func (h UrlHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -49,6 +81,7 @@ func (h UrlHandler) Create(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	if r.Header.Get("Content-Type") != "application/json" {
+		h.metric.ValidationRejected(ctx, "create", observability.ValidationContentType)
 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 		return
 	}
@@ -63,24 +96,44 @@ func (h UrlHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err = json.Unmarshal(body, &request); err != nil {
+		h.metric.ValidationRejected(ctx, "create", observability.ValidationBody)
 		observability.TraceError(ctx, http.StatusText(http.StatusBadRequest), err)
 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 		return
 	}
 
-	url, err := h.UrlSvc.Create(ctx, request.Target)
+	if request.Owner != "" || (request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now())) {
+		h.metric.ValidationRejected(ctx, "create", observability.ValidationBody)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	url, err := h.UrlSvc.Create(ctx, request.Target, "", request.ExpiresAt)
 
-	if err != nil {
+	if errors.Is(err, db.ErrDBConflict) {
+		h.logger.Warn(ctx, "url conflicts with an existing one", slog.String("constraint", constraintName(err)))
+		observability.TraceError(ctx, http.StatusText(http.StatusConflict), err)
+		http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
+		return
+	} else if errors.Is(err, db.ErrDBConstraint) {
+		h.logger.Warn(ctx, "url rejected by a constraint", slog.String("constraint", constraintName(err)))
+		observability.TraceError(ctx, http.StatusText(http.StatusUnprocessableEntity), err)
+		http.Error(w, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+		return
+	} else if err != nil {
 		h.logger.Error(ctx, "error creating url", slog.Any("error", err))
 		observability.TraceError(ctx, http.StatusText(http.StatusInternalServerError), err)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
+	h.metric.LinkCreated(ctx)
+
 	data, err := json.Marshal(UrlCreateResponse{
-		ID:     url.ID,
-		Code:   url.Code,
-		Target: url.Target,
+		ID:        url.ID,
+		Code:      url.Code,
+		Target:    url.Target,
+		ExpiresAt: url.ExpiresAt,
 	})
 
 	if err != nil {
@@ -93,17 +146,65 @@ func (h UrlHandler) Create(w http.ResponseWriter, r *http.Request) {
 	w.Write(data)
 }
 
+// List pages through links, newest first unless order=asc. The cursor
+// of a page carries the order and filters of the listing, so only limit
+// may change while following it.
 func (h UrlHandler) List(w http.ResponseWriter, r *http.Request) {
-	limit := 50
 	ctx := r.Context()
+	query := r.URL.Query()
 
 	if r.Header.Get("Accept") != "application/json" {
+		h.metric.ValidationRejected(ctx, "list", observability.ValidationAccept)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	limit, err := h.pager.Limit(query.Get("limit"))
+
+	if err != nil {
+		h.metric.ValidationRejected(ctx, "list", observability.ValidationLimit)
+		observability.TraceError(ctx, http.StatusText(http.StatusBadRequest), err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	order := pagination.Order(query.Get("order"))
+
+	if order != "" && order != pagination.Ascending && order != pagination.Descending {
+		h.metric.ValidationRejected(ctx, "list", observability.ValidationOrder)
+		observability.TraceError(ctx, http.StatusText(http.StatusBadRequest), fmt.Errorf("invalid order %q", order))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseURLFilter(query)
+
+	if err != nil {
+		h.metric.ValidationRejected(ctx, "list", observability.ValidationFilter)
+		observability.TraceError(ctx, http.StatusText(http.StatusBadRequest), err)
 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 		return
 	}
 
-	direction, cursor := pagination.GetCursor(r)
-	urls, err := h.UrlSvc.List(cache.WithCache(ctx), limit, direction, cursor)
+	fingerprint := filterValues(filter).Encode()
+	page := pagination.Query{Limit: limit, Order: order, Direction: pagination.Forward}
+
+	if page.Order == "" {
+		page.Order = pagination.Descending
+	}
+
+	if token := query.Get("cursor"); token != "" {
+		if page, filter, err = h.resumeList(token, limit, order, fingerprint); err != nil {
+			h.metric.ValidationRejected(ctx, "list", observability.ValidationCursor)
+			observability.TraceError(ctx, http.StatusText(http.StatusBadRequest), err)
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		fingerprint = filterValues(filter).Encode()
+	}
+
+	urls, err := h.UrlSvc.List(cache.WithCache(ctx), page, filter)
 
 	if err != nil {
 		observability.TraceError(ctx, http.StatusText(http.StatusInternalServerError), err)
@@ -112,7 +213,8 @@ func (h UrlHandler) List(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cursorKey := func(u model.URL) int64 { return u.ID }
-	data, err := pagination.NewPagination(urls, limit, cursor).Encode(cursorKey)
+	listing := pagination.NewPagination(urls, page, fingerprint).WithCursors(h.pager, cursorKey)
+	data, err := listing.Encode()
 
 	if err != nil {
 		observability.TraceError(ctx, http.StatusText(http.StatusInternalServerError), err)
@@ -120,14 +222,56 @@ func (h UrlHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if links := listing.Page.Links(*r.URL, limit); links != "" {
+		w.Header().Set("Link", links)
+	}
+
+	etag := conditional.HashETag(data)
+	conditional.SetValidators(w, etag, time.Time{})
+
+	if conditional.NotModified(r, etag, time.Time{}) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 	w.Write(data)
 }
 
+// resumeList returns the page a cursor points to and the filter of its
+// listing. An order or filter sent along with the cursor must match the
+// ones it was issued for.
+func (h UrlHandler) resumeList(token string, limit int, order pagination.Order, fingerprint string) (pagination.Query, model.URLFilter, error) {
+	cursor, err := h.pager.Parse(token)
+
+	if err != nil {
+		return pagination.Query{}, model.URLFilter{}, err
+	}
+
+	if (order != "" && order != cursor.Order) || (fingerprint != "" && fingerprint != cursor.Filter) {
+		return pagination.Query{}, model.URLFilter{}, fmt.Errorf("%w: parameters differ from its listing", pagination.ErrInvalidCursor)
+	}
+
+	values, err := url.ParseQuery(cursor.Filter)
+
+	if err != nil {
+		return pagination.Query{}, model.URLFilter{}, fmt.Errorf("%w: %w", pagination.ErrInvalidCursor, err)
+	}
+
+	filter, err := parseURLFilter(values)
+
+	if err != nil {
+		return pagination.Query{}, model.URLFilter{}, fmt.Errorf("%w: %w", pagination.ErrInvalidCursor, err)
+	}
+
+	return cursor.Query(limit), filter, nil
+}
+
 func (h UrlHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	if r.Header.Get("Accept") != "application/json" {
+		h.metric.ValidationRejected(ctx, "get", observability.ValidationAccept)
 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 		return
 	}
@@ -135,6 +279,7 @@ func (h UrlHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 
 	if err != nil {
+		h.metric.ValidationRejected(ctx, "get", observability.ValidationID)
 		observability.TraceError(ctx, http.StatusText(http.StatusBadRequest), err)
 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 		return
@@ -152,6 +297,14 @@ func (h UrlHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	etag, modified := urlValidators(*url)
+	conditional.SetValidators(w, etag, modified)
+
+	if conditional.NotModified(r, etag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	data, err := json.Marshal(url)
 
 	if err != nil {
@@ -164,11 +317,26 @@ func (h UrlHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	w.Write(data)
 }
 
+// urlValidators returns the entity tag and modification time of a link,
+// which change with every update since each one moves updated_at. Update
+// and delete endpoints check them through conditional.PreconditionFailed.
+func urlValidators(u model.URL) (string, time.Time) {
+	version := base62.Encode(u.ID)
+
+	if u.UpdatedAt == nil {
+		return conditional.ETag(version), time.Time{}
+	}
+
+	return conditional.ETag(version + "-" + strconv.FormatInt(u.UpdatedAt.UnixNano(), 36)), *u.UpdatedAt
+}
+
 func (h UrlHandler) Redirect(w http.ResponseWriter, r *http.Request) {
+	startAt := time.Now()
 	ctx := r.Context()
 	code := r.PathValue("code")
 
-	if code == "" {
+	if !base62.IsValid(code) {
+		h.metric.ValidationRejected(ctx, "redirect", observability.ValidationCode)
 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 		return
 	}
@@ -176,15 +344,150 @@ func (h UrlHandler) Redirect(w http.ResponseWriter, r *http.Request) {
 	url, err := h.UrlSvc.GetByCode(cache.WithCache(ctx), code)
 
 	if errors.Is(err, db.ErrDBResourceNotFound) {
+		h.metric.Redirect(ctx, observability.RedirectNotFound, time.Since(startAt))
 		observability.TraceError(ctx, http.StatusText(http.StatusNotFound), err)
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
 		return
 	} else if err != nil {
+		h.metric.Redirect(ctx, observability.RedirectError, time.Since(startAt))
 		observability.TraceError(ctx, http.StatusText(http.StatusInternalServerError), err)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
+	if url.IsExpired(time.Now()) {
+		h.metric.Redirect(ctx, observability.RedirectExpired, time.Since(startAt))
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	if !url.IsActive() {
+		h.metric.Redirect(ctx, observability.RedirectNotFound, time.Since(startAt))
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	h.clicks.Count(url.ID)
+
+	w.Header().Set("Cache-Control", redirectCacheControl(*url, time.Now()))
 	w.Header().Set("Location", url.Target)
 	w.WriteHeader(http.StatusFound)
+	h.metric.Redirect(ctx, observability.RedirectFound, time.Since(startAt))
+}
+
+// redirectMaxAge bounds how long a client may reuse a redirect, and so how
+// long a disabled link may keep redirecting for it.
+const redirectMaxAge = time.Minute
+
+// redirectCacheControl lets the client reuse the redirect of u, never past
+// its expiry. Shared caches may not store it, as they would keep
+// redirecting every client after the link is disabled or deleted.
+func redirectCacheControl(u model.URL, now time.Time) string {
+	maxAge := redirectMaxAge
+
+	if u.ExpiresAt != nil && u.ExpiresAt.Sub(now) < maxAge {
+		maxAge = u.ExpiresAt.Sub(now)
+	}
+
+	return fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds()))
+}
+
+// Bounds of the search parameters of listings.
+const (
+	maxQueryLength  = 256
+	maxDomainLength = 255
+	maxCodeLength   = 20
+)
+
+// parseURLFilter reads the search parameters of a listing: q, domain, code
+// (a prefix), owner, status, created_after and created_before (RFC 3339).
+func parseURLFilter(query url.Values) (model.URLFilter, error) {
+	filter := model.URLFilter{
+		Query:      query.Get("q"),
+		Domain:     query.Get("domain"),
+		CodePrefix: query.Get("code"),
+		Owner:      query.Get("owner"),
+		Status:     model.URLStatus(query.Get("status")),
+	}
+
+	if len(filter.Query) > maxQueryLength || len(filter.Domain) > maxDomainLength || len(filter.Owner) > maxOwnerLength {
+		return filter, errors.New("search parameter too long")
+	}
+
+	if filter.CodePrefix != "" && (len(filter.CodePrefix) > maxCodeLength || !base62.IsValid(filter.CodePrefix)) {
+		return filter, fmt.Errorf("invalid code prefix %q", filter.CodePrefix)
+	}
+
+	if filter.Status != "" && !slices.Contains(model.URLStatuses, filter.Status) {
+		return filter, fmt.Errorf("invalid status %q", filter.Status)
+	}
+
+	var err error
+
+	if filter.CreatedAfter, err = parseTime(query, "created_after"); err != nil {
+		return filter, err
+	}
+
+	if filter.CreatedBefore, err = parseTime(query, "created_before"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// filterValues returns the search parameters selecting filter, in a
+// canonical form: two requests for the same filter encode the same way.
+func filterValues(filter model.URLFilter) url.Values {
+	values := url.Values{}
+
+	set := func(name string, value string) {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+
+	set("q", filter.Query)
+	set("domain", strings.ToLower(filter.Domain))
+	set("code", filter.CodePrefix)
+	set("owner", filter.Owner)
+	set("status", string(filter.Status))
+
+	if filter.CreatedAfter != nil {
+		set("created_after", filter.CreatedAfter.UTC().Format(time.RFC3339Nano))
+	}
+
+	if filter.CreatedBefore != nil {
+		set("created_before", filter.CreatedBefore.UTC().Format(time.RFC3339Nano))
+	}
+
+	return values
+}
+
+// parseTime reads the named RFC 3339 parameter, which may be absent.
+func parseTime(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+
+	if value == "" {
+		return nil, nil
+	}
+
+	at, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return &at, nil
+}
+
+// constraintName returns the constraint a rejected write violated, when
+// the database reported it.
+func constraintName(err error) string {
+	var constraintErr *db.ConstraintError
+
+	if errors.As(err, &constraintErr) {
+		return constraintErr.Constraint
+	}
+
+	return ""
 }
@@ -2,26 +2,32 @@ package handler_test
 
 import (
 	"bytes"
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	json "github.com/json-iterator/go"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/zeon-code/tiny-url/internal/http/handler"
 	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
 	"github.com/zeon-code/tiny-url/internal/pkg/pagination"
 	"github.com/zeon-code/tiny-url/internal/pkg/test"
 )
 
 func TestUrlHandler(t *testing.T) {
+	createQuery := "INSERT INTO urls (target, code, owner, expires_at, domain) VALUES ($1, '', $2, $3, $4) RETURNING id, target, code, owner, created_at, updated_at, expires_at"
 
 	t.Run("create url", func(t *testing.T) {
 		var payload handler.UrlCreateResponse
-		fake := test.NewFakeDependencies()
-		router := handler.NewRouter(fake.Services(), fake.Observer())
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
 
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodPost, "/api/v1/url/", bytes.NewBufferString(`{"target":"target"}`))
@@ -35,12 +41,122 @@ func TestUrlHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusCreated, rec.Code)
 		assert.Equal(t, handler.UrlCreateResponse{ID: 1, Code: "1", Target: "target"}, payload)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		assert.Equal(t, 1, fake.HTTPMetric.LinkCreatedCount)
+	})
+
+	t.Run("create url without json content type", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/url/", bytes.NewBufferString(`{"target":"target"}`))
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, 0, fake.HTTPMetric.LinkCreatedCount)
+		assert.Equal(t, "create", fake.HTTPMetric.LastValidationOperation)
+		assert.Equal(t, observability.ValidationContentType, fake.HTTPMetric.LastValidationRejectCause)
+	})
+
+	t.Run("create url with an expiry", func(t *testing.T) {
+		var payload handler.UrlCreateResponse
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+		expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/url/", bytes.NewBufferString(`{"target":"target","expires_at":"`+expiresAt.Format(time.RFC3339)+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		rows := sqlmock.NewRows([]string{"id", "target", "code", "owner", "created_at", "updated_at", "expires_at"}).
+			AddRow(int64(1), "target", "", "", time.Now(), time.Now(), expiresAt)
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(createQuery).WithArgs("target", "", expiresAt, "").WillReturnRows(rows)
+		fake.DBMock.ExpectExec("UPDATE urls SET code = $1 WHERE id = $2").WithArgs("1", int64(1)).WillReturnResult(sqlmock.NewResult(1, 1))
+		fake.DBMock.ExpectExec("INSERT INTO outbox (link_id, event_type, payload) VALUES ($1, $2, $3)").WithArgs(int64(1), model.EventLinkCreated, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+		fake.DBMock.ExpectCommit()
+		router.ServeHTTP(rec, req)
+
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&payload))
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		require.NotNil(t, payload.ExpiresAt)
+		assert.True(t, expiresAt.Equal(*payload.ExpiresAt))
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("create url with an owner", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/url/", bytes.NewBufferString(`{"target":"target","owner":"acme"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, 0, fake.HTTPMetric.LinkCreatedCount)
+		assert.Equal(t, observability.ValidationBody, fake.HTTPMetric.LastValidationRejectCause)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("create url expiring in the past", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/url/", bytes.NewBufferString(`{"target":"target","expires_at":"2020-01-01T00:00:00Z"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, 0, fake.HTTPMetric.LinkCreatedCount)
+		assert.Equal(t, observability.ValidationBody, fake.HTTPMetric.LastValidationRejectCause)
+	})
+
+	t.Run("create url conflicting with an existing one", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/url/", bytes.NewBufferString(`{"target":"target"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(createQuery).WithArgs("target", "", nil, "").WillReturnError(&pq.Error{Code: "23505", Constraint: "urls_code_key"})
+		fake.DBMock.ExpectRollback()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, 0, fake.HTTPMetric.LinkCreatedCount)
+	})
+
+	t.Run("create url rejected by a constraint", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/url/", bytes.NewBufferString(`{"target":"target"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(createQuery).WithArgs("target", "", nil, "").WillReturnError(&pq.Error{Code: "23514", Constraint: "urls_target_check"})
+		fake.DBMock.ExpectRollback()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, 0, fake.HTTPMetric.LinkCreatedCount)
 	})
 
 	t.Run("list urls", func(t *testing.T) {
 		var payload pagination.Pagination[model.URL]
-		fake := test.NewFakeDependencies()
-		router := handler.NewRouter(fake.Services(), fake.Observer())
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
 
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodGet, "/api/v1/url/", nil)
@@ -65,19 +181,101 @@ func TestUrlHandler(t *testing.T) {
 				Size: 5,
 			},
 		}, payload)
+		assert.NotEmpty(t, rec.Header().Get("ETag"))
+		assert.Empty(t, rec.Header().Get("Last-Modified"))
+		assert.Equal(t, "private, no-cache", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("list urls not modified", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/url/", nil)
+		req.Header.Set("Accept", "application/json")
+
+		fake.MockUrlList()
+		router.ServeHTTP(rec, req)
+		etag := rec.Header().Get("ETag")
+
+		rec = httptest.NewRecorder()
+		req.Header.Set("If-None-Match", etag)
+
+		fake.MockUrlList()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Equal(t, etag, rec.Header().Get("ETag"))
+		assert.Empty(t, rec.Body.Bytes())
+
+		rec = httptest.NewRecorder()
+		req.Header.Set("If-None-Match", `"stale"`)
+
+		fake.MockUrlList()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, etag, rec.Header().Get("ETag"))
+	})
+
+	t.Run("search urls", func(t *testing.T) {
+		var payload pagination.Pagination[model.URL]
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/url/?q=docs&domain=Example.com&code=a1&owner=acme&status=active&created_after=2026-01-01T00:00:00Z", nil)
+		req.Header.Set("Accept", "application/json")
+
+		query := "SELECT id, code, target FROM urls WHERE deleted_at IS NULL" +
+			` AND target ILIKE '%' || $1 || '%' ESCAPE '\'` +
+			` AND (domain = $2 OR domain LIKE $3 ESCAPE '\')` +
+			" AND code LIKE $4 || '%'" +
+			" AND owner = $5" +
+			" AND created_at >= $6" +
+			" AND disabled_at IS NULL AND (expires_at IS NULL OR expires_at > $7)" +
+			" ORDER BY id DESC LIMIT $8"
+
+		fake.DBMock.ExpectQuery(query).
+			WithArgs("docs", "example.com", "%.example.com", "a1", "acme", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), sqlmock.AnyArg(), 51).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "code", "target"}).AddRow(int64(620), "a1", "https://example.com/docs"))
+		router.ServeHTTP(rec, req)
+
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&payload))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []model.URL{{ID: 620, Code: "a1", Target: "https://example.com/docs"}}, payload.Items)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("search urls with invalid filters", func(t *testing.T) {
+		for _, query := range []string{"status=archived", "code=not-a-code", "created_after=yesterday", "owner=" + strings.Repeat("a", 65)} {
+			fake := test.NewFakeDependencies(t)
+			router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/url/?"+query, nil)
+			req.Header.Set("Accept", "application/json")
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+			assert.Equal(t, "list", fake.HTTPMetric.LastValidationOperation, query)
+			assert.Equal(t, observability.ValidationFilter, fake.HTTPMetric.LastValidationRejectCause, query)
+		}
 	})
 
 	t.Run("list urls with cursor", func(t *testing.T) {
 		var payload pagination.Pagination[model.URL]
-		fake := test.NewFakeDependencies()
-		router := handler.NewRouter(fake.Services(), fake.Observer())
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
 
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodGet, "/api/v1/url/", nil)
 		req.Header.Set("Accept", "application/json")
 
 		query := req.URL.Query()
-		query.Add("cursor", ">1")
+		query.Add("cursor", fake.Pager().Sign(pagination.Cursor{Order: pagination.Descending, Direction: pagination.Backward, Key: 1}))
 		req.URL.RawQuery = query.Encode()
 
 		fake.MockPaginatedUrlList()
@@ -86,6 +284,8 @@ func TestUrlHandler(t *testing.T) {
 		err := json.NewDecoder(rec.Body).Decode(&payload)
 		require.NoError(t, err)
 
+		next := fake.Pager().Sign(pagination.Cursor{Order: pagination.Descending, Direction: pagination.Forward, Key: 2})
+
 		assert.Equal(t, http.StatusOK, rec.Code)
 		assert.Equal(t, pagination.Pagination[model.URL]{
 			Items: []model.URL{
@@ -96,16 +296,95 @@ func TestUrlHandler(t *testing.T) {
 				{ID: 2, Target: "target2", Code: "2"},
 			},
 			Page: pagination.Page{
-				Previous: ">6",
-				Size:     5,
+				Next: next,
+				Size: 5,
 			},
 		}, payload)
+		assert.Equal(t, `</api/v1/url/?cursor=`+next+`&limit=50>; rel="next"`, rec.Header().Get("Link"))
+	})
+
+	t.Run("list urls in ascending order with limit", func(t *testing.T) {
+		var payload pagination.Pagination[model.URL]
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/url/?order=asc&limit=2", nil)
+		req.Header.Set("Accept", "application/json")
+
+		fake.DBMock.ExpectQuery("SELECT id, code, target FROM urls WHERE deleted_at IS NULL ORDER BY id ASC LIMIT $1").
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "code", "target"}).AddRow(int64(1), "1", "target1").AddRow(int64(2), "2", "target2").AddRow(int64(3), "3", "target3"))
+		router.ServeHTTP(rec, req)
+
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&payload))
+
+		next := fake.Pager().Sign(pagination.Cursor{Order: pagination.Ascending, Direction: pagination.Forward, Key: 2})
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, pagination.Page{Next: next, Size: 2}, payload.Page)
+		assert.Equal(t, `</api/v1/url/?cursor=`+next+`&limit=2>; rel="next"`, rec.Header().Get("Link"))
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("list urls with cursor keeps its order and filters", func(t *testing.T) {
+		var payload pagination.Pagination[model.URL]
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+		cursor := fake.Pager().Sign(pagination.Cursor{Order: pagination.Ascending, Direction: pagination.Forward, Key: 2, Filter: "owner=acme"})
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/url/?limit=2&owner=acme&cursor="+cursor, nil)
+		req.Header.Set("Accept", "application/json")
+
+		fake.DBMock.ExpectQuery("SELECT id, code, target FROM urls WHERE deleted_at IS NULL AND owner = $1 AND id > $2 ORDER BY id ASC LIMIT $3").
+			WithArgs("acme", int64(2), 3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "code", "target"}).AddRow(int64(3), "3", "target3"))
+		router.ServeHTTP(rec, req)
+
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&payload))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, pagination.Page{
+			Previous: fake.Pager().Sign(pagination.Cursor{Order: pagination.Ascending, Direction: pagination.Backward, Key: 3, Filter: "owner=acme"}),
+			Size:     1,
+		}, payload.Page)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("list urls with invalid pagination", func(t *testing.T) {
+		pager := test.NewFakeDependencies(t).Pager()
+		forged := pagination.NewPager([]byte("forged"), 50, 1, 100).Sign(pagination.Cursor{Order: pagination.Descending, Direction: pagination.Forward, Key: 1})
+		filtered := pager.Sign(pagination.Cursor{Order: pagination.Descending, Direction: pagination.Forward, Key: 1, Filter: "owner=acme"})
+
+		for query, reason := range map[string]observability.ValidationReason{
+			"limit=0":                        observability.ValidationLimit,
+			"limit=101":                      observability.ValidationLimit,
+			"limit=all":                      observability.ValidationLimit,
+			"order=random":                   observability.ValidationOrder,
+			"cursor=%3E1":                    observability.ValidationCursor,
+			"cursor=" + forged:               observability.ValidationCursor,
+			"owner=other&cursor=" + filtered: observability.ValidationCursor,
+			"order=asc&cursor=" + filtered:   observability.ValidationCursor,
+		} {
+			fake := test.NewFakeDependencies(t)
+			router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/url/?"+query, nil)
+			req.Header.Set("Accept", "application/json")
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+			assert.Equal(t, reason, fake.HTTPMetric.LastValidationRejectCause, query)
+		}
 	})
 
 	t.Run("url get by id", func(t *testing.T) {
 		var payload model.URL
-		fake := test.NewFakeDependencies()
-		router := handler.NewRouter(fake.Services(), fake.Observer())
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
 
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodGet, "/api/v1/url/1", nil)
@@ -121,11 +400,64 @@ func TestUrlHandler(t *testing.T) {
 
 		at, _ := time.Parse(time.RFC3339, "2026-01-29T15:23:24Z")
 		assert.Equal(t, model.URL{ID: 1, Code: "1", Target: "target1", CreatedAt: &at, UpdatedAt: &at}, payload)
+		assert.NotEmpty(t, rec.Header().Get("ETag"))
+		assert.Equal(t, "Thu, 29 Jan 2026 15:23:24 GMT", rec.Header().Get("Last-Modified"))
+		assert.Equal(t, "private, no-cache", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("url get by id not modified", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/url/1", nil)
+		req.Header.Set("Accept", "application/json")
+
+		fake.MockUrlGetById()
+		router.ServeHTTP(rec, req)
+		etag := rec.Header().Get("ETag")
+
+		for _, header := range []map[string]string{
+			{"If-None-Match": etag},
+			{"If-None-Match": `"other", ` + etag},
+			{"If-Modified-Since": "Thu, 29 Jan 2026 15:23:24 GMT"},
+		} {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/url/1", nil)
+			req.Header.Set("Accept", "application/json")
+
+			for name, value := range header {
+				req.Header.Set(name, value)
+			}
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusNotModified, rec.Code, header)
+			assert.Equal(t, etag, rec.Header().Get("ETag"), header)
+			assert.Empty(t, rec.Body.Bytes(), header)
+		}
+	})
+
+	t.Run("url get by id modified since", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/url/1", nil)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("If-Modified-Since", "Thu, 29 Jan 2026 15:23:23 GMT")
+
+		fake.MockUrlGetById()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEmpty(t, rec.Body.Bytes())
 	})
 
 	t.Run("url get by code", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
-		router := handler.NewRouter(fake.Services(), fake.Observer())
+		fake := test.NewFakeDependencies(t)
+		services := fake.Services()
+		router := handler.NewRouter(services, fake.Pager(), fake.Observer())
 
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodGet, "/r/1", nil)
@@ -135,5 +467,111 @@ func TestUrlHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusFound, rec.Code)
 		assert.Equal(t, "target1", rec.Header().Get("Location"))
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet(), "the click is not written on the request path")
+		assert.Equal(t, "private, max-age=60", rec.Header().Get("Cache-Control"))
+		assert.Equal(t, observability.RedirectFound, fake.HTTPMetric.LastRedirectOutcome)
+		assert.NotZero(t, fake.HTTPMetric.LastRedirectLatency)
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery("UPDATE urls SET clicks = clicks + $1 WHERE id = $2 AND deleted_at IS NULL RETURNING id, code, target, owner, created_at, updated_at, disabled_at, expires_at, clicks").
+			WithArgs(int64(1), int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "code", "target", "clicks"}).AddRow(int64(1), "1", "target1", int64(1)))
+		fake.DBMock.ExpectCommit()
+
+		assert.NoError(t, services.Clicks.Flush(context.Background()))
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("url get by code expiring soon", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/r/1", nil)
+
+		fake.DBMock.ExpectQuery("SELECT id, code, target, domain, owner, created_at, updated_at, disabled_at, expires_at, deleted_at FROM urls WHERE id = $1 AND deleted_at IS NULL").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "code", "target", "expires_at"}).AddRow(int64(1), "1", "target1", time.Now().Add(10*time.Second+500*time.Millisecond)),
+		)
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "private, max-age=10", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("url get by code when not found", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/r/1", nil)
+
+		fake.DBMock.ExpectQuery("SELECT id, code, target, domain, owner, created_at, updated_at, disabled_at, expires_at, deleted_at FROM urls WHERE id = $1 AND deleted_at IS NULL").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "code", "target", "created_at", "updated_at"}),
+		)
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		assert.Equal(t, observability.RedirectNotFound, fake.HTTPMetric.LastRedirectOutcome)
+	})
+
+	t.Run("url get by code when disabled", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/r/1", nil)
+
+		fake.DBMock.ExpectQuery("SELECT id, code, target, domain, owner, created_at, updated_at, disabled_at, expires_at, deleted_at FROM urls WHERE id = $1 AND deleted_at IS NULL").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "code", "target", "disabled_at"}).AddRow(int64(1), "1", "target1", time.Now()),
+		)
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, observability.RedirectNotFound, fake.HTTPMetric.LastRedirectOutcome)
+	})
+
+	t.Run("url get by code when expired", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/r/1", nil)
+
+		fake.DBMock.ExpectQuery("SELECT id, code, target, domain, owner, created_at, updated_at, disabled_at, expires_at, deleted_at FROM urls WHERE id = $1 AND deleted_at IS NULL").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "code", "target", "expires_at"}).AddRow(int64(1), "1", "target1", time.Now().Add(-time.Second)),
+		)
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, observability.RedirectExpired, fake.HTTPMetric.LastRedirectOutcome)
+	})
+
+	t.Run("url get by code with error", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/r/1", nil)
+
+		fake.DBMock.ExpectQuery("SELECT id, code, target, domain, owner, created_at, updated_at, disabled_at, expires_at, deleted_at FROM urls WHERE id = $1 AND deleted_at IS NULL").WillReturnError(context.DeadlineExceeded)
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, observability.RedirectError, fake.HTTPMetric.LastRedirectOutcome)
+	})
+
+	t.Run("url get by invalid code", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := handler.NewRouter(fake.Services(), fake.Pager(), fake.Observer())
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/r/invalid-code", nil)
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "redirect", fake.HTTPMetric.LastValidationOperation)
+		assert.Equal(t, observability.ValidationCode, fake.HTTPMetric.LastValidationRejectCause)
 	})
 }
@@ -0,0 +1,312 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	json "github.com/json-iterator/go"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/service"
+	"github.com/zeon-code/tiny-url/internal/webhook"
+)
+
+type WebhookHandler struct {
+	WebhookSvc service.WebhookService
+	metric     observability.MetricClient
+	logger     observability.Logger
+}
+
+func NewWebhookHandler(services service.Services, observer observability.Observer) WebhookHandler {
+	logger := observer.Logger().With("handler", "webhook")
+	metric, err := observer.Metric()
+
+	if err != nil {
+		logger.Error(context.Background(), "error building metric client", slog.Any("error", err))
+		metric = observability.NewNoopMetricClient()
+	}
+
+	return WebhookHandler{
+		WebhookSvc: services.Webhook,
+		metric:     metric,
+		logger:     logger,
+	}
+}
+
+type WebhookSubscribeRequest struct {
+	Owner  string            `json:"owner"`
+	URL    string            `json:"url"`
+	Events []model.EventType `json:"events"`
+}
+
+type WebhookListResponse struct {
+	Subscriptions []model.WebhookSubscription `json:"items"`
+}
+
+type WebhookDeadLetterResponse struct {
+	Deliveries []model.WebhookDelivery `json:"items"`
+}
+
+type WebhookReplayResponse struct {
+	Replayed int `json:"replayed"`
+}
+
+func (h WebhookHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	defer r.Body.Close()
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		h.metric.ValidationRejected(ctx, "subscribe", observability.ValidationContentType)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	request := WebhookSubscribeRequest{}
+	body, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		observability.TraceError(ctx, http.StatusText(http.StatusInternalServerError), err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if err = json.Unmarshal(body, &request); err != nil || !validSubscription(request) {
+		h.metric.ValidationRejected(ctx, "subscribe", observability.ValidationBody)
+		observability.TraceError(ctx, http.StatusText(http.StatusBadRequest), err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	subscription, err := h.WebhookSvc.Subscribe(ctx, request.Owner, request.URL, request.Events)
+
+	if err != nil {
+		h.logger.Error(ctx, "error creating webhook subscription", slog.Any("error", err))
+		observability.TraceError(ctx, http.StatusText(http.StatusInternalServerError), err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	h.write(w, r, http.StatusCreated, subscription)
+}
+
+// List returns the subscriptions of the owner given by the owner query
+// parameter, without their secrets.
+func (h WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Header.Get("Accept") != "application/json" {
+		h.metric.ValidationRejected(ctx, "list_webhooks", observability.ValidationAccept)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	owner, ok := h.owner(w, r, "list_webhooks")
+
+	if !ok {
+		return
+	}
+
+	subscriptions, err := h.WebhookSvc.Subscriptions(ctx, owner)
+
+	if err != nil {
+		observability.TraceError(ctx, http.StatusText(http.StatusInternalServerError), err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	h.write(w, r, http.StatusOK, WebhookListResponse{Subscriptions: subscriptions})
+}
+
+// Unsubscribe deletes a subscription of the owner given by the owner query
+// parameter.
+func (h WebhookHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	owner, ok := h.owner(w, r, "unsubscribe")
+
+	if !ok {
+		return
+	}
+
+	id, ok := h.pathID(w, r, "unsubscribe", "id")
+
+	if !ok {
+		return
+	}
+
+	if err := h.WebhookSvc.Unsubscribe(ctx, owner, id); !h.handleError(w, r, err) {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeadLetters lists the deliveries of a subscription that exhausted their
+// attempts. Subscriptions of other owners answer 404.
+func (h WebhookHandler) DeadLetters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Header.Get("Accept") != "application/json" {
+		h.metric.ValidationRejected(ctx, "dead_letters", observability.ValidationAccept)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	owner, ok := h.owner(w, r, "dead_letters")
+
+	if !ok {
+		return
+	}
+
+	id, ok := h.pathID(w, r, "dead_letters", "id")
+
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.WebhookSvc.DeadLetters(ctx, owner, id)
+
+	if !h.handleError(w, r, err) {
+		return
+	}
+
+	h.write(w, r, http.StatusOK, WebhookDeadLetterResponse{Deliveries: deliveries})
+}
+
+// Replay queues a single dead delivery again.
+func (h WebhookHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	owner, ok := h.owner(w, r, "replay")
+
+	if !ok {
+		return
+	}
+
+	id, ok := h.pathID(w, r, "replay", "id")
+
+	if !ok {
+		return
+	}
+
+	delivery, ok := h.pathID(w, r, "replay", "delivery")
+
+	if !ok {
+		return
+	}
+
+	if err := h.WebhookSvc.Replay(ctx, owner, id, delivery); !h.handleError(w, r, err) {
+		return
+	}
+
+	h.write(w, r, http.StatusAccepted, WebhookReplayResponse{Replayed: 1})
+}
+
+// ReplayAll queues every dead delivery of a subscription again.
+func (h WebhookHandler) ReplayAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	owner, ok := h.owner(w, r, "replay")
+
+	if !ok {
+		return
+	}
+
+	id, ok := h.pathID(w, r, "replay", "id")
+
+	if !ok {
+		return
+	}
+
+	replayed, err := h.WebhookSvc.ReplayAll(ctx, owner, id)
+
+	if !h.handleError(w, r, err) {
+		return
+	}
+
+	h.write(w, r, http.StatusAccepted, WebhookReplayResponse{Replayed: replayed})
+}
+
+// owner reads the owner query parameter that scopes subscription requests,
+// answering 400 when it is missing or too long.
+func (h WebhookHandler) owner(w http.ResponseWriter, r *http.Request, operation string) (string, bool) {
+	owner := r.URL.Query().Get("owner")
+
+	if owner == "" || len(owner) > maxOwnerLength {
+		h.metric.ValidationRejected(r.Context(), operation, observability.ValidationOwner)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return "", false
+	}
+
+	return owner, true
+}
+
+// pathID parses the named path value, answering 400 when it is not an ID.
+func (h WebhookHandler) pathID(w http.ResponseWriter, r *http.Request, operation string, name string) (int64, bool) {
+	ctx := r.Context()
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+
+	if err != nil {
+		h.metric.ValidationRejected(ctx, operation, observability.ValidationID)
+		observability.TraceError(ctx, http.StatusText(http.StatusBadRequest), err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return 0, false
+	}
+
+	return id, true
+}
+
+// handleError answers 404 or 500 for a failed call and reports whether the
+// call succeeded.
+func (h WebhookHandler) handleError(w http.ResponseWriter, r *http.Request, err error) bool {
+	ctx := r.Context()
+
+	if errors.Is(err, db.ErrDBResourceNotFound) {
+		observability.TraceError(ctx, http.StatusText(http.StatusNotFound), err)
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return false
+	} else if err != nil {
+		observability.TraceError(ctx, http.StatusText(http.StatusInternalServerError), err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return false
+	}
+
+	return true
+}
+
+func (h WebhookHandler) write(w http.ResponseWriter, r *http.Request, status int, value any) {
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		observability.TraceError(r.Context(), http.StatusText(http.StatusInternalServerError), err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// validSubscription requires an owner, an absolute http(s) endpoint that
+// is not on an internal address, and known event types.
+func validSubscription(request WebhookSubscribeRequest) bool {
+	if request.Owner == "" || len(request.Owner) > maxOwnerLength {
+		return false
+	}
+
+	if webhook.CheckEndpoint(request.URL) != nil {
+		return false
+	}
+
+	for _, eventType := range request.Events {
+		if !slices.Contains(model.LinkEvents, eventType) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,204 @@
+package handler_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	json "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeon-code/tiny-url/internal/http/handler"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	subscribeQuery := "INSERT INTO webhook_subscriptions (owner, url, secret, events) VALUES ($1, $2, $3, $4) RETURNING id, owner, url, secret, events, created_at"
+	replayQuery := "UPDATE webhook_deliveries SET status = 'pending', attempts = 0, last_error = NULL, next_attempt_at = $1 WHERE id = $2 AND subscription_id = $3 AND status = 'dead' AND subscription_id IN (SELECT id FROM webhook_subscriptions WHERE owner = $4) RETURNING id"
+
+	t.Run("webhooks are not served on the public listener", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := fake.Router()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/?owner=acme", nil)
+		req.Header.Set("Accept", "application/json")
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("subscribe discloses the signing secret", func(t *testing.T) {
+		var payload model.WebhookSubscription
+		fake := test.NewFakeDependencies(t)
+		router := fake.AdminRouter()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/", bytes.NewBufferString(`{"owner":"acme","url":"https://hooks.example.com","events":["link.created"]}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		rows := sqlmock.NewRows([]string{"id", "owner", "url", "secret", "events", "created_at"}).
+			AddRow(int64(1), "acme", "https://hooks.example.com", "s3cr3t", "link.created", time.Now())
+
+		fake.DBMock.ExpectQuery(subscribeQuery).
+			WithArgs("acme", "https://hooks.example.com", sqlmock.AnyArg(), "link.created").
+			WillReturnRows(rows)
+		router.ServeHTTP(rec, req)
+
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&payload))
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "s3cr3t", payload.Secret)
+		assert.Equal(t, model.EventTypes{model.EventLinkCreated}, payload.Events)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("subscribe rejects invalid subscriptions", func(t *testing.T) {
+		bodies := []string{
+			`{"url":"https://hooks.example.com"}`,
+			`{"owner":"acme","url":"hooks.example.com"}`,
+			`{"owner":"acme","url":"ftp://hooks.example.com"}`,
+			`{"owner":"acme","url":"http://localhost:8081/debug/pprof/"}`,
+			`{"owner":"acme","url":"http://169.254.169.254/latest/meta-data/"}`,
+			`{"owner":"acme","url":"http://10.0.0.5/hook"}`,
+			`{"owner":"acme","url":"https://hooks.example.com","events":["link.clicked"]}`,
+			`{"owner":`,
+		}
+
+		for _, body := range bodies {
+			fake := test.NewFakeDependencies(t)
+			router := fake.AdminRouter()
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+			assert.Equal(t, observability.ValidationBody, fake.HTTPMetric.LastValidationRejectCause, body)
+		}
+	})
+
+	t.Run("list requires an owner", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := fake.AdminRouter()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/", nil)
+		req.Header.Set("Accept", "application/json")
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "list_webhooks", fake.HTTPMetric.LastValidationOperation)
+		assert.Equal(t, observability.ValidationOwner, fake.HTTPMetric.LastValidationRejectCause)
+	})
+
+	t.Run("list subscriptions of an owner", func(t *testing.T) {
+		var payload handler.WebhookListResponse
+		fake := test.NewFakeDependencies(t)
+		router := fake.AdminRouter()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/?owner=acme", nil)
+		req.Header.Set("Accept", "application/json")
+
+		rows := sqlmock.NewRows([]string{"id", "owner", "url", "events", "created_at"}).
+			AddRow(int64(1), "acme", "https://hooks.example.com", "", time.Now())
+
+		fake.DBMock.ExpectQuery("SELECT id, owner, url, events, created_at FROM webhook_subscriptions WHERE owner = $1 ORDER BY id").
+			WithArgs("acme").
+			WillReturnRows(rows)
+		router.ServeHTTP(rec, req)
+
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&payload))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.Len(t, payload.Subscriptions, 1)
+		assert.Empty(t, payload.Subscriptions[0].Secret)
+		assert.Equal(t, model.EventTypes{}, payload.Subscriptions[0].Events)
+	})
+
+	t.Run("replay a dead letter", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := fake.AdminRouter()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/1/dead-letters/7/replay?owner=acme", nil)
+
+		fake.DBMock.ExpectQuery(replayQuery).
+			WithArgs(sqlmock.AnyArg(), int64(7), int64(1), "acme").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(7)))
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.JSONEq(t, `{"replayed":1}`, rec.Body.String())
+	})
+
+	t.Run("replay an unknown dead letter", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := fake.AdminRouter()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/1/dead-letters/7/replay?owner=acme", nil)
+
+		fake.DBMock.ExpectQuery(replayQuery).
+			WithArgs(sqlmock.AnyArg(), int64(7), int64(1), "acme").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("replay requires an owner", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := fake.AdminRouter()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/1/dead-letters/replay", nil)
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, observability.ValidationOwner, fake.HTTPMetric.LastValidationRejectCause)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("unsubscribe another owner's subscription", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := fake.AdminRouter()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/webhooks/1?owner=other", nil)
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery("DELETE FROM webhook_subscriptions WHERE id = $1 AND owner = $2 RETURNING id").
+			WithArgs(int64(1), "other").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		fake.DBMock.ExpectRollback()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("replay with an invalid delivery id", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		router := fake.AdminRouter()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/1/dead-letters/abc/replay?owner=acme", nil)
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, observability.ValidationID, fake.HTTPMetric.LastValidationRejectCause)
+	})
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"crypto/tls"
+	"sync/atomic"
+)
+
+// Certificate holds a TLS key pair loaded from disk that can be swapped
+// at runtime without restarting the listener.
+//
+// Handshakes always use the most recently loaded pair; a failed reload
+// keeps serving the previous certificate.
+type Certificate struct {
+	certFile string
+	keyFile  string
+	current  atomic.Pointer[tls.Certificate]
+}
+
+func NewCertificate(certFile string, keyFile string) (*Certificate, error) {
+	cert := &Certificate{certFile: certFile, keyFile: keyFile}
+
+	if err := cert.Reload(); err != nil {
+		return nil, err
+	}
+
+	return cert, nil
+}
+
+// Reload reads the certificate and key files again and, when both parse
+// successfully, replaces the pair used for new handshakes.
+func (c *Certificate) Reload() error {
+	pair, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+
+	if err != nil {
+		return err
+	}
+
+	c.current.Store(&pair)
+	return nil
+}
+
+// GetCertificate returns the active key pair. It matches the signature of
+// tls.Config.GetCertificate.
+func (c *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.current.Load(), nil
+}
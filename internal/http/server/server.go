@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+// Server wraps http.Server with the timeouts, header limits and optional
+// TLS termination described by a config.ServerConfiguration.
+type Server struct {
+	name            string
+	server          *http.Server
+	certificate     *Certificate
+	shutdownTimeout time.Duration
+	logger          observability.Logger
+}
+
+// NewServer builds a Server for the given handler. The provided context is
+// used as the base context of every incoming request.
+//
+// When TLS mode is enabled the certificate and key files are loaded
+// eagerly, so misconfiguration is reported before the listener starts.
+func NewServer(ctx context.Context, name string, conf config.ServerConfiguration, handler http.Handler, observer observability.Observer) (*Server, error) {
+	readTimeout, err := conf.ReadTimeout()
+
+	if err != nil {
+		return nil, err
+	}
+
+	readHeaderTimeout, err := conf.ReadHeaderTimeout()
+
+	if err != nil {
+		return nil, err
+	}
+
+	writeTimeout, err := conf.WriteTimeout()
+
+	if err != nil {
+		return nil, err
+	}
+
+	idleTimeout, err := conf.IdleTimeout()
+
+	if err != nil {
+		return nil, err
+	}
+
+	maxHeaderBytes, err := conf.MaxHeaderBytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownTimeout, err := conf.ShutdownTimeout()
+
+	if err != nil {
+		return nil, err
+	}
+
+	isTLSMode, err := conf.TLSMode()
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		name:            name,
+		shutdownTimeout: shutdownTimeout,
+		logger:          observer.Logger().With("server", name),
+		server: &http.Server{
+			Addr:              conf.Addr(),
+			Handler:           handler,
+			ReadTimeout:       readTimeout,
+			ReadHeaderTimeout: readHeaderTimeout,
+			WriteTimeout:      writeTimeout,
+			IdleTimeout:       idleTimeout,
+			MaxHeaderBytes:    maxHeaderBytes,
+			BaseContext:       func(net.Listener) context.Context { return ctx },
+		},
+	}
+
+	if !isTLSMode {
+		return s, nil
+	}
+
+	certFile, err := conf.TLSCertFile()
+
+	if err != nil {
+		return nil, err
+	}
+
+	keyFile, err := conf.TLSKeyFile()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if s.certificate, err = NewCertificate(certFile, keyFile); err != nil {
+		return nil, err
+	}
+
+	s.server.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: s.certificate.GetCertificate,
+	}
+
+	return s, nil
+}
+
+// Name returns the name the server was registered with.
+func (s *Server) Name() string {
+	return s.name
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() string {
+	return s.server.Addr
+}
+
+// ShutdownTimeout returns the configured grace period for in-flight
+// requests once shutdown starts.
+func (s *Server) ShutdownTimeout() time.Duration {
+	return s.shutdownTimeout
+}
+
+// ListenAndServe starts accepting connections, terminating TLS when it
+// is enabled. It blocks until the server stops and returns nil when the
+// stop was caused by Shutdown.
+func (s *Server) ListenAndServe() error {
+	var err error
+
+	if s.certificate != nil {
+		err = s.server.ListenAndServeTLS("", "")
+	} else {
+		err = s.server.ListenAndServe()
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// Reload reloads the TLS certificate from disk. It is a no-op when TLS
+// is disabled.
+func (s *Server) Reload() error {
+	if s.certificate == nil {
+		return nil
+	}
+
+	return s.certificate.Reload()
+}
+
+// ReloadOn reloads the TLS certificate every time one of the given
+// signals is received, until ctx is cancelled.
+func (s *Server) ReloadOn(ctx context.Context, signals ...os.Signal) {
+	if s.certificate == nil {
+		return
+	}
+
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+
+	go func() {
+		defer signal.Stop(received)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-received:
+				if err := s.Reload(); err != nil {
+					s.logger.Error(ctx, "error reloading tls certificate", slog.Any("error", err))
+					continue
+				}
+
+				s.logger.Info(ctx, "TLS certificate reloaded")
+			}
+		}
+	}()
+}
+
+// Shutdown gracefully stops the server, waiting for in-flight requests
+// until ctx is done. Callers bound the wait, typically with
+// ShutdownTimeout.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,131 @@
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeon-code/tiny-url/internal/http/server"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+)
+
+func writeCertificate(t *testing.T, dir string, name string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cert.pem"), certPEM, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key.pem"), keyPEM, 0600))
+}
+
+func TestCertificate(t *testing.T) {
+	t.Run("should reload certificate from disk", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCertificate(t, dir, "first")
+
+		cert, err := server.NewCertificate(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+		require.NoError(t, err)
+
+		first, _ := cert.GetCertificate(nil)
+		writeCertificate(t, dir, "second")
+
+		assert.NoError(t, cert.Reload())
+
+		second, _ := cert.GetCertificate(nil)
+		assert.NotEqual(t, first.Certificate[0], second.Certificate[0])
+	})
+
+	t.Run("should keep previous certificate when reload fails", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCertificate(t, dir, "first")
+
+		cert, err := server.NewCertificate(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+		require.NoError(t, err)
+
+		first, _ := cert.GetCertificate(nil)
+		os.WriteFile(filepath.Join(dir, "cert.pem"), []byte("invalid"), 0600)
+
+		assert.Error(t, cert.Reload())
+
+		current, _ := cert.GetCertificate(nil)
+		assert.Equal(t, first, current)
+	})
+
+	t.Run("should return error when files do not exist", func(t *testing.T) {
+		_, err := server.NewCertificate("missing.pem", "missing.pem")
+		assert.Error(t, err)
+	})
+}
+
+func TestServer(t *testing.T) {
+	observer := test.NewFakeObserver(test.NewFakeMetric())
+	handler := http.NotFoundHandler()
+
+	t.Run("should build server from configuration", func(t *testing.T) {
+		os.Setenv("SERVER_TEST_ADDR", ":9999")
+		os.Setenv("SERVER_TEST_SHUTDOWN_TIMEOUT", "3s")
+		defer os.Unsetenv("SERVER_TEST_ADDR")
+		defer os.Unsetenv("SERVER_TEST_SHUTDOWN_TIMEOUT")
+
+		srv, err := server.NewServer(context.Background(), "test", config.NewServerConfig("SERVER_TEST", ":8080"), handler, observer)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ":9999", srv.Addr())
+		assert.Equal(t, 3*time.Second, srv.ShutdownTimeout())
+		assert.NoError(t, srv.Reload())
+	})
+
+	t.Run("should return error when tls files are missing", func(t *testing.T) {
+		os.Setenv("SERVER_TEST_TLS_MODE", "true")
+		defer os.Unsetenv("SERVER_TEST_TLS_MODE")
+
+		_, err := server.NewServer(context.Background(), "test", config.NewServerConfig("SERVER_TEST", ":8080"), handler, observer)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should load tls certificate", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCertificate(t, dir, "server")
+
+		os.Setenv("SERVER_TEST_TLS_MODE", "true")
+		os.Setenv("SERVER_TEST_TLS_CERT_FILE", filepath.Join(dir, "cert.pem"))
+		os.Setenv("SERVER_TEST_TLS_KEY_FILE", filepath.Join(dir, "key.pem"))
+		defer os.Unsetenv("SERVER_TEST_TLS_MODE")
+		defer os.Unsetenv("SERVER_TEST_TLS_CERT_FILE")
+		defer os.Unsetenv("SERVER_TEST_TLS_KEY_FILE")
+
+		srv, err := server.NewServer(context.Background(), "test", config.NewServerConfig("SERVER_TEST", ":8080"), handler, observer)
+
+		assert.NoError(t, err)
+		assert.NoError(t, srv.Reload())
+	})
+}
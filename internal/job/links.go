@@ -0,0 +1,92 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/repository"
+	"github.com/zeon-code/tiny-url/internal/service"
+)
+
+// Names of the link jobs, used for their leases and metrics.
+const (
+	ExpireLinks = "expire-links"
+	PurgeLinks  = "purge-links"
+)
+
+// NewSchedulerFromConfig builds the scheduler of the link jobs: disabling
+// expired links and purging links deleted longer than the retention ago.
+func NewSchedulerFromConfig(conf config.JobConfiguration, repo repository.Repositories, svc service.Services, observer observability.Observer) (*Scheduler, error) {
+	expireInterval, err := conf.ExpireInterval()
+
+	if err != nil {
+		return nil, err
+	}
+
+	purgeInterval, err := conf.PurgeInterval()
+
+	if err != nil {
+		return nil, err
+	}
+
+	retention, err := conf.Retention()
+
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := conf.BatchSize()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewScheduler(
+		repo.Lease,
+		observer,
+		NewExpireLinksJob(svc.Url, expireInterval, batch),
+		NewPurgeLinksJob(svc.Url, purgeInterval, retention, batch),
+	), nil
+}
+
+// NewExpireLinksJob disables every link whose expiry passed, batch links
+// at a time.
+func NewExpireLinksJob(svc service.URLService, interval time.Duration, batch int) Job {
+	return Job{
+		Name:     ExpireLinks,
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			now := time.Now()
+
+			return drain(batch, func() (int, error) { return svc.Expire(ctx, now, batch) })
+		},
+	}
+}
+
+// NewPurgeLinksJob permanently removes every link deleted longer than
+// retention ago, batch links at a time.
+func NewPurgeLinksJob(svc service.URLService, interval time.Duration, retention time.Duration, batch int) Job {
+	return Job{
+		Name:     PurgeLinks,
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			before := time.Now().Add(-retention)
+
+			return drain(batch, func() (int, error) { return svc.Purge(ctx, before, batch) })
+		},
+	}
+}
+
+// drain repeats step until it handles less than a full batch, keeping each
+// transaction short however much work is due.
+func drain(batch int, step func() (int, error)) error {
+	for {
+		handled, err := step()
+
+		if err != nil || handled < batch {
+			return err
+		}
+	}
+}
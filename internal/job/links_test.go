@@ -0,0 +1,91 @@
+package job_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/job"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/cache"
+	"github.com/zeon-code/tiny-url/internal/pkg/pagination"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+	"github.com/zeon-code/tiny-url/internal/repository"
+	"github.com/zeon-code/tiny-url/internal/service"
+)
+
+func TestLinkJobs(t *testing.T) {
+	ctx := context.Background()
+
+	newStores := func(t *testing.T) (test.FakeDependencies, repository.Repositories, service.Services) {
+		fake := test.NewFakeDependencies(t)
+		client := test.NewSQLiteClient(t)
+
+		memory, err := db.NewMemoryDatabase(client, fake.Cache(), fake.Observer())
+		require.NoError(t, err)
+
+		repo := repository.NewRepositories(client, memory, fake.Cache(), fake.Observer())
+		return fake, repo, service.NewServices(repo, fake.Observer())
+	}
+
+	// cached reports whether the link is served from the cache.
+	cached := func(fake test.FakeDependencies, id int64) bool {
+		_, err := fake.Cache().Get(ctx, service.URLCacheKey.With("id", id).String())
+		return err == nil
+	}
+
+	t.Run("expire job disables expired links and evicts them", func(t *testing.T) {
+		fake, repo, svc := newStores(t)
+		past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+
+		expired, err := repo.Url.Create(ctx, "https://example.com/expired", "", &past)
+		require.NoError(t, err)
+		active, err := repo.Url.Create(ctx, "https://example.com/active", "", &future)
+		require.NoError(t, err)
+
+		for _, id := range []int64{expired.ID, active.ID} {
+			_, err := svc.Url.GetByID(cache.WithCache(ctx), id)
+			require.NoError(t, err)
+		}
+
+		_, err = svc.Url.List(cache.WithCache(ctx), pagination.Query{Limit: 10, Order: pagination.Descending, Direction: pagination.Forward}, model.URLFilter{})
+		require.NoError(t, err)
+		_, err = fake.Cache().Get(ctx, service.URLCacheKey.With("list", "desc", "next", 10).String())
+		require.NoError(t, err)
+
+		require.NoError(t, job.NewExpireLinksJob(svc.Url, time.Minute, 1).Run(ctx))
+
+		assert.False(t, cached(fake, expired.ID))
+		assert.True(t, cached(fake, active.ID))
+
+		_, err = fake.Cache().Get(ctx, service.URLCacheKey.With("list", "desc", "next", 10).String())
+		assert.ErrorIs(t, err, db.ErrCacheNotFound, "listings are evicted")
+
+		url, err := svc.Url.GetByID(cache.WithCache(ctx), expired.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, url.DisabledAt)
+	})
+
+	t.Run("purge job removes links deleted past the retention and evicts them", func(t *testing.T) {
+		fake, repo, svc := newStores(t)
+
+		deleted, err := repo.Url.Create(ctx, "https://example.com", "", nil)
+		require.NoError(t, err)
+
+		_, err = svc.Url.GetByID(cache.WithCache(ctx), deleted.ID)
+		require.NoError(t, err)
+		require.NoError(t, repo.Url.Delete(ctx, deleted.ID))
+
+		require.NoError(t, job.NewPurgeLinksJob(svc.Url, time.Hour, time.Hour, 10).Run(ctx))
+		assert.True(t, cached(fake, deleted.ID), "links within the retention are kept")
+
+		require.NoError(t, job.NewPurgeLinksJob(svc.Url, time.Hour, -time.Second, 10).Run(ctx))
+		assert.False(t, cached(fake, deleted.ID))
+
+		_, err = svc.Url.GetByID(cache.WithCache(ctx), deleted.ID)
+		assert.ErrorIs(t, err, db.ErrDBResourceNotFound)
+	})
+}
@@ -0,0 +1,149 @@
+// Package job runs the periodic background work of the service, such as
+// disabling expired links and purging deleted ones. Every run takes a lease
+// shared by all instances, so each job runs on a single instance at a time.
+package job
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/repository"
+)
+
+// Job is a unit of periodic work. Run must be safe to repeat: a run
+// interrupted by a shutdown or a failure is only retried at the next
+// interval.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(context.Context) error
+}
+
+// Scheduler runs each of its jobs on its own interval, starting when the
+// scheduler starts.
+//
+// Before every run, the scheduler acquires the lease of the job for its
+// interval and leaves it to expire, so a job runs at most once per interval
+// across every instance. Runs are skipped while the lease cannot be
+// checked, as running without it could duplicate work across instances.
+type Scheduler struct {
+	jobs   []Job
+	leases repository.LeaseRepository
+
+	started atomic.Bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+	metric  observability.MetricClient
+	logger  observability.Logger
+}
+
+func NewScheduler(leases repository.LeaseRepository, observer observability.Observer, jobs ...Job) *Scheduler {
+	logger := observer.Logger().With("worker", "job-scheduler")
+	metric, err := observer.Metric()
+
+	if err != nil {
+		logger.Error(context.Background(), "error building metric client", slog.Any("error", err))
+		metric = observability.NewNoopMetricClient()
+	}
+
+	return &Scheduler{
+		jobs:   jobs,
+		leases: leases,
+		stop:   make(chan struct{}),
+		metric: metric,
+		logger: logger,
+	}
+}
+
+// Start runs every job in the background, once immediately and then on its
+// interval, until Close is called.
+func (s *Scheduler) Start() {
+	if !s.started.CompareAndSwap(false, true) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		<-s.stop
+		cancel()
+	}()
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Close stops scheduling runs and waits for those in progress, which are
+// canceled.
+func (s *Scheduler) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	s.wg.Wait()
+	return nil
+}
+
+// RunNow runs the named job once, as if its interval had elapsed, and
+// reports whether it ran on this instance.
+func (s *Scheduler) RunNow(ctx context.Context, name string) (bool, error) {
+	for _, job := range s.jobs {
+		if job.Name == name {
+			return s.run(ctx, job)
+		}
+	}
+
+	return false, fmt.Errorf("unknown job %q", name)
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.run(ctx, job); err != nil && ctx.Err() == nil {
+			s.logger.Error(ctx, "error running job", slog.String("job", job.Name), slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run takes the job lease and runs the job within its interval, recording
+// the outcome.
+func (s *Scheduler) run(ctx context.Context, job Job) (bool, error) {
+	startAt := time.Now()
+	acquired, err := s.leases.Acquire(ctx, job.Name, job.Interval)
+
+	if err != nil || !acquired {
+		if err != nil && ctx.Err() == nil {
+			s.logger.Warn(ctx, "error acquiring job lease, skipping run", slog.String("job", job.Name), slog.Any("error", err))
+		}
+
+		s.metric.JobRun(ctx, job.Name, observability.JobSkipped, time.Since(startAt))
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, job.Interval)
+	defer cancel()
+
+	if err := job.Run(ctx); err != nil {
+		s.metric.JobRun(ctx, job.Name, observability.JobFailed, time.Since(startAt))
+		return true, err
+	}
+
+	s.metric.JobRun(ctx, job.Name, observability.JobSucceeded, time.Since(startAt))
+	s.logger.Debug(ctx, "job ran", slog.String("job", job.Name), slog.Duration("duration", time.Since(startAt)))
+	return true, nil
+}
@@ -0,0 +1,126 @@
+package job_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeon-code/tiny-url/internal/job"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+	"github.com/zeon-code/tiny-url/internal/repository"
+)
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	counting := func(name string, runs *atomic.Int32, err error) job.Job {
+		return job.Job{
+			Name:     name,
+			Interval: time.Hour,
+			Run: func(context.Context) error {
+				runs.Add(1)
+				return err
+			},
+		}
+	}
+
+	t.Run("runs a job on a single instance per interval", func(t *testing.T) {
+		var runs atomic.Int32
+		fake := test.NewFakeDependencies(t)
+		leases := repository.NewLeaseRepository(fake.Cache(), fake.Observer())
+
+		first := job.NewScheduler(leases, fake.Observer(), counting("sweep", &runs, nil))
+		second := job.NewScheduler(leases, fake.Observer(), counting("sweep", &runs, nil))
+
+		ran, err := first.RunNow(ctx, "sweep")
+		assert.NoError(t, err)
+		assert.True(t, ran)
+
+		ran, err = second.RunNow(ctx, "sweep")
+		assert.NoError(t, err)
+		assert.False(t, ran)
+
+		assert.Equal(t, int32(1), runs.Load())
+		assert.Equal(t, []test.JobRun{
+			{Job: "sweep", Outcome: observability.JobSucceeded},
+			{Job: "sweep", Outcome: observability.JobSkipped},
+		}, fake.HTTPMetric.Jobs())
+	})
+
+	t.Run("leases are held per job", func(t *testing.T) {
+		var runs atomic.Int32
+		fake := test.NewFakeDependencies(t)
+		leases := repository.NewLeaseRepository(fake.Cache(), fake.Observer())
+		scheduler := job.NewScheduler(leases, fake.Observer(), counting("expire", &runs, nil), counting("purge", &runs, nil))
+
+		for _, name := range []string{"expire", "purge"} {
+			ran, err := scheduler.RunNow(ctx, name)
+			assert.NoError(t, err)
+			assert.True(t, ran)
+		}
+
+		assert.Equal(t, int32(2), runs.Load())
+	})
+
+	t.Run("skips runs while the lease cannot be checked", func(t *testing.T) {
+		var runs atomic.Int32
+		fake := test.NewFakeDependencies(t)
+		leases := repository.NewLeaseRepository(fake.Cache(), fake.Observer())
+		scheduler := job.NewScheduler(leases, fake.Observer(), counting("sweep", &runs, nil))
+
+		fake.Cache().Close()
+		ran, err := scheduler.RunNow(ctx, "sweep")
+
+		assert.NoError(t, err)
+		assert.False(t, ran)
+		assert.Zero(t, runs.Load())
+		assert.Equal(t, []test.JobRun{{Job: "sweep", Outcome: observability.JobSkipped}}, fake.HTTPMetric.Jobs())
+	})
+
+	t.Run("records failed runs", func(t *testing.T) {
+		var runs atomic.Int32
+		fake := test.NewFakeDependencies(t)
+		leases := repository.NewLeaseRepository(fake.Cache(), fake.Observer())
+		scheduler := job.NewScheduler(leases, fake.Observer(), counting("sweep", &runs, errors.New("boom")))
+
+		ran, err := scheduler.RunNow(ctx, "sweep")
+
+		assert.EqualError(t, err, "boom")
+		assert.True(t, ran)
+		assert.Equal(t, []test.JobRun{{Job: "sweep", Outcome: observability.JobFailed}}, fake.HTTPMetric.Jobs())
+	})
+
+	t.Run("rejects unknown jobs", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		scheduler := job.NewScheduler(repository.NewLeaseRepository(fake.Cache(), fake.Observer()), fake.Observer())
+
+		_, err := scheduler.RunNow(ctx, "sweep")
+
+		assert.EqualError(t, err, `unknown job "sweep"`)
+	})
+
+	t.Run("runs jobs in the background until closed", func(t *testing.T) {
+		var runs atomic.Int32
+		fake := test.NewFakeDependencies(t)
+		leases := repository.NewLeaseRepository(fake.Cache(), fake.Observer())
+
+		sweep := counting("sweep", &runs, nil)
+		sweep.Interval = 5 * time.Millisecond
+		scheduler := job.NewScheduler(leases, fake.Observer(), sweep)
+		scheduler.Start()
+
+		require.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, time.Millisecond)
+
+		assert.NoError(t, scheduler.Close())
+		assert.NoError(t, scheduler.Close())
+
+		stopped := runs.Load()
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, stopped, runs.Load())
+	})
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// EventType names a domain event. Consumers rely on these names, so they
+// must never change.
+type EventType string
+
+const (
+	EventLinkCreated EventType = "link.created"
+	EventLinkUpdated EventType = "link.updated"
+	EventLinkDeleted EventType = "link.deleted"
+	EventLinkExpired EventType = "link.expired"
+
+	// EventLinkClickThreshold is recorded when the click count of a link
+	// reaches one of ClickThresholds. Its payload carries the count. Clicks
+	// are the redirects the service answers: clients may reuse a redirect
+	// for a minute without asking again, so repeat visits go uncounted and
+	// thresholds are reached later than the visits alone would.
+	EventLinkClickThreshold EventType = "link.click_threshold"
+)
+
+// LinkEvents lists the event types webhook subscriptions can ask for.
+var LinkEvents = []EventType{EventLinkCreated, EventLinkUpdated, EventLinkDeleted, EventLinkExpired, EventLinkClickThreshold}
+
+// ClickThresholds lists the click counts at which a link records a
+// link.click_threshold event.
+var ClickThresholds = []int64{10, 100, 1_000, 10_000, 100_000, 1_000_000}
+
+// Event is a domain event recorded in the outbox in the same transaction as
+// the change it describes, then published by the outbox relay.
+type Event struct {
+	ID        int64        `db:"id" json:"id"`
+	LinkID    int64        `db:"link_id" json:"link_id"`
+	Type      EventType    `db:"event_type" json:"type"`
+	Payload   EventPayload `db:"payload" json:"payload"`
+	CreatedAt time.Time    `db:"created_at" json:"created_at"`
+
+	// Attempts counts the failed attempts at publishing the event. It is
+	// only known to the relay, so it is left out of the published event.
+	Attempts int `db:"attempts" json:"-"`
+}
+
+// EventPayload is the JSON document carried by an event. It is stored as
+// text, so it round-trips through both JSONB and SQLite TEXT columns, and
+// is embedded as is when the event is encoded.
+type EventPayload []byte
+
+func (p EventPayload) Value() (driver.Value, error) {
+	return string(p), nil
+}
+
+func (p *EventPayload) Scan(src any) error {
+	switch value := src.(type) {
+	case []byte:
+		*p = append(EventPayload(nil), value...)
+	case string:
+		*p = EventPayload(value)
+	default:
+		return fmt.Errorf("unsupported event payload type %T", src)
+	}
+
+	return nil
+}
+
+func (p EventPayload) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+
+	return p, nil
+}
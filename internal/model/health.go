@@ -1,6 +1,16 @@
 package model
 
 type Health struct {
-	Status string `json:"status"`
-	Reason string `json:"reason,omitempty"`
+	Status    string          `json:"status"`
+	Reason    string          `json:"reason,omitempty"`
+	Degraded  []string        `json:"degraded,omitempty"`
+	Replicas  []ReplicaHealth `json:"replicas,omitempty"`
+	Telemetry string          `json:"telemetry,omitempty"`
+}
+
+// ReplicaHealth reports a read replica as seen by its last background check.
+type ReplicaHealth struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	LagSeconds float64 `json:"lag_seconds"`
 }
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type Build struct {
+	Version   string
+	Commit    string
+	StartedAt time.Time
+}
+
+type Runtime struct {
+	Version    string    `json:"version"`
+	Commit     string    `json:"commit"`
+	GoVersion  string    `json:"go_version"`
+	StartedAt  time.Time `json:"started_at"`
+	Uptime     string    `json:"uptime"`
+	Goroutines int       `json:"goroutines"`
+}
@@ -1,11 +1,87 @@
 package model
 
-import "time"
+import (
+	"net/url"
+	"strings"
+	"time"
+)
 
 type URL struct {
-	ID        int64      `db:"id" json:"id"`
-	Code      string     `db:"code" json:"code"`
-	Target    string     `db:"target" json:"target"`
-	CreatedAt *time.Time `db:"created_at" json:"created_at,omitempty"`
-	UpdatedAt *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+	ID         int64      `db:"id" json:"id"`
+	Code       string     `db:"code" json:"code"`
+	Target     string     `db:"target" json:"target"`
+	Domain     string     `db:"domain" json:"-"`
+	Owner      string     `db:"owner" json:"owner,omitempty"`
+	CreatedAt  *time.Time `db:"created_at" json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+	DisabledAt *time.Time `db:"disabled_at" json:"disabled_at,omitempty"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	DeletedAt  *time.Time `db:"deleted_at" json:"-"`
+	// Clicks is only read when counting a redirect, so it is set in
+	// link.click_threshold events and left out of link reads.
+	Clicks int64 `db:"clicks" json:"clicks,omitempty"`
+}
+
+// IsActive reports whether the link can be used for redirects. Expired
+// links are inactive even before the expiry job disables them.
+func (u URL) IsActive() bool {
+	return u.DisabledAt == nil && u.DeletedAt == nil && !u.IsExpired(time.Now())
+}
+
+// IsExpired reports whether the link expiry has passed at now.
+func (u URL) IsExpired(now time.Time) bool {
+	return u.ExpiresAt != nil && !u.ExpiresAt.After(now)
+}
+
+// Status returns the state of the link at now. Expiry takes precedence, so
+// links disabled by the expiry job remain expired.
+func (u URL) Status(now time.Time) URLStatus {
+	switch {
+	case u.IsExpired(now):
+		return URLExpired
+	case u.DisabledAt != nil:
+		return URLDisabled
+	default:
+		return URLActive
+	}
+}
+
+// TargetDomain returns the lowercase host of target, without its port, or
+// an empty string when target is not an absolute URL.
+func TargetDomain(target string) string {
+	parsed, err := url.Parse(target)
+
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(parsed.Hostname())
+}
+
+// URLStatus is the state of a link, as filtered on by listings.
+type URLStatus string
+
+const (
+	URLActive   URLStatus = "active"
+	URLDisabled URLStatus = "disabled"
+	URLExpired  URLStatus = "expired"
+)
+
+// URLStatuses lists the statuses links can be filtered on.
+var URLStatuses = []URLStatus{URLActive, URLDisabled, URLExpired}
+
+// URLFilter narrows a listing of links. Zero fields match every link.
+type URLFilter struct {
+	// Query matches links whose target contains it, ignoring case.
+	Query string
+	// Domain matches links whose target host is the domain or one of its
+	// subdomains.
+	Domain     string
+	CodePrefix string
+	Owner      string
+	Status     URLStatus
+	// CreatedAfter and CreatedBefore bound the creation time, inclusively
+	// and exclusively.
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
 }
@@ -0,0 +1,103 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WebhookSubscription asks for the events of an owner's links to be posted
+// to URL. The secret signs every delivery; it is only disclosed when the
+// subscription is created.
+type WebhookSubscription struct {
+	ID        int64      `db:"id" json:"id"`
+	Owner     string     `db:"owner" json:"owner"`
+	URL       string     `db:"url" json:"url"`
+	Secret    string     `db:"secret" json:"secret,omitempty"`
+	Events    EventTypes `db:"events" json:"events"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
+
+// Accepts reports whether the subscription receives events of the given
+// type. A subscription without event types receives every event.
+func (s WebhookSubscription) Accepts(eventType EventType) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+
+	for _, accepted := range s.Events {
+		if accepted == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EventTypes is stored as a comma-separated list, which keeps the column
+// portable across dialects.
+type EventTypes []EventType
+
+func (t EventTypes) Value() (driver.Value, error) {
+	names := make([]string, len(t))
+
+	for i, eventType := range t {
+		names[i] = string(eventType)
+	}
+
+	return strings.Join(names, ","), nil
+}
+
+func (t *EventTypes) Scan(src any) error {
+	var value string
+
+	switch src := src.(type) {
+	case []byte:
+		value = string(src)
+	case string:
+		value = src
+	default:
+		return fmt.Errorf("unsupported event types type %T", src)
+	}
+
+	*t = EventTypes{}
+
+	for _, name := range strings.Split(value, ",") {
+		if name != "" {
+			*t = append(*t, EventType(name))
+		}
+	}
+
+	return nil
+}
+
+// WebhookStatus is the state of a webhook delivery.
+type WebhookStatus string
+
+const (
+	WebhookPending   WebhookStatus = "pending"
+	WebhookDelivered WebhookStatus = "delivered"
+	// WebhookDead marks a delivery that exhausted its attempts. It stays in
+	// the dead-letter list of its subscription until replayed.
+	WebhookDead WebhookStatus = "dead"
+)
+
+// WebhookDelivery is an event queued for a subscription. Body is the
+// encoded event, signed and posted as is on every attempt; Endpoint and
+// Secret are read from the subscription when the delivery is claimed.
+type WebhookDelivery struct {
+	ID             int64         `db:"id" json:"id"`
+	SubscriptionID int64         `db:"subscription_id" json:"subscription_id"`
+	EventID        int64         `db:"event_id" json:"event_id"`
+	EventType      EventType     `db:"event_type" json:"event_type"`
+	Body           EventPayload  `db:"body" json:"body"`
+	Status         WebhookStatus `db:"status" json:"status"`
+	Attempts       int           `db:"attempts" json:"attempts"`
+	LastError      *string       `db:"last_error" json:"last_error,omitempty"`
+	NextAttemptAt  time.Time     `db:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt      time.Time     `db:"created_at" json:"created_at"`
+	DeliveredAt    *time.Time    `db:"delivered_at" json:"delivered_at,omitempty"`
+	Endpoint       string        `db:"url" json:"-"`
+	Secret         string        `db:"secret" json:"-"`
+}
@@ -0,0 +1,306 @@
+// Package outbox publishes the domain events recorded in the outbox table
+// to a Sink, so other systems react to link changes without the service
+// writing to them directly.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/repository"
+)
+
+// RetryPolicy controls how events the sinks reject are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts before an event is given
+	// up on and stops holding back its link.
+	MaxAttempts int
+	// Backoff is the wait after the first failed attempt; it doubles after
+	// every failure up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// delay returns the wait before the attempt following the given number of
+// failed ones.
+func (p RetryPolicy) delay(failed int) time.Duration {
+	delay := p.Backoff
+
+	for i := 1; i < failed && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.MaxBackoff)
+}
+
+// Relay polls the outbox and publishes pending events to its sink, with
+// at-least-once delivery and in order for each link. An event the sink
+// rejects holds back the following events of its link and is retried with
+// exponential backoff until it is accepted or the policy is exhausted.
+//
+// Events are claimed in a short transaction and published outside of it,
+// so a slow sink never holds database locks. TxSinks are the exception:
+// they write in the transaction marking the event published.
+type Relay struct {
+	repo     repository.OutboxRepository
+	sink     Sink
+	sinks    []Sink
+	txSinks  []TxSink
+	policy   RetryPolicy
+	interval time.Duration
+	batch    int
+
+	started atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	logger  observability.Logger
+}
+
+// NewRelayFromConfig builds a relay publishing to the sink selected by the
+// configuration, and to the extra sinks, such as the webhook dispatcher.
+func NewRelayFromConfig(conf config.OutboxConfiguration, repo repository.OutboxRepository, observer observability.Observer, extra ...Sink) (*Relay, error) {
+	interval, err := conf.PollInterval()
+
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := conf.BatchSize()
+
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := conf.MaxAttempts()
+
+	if err != nil {
+		return nil, err
+	}
+
+	backoff, err := conf.Backoff()
+
+	if err != nil {
+		return nil, err
+	}
+
+	maxBackoff, err := conf.MaxBackoff()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sink, err := NewSinkFromConfig(conf, observer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(extra) > 0 {
+		sink = NewFanoutSink(append([]Sink{sink}, extra...)...)
+	}
+
+	policy := RetryPolicy{MaxAttempts: attempts, Backoff: backoff, MaxBackoff: maxBackoff}
+	return NewRelay(repo, sink, policy, interval, batch, observer), nil
+}
+
+func NewRelay(repo repository.OutboxRepository, sink Sink, policy RetryPolicy, interval time.Duration, batch int, observer observability.Observer) *Relay {
+	sinks, txSinks := split(sink)
+
+	return &Relay{
+		repo:     repo,
+		sink:     sink,
+		sinks:    sinks,
+		txSinks:  txSinks,
+		policy:   policy,
+		interval: interval,
+		batch:    batch,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		logger:   observer.Logger().With("worker", "outbox-relay"),
+	}
+}
+
+// Start polls the outbox in the background until Close is called.
+func (r *Relay) Start() {
+	if r.started.CompareAndSwap(false, true) {
+		go r.run()
+	}
+}
+
+// Close stops polling and closes the sink. The batch in progress is
+// canceled; its events stay claimed until their lease expires, then are
+// published again by the next relay to run.
+func (r *Relay) Close() error {
+	r.once.Do(func() { close(r.stop) })
+
+	if r.started.Load() {
+		<-r.done
+	}
+
+	return r.sink.Close()
+}
+
+// Flush publishes pending events until the outbox is drained or an error
+// occurs, returning how many were published. Events held back by a failed
+// event of their link stay pending until it is retried.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	total := 0
+
+	for {
+		published, err := r.relay(ctx)
+		total += published
+
+		if err != nil || published < r.batch {
+			return total, err
+		}
+	}
+}
+
+func (r *Relay) run() {
+	defer close(r.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-r.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := r.Flush(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			r.logger.Error(ctx, "error relaying outbox events", slog.Any("error", err))
+		}
+	}
+}
+
+// relay claims a batch of pending events and publishes each of them in
+// turn, returning how many were published. The claim lasts long enough for
+// every event to time out on the http sink. Once an event of a link fails,
+// the following events of that link are left for a later batch.
+func (r *Relay) relay(ctx context.Context) (int, error) {
+	now := time.Now()
+	leaseUntil := now.Add(time.Duration(r.batch) * httpSinkTimeout)
+	events, err := r.repo.Claim(ctx, now, leaseUntil, r.batch)
+
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	blocked := map[int64]bool{}
+
+	for _, event := range events {
+		if blocked[event.LinkID] {
+			continue
+		}
+
+		err := r.publish(ctx, event, leaseUntil)
+
+		if ctx.Err() != nil {
+			return published, ctx.Err()
+		}
+
+		switch {
+		case err == nil:
+			published++
+		case errors.Is(err, repository.ErrClaimLost):
+			blocked[event.LinkID] = true
+			r.logger.Warn(ctx, "outbox event claimed by another relay, skipping its link", slog.Int64("event", event.ID), slog.Int64("link", event.LinkID))
+		default:
+			blocked[event.LinkID] = true
+
+			if err := r.fail(ctx, event, leaseUntil, err); err != nil && !errors.Is(err, repository.ErrClaimLost) {
+				return published, err
+			}
+		}
+	}
+
+	return published, nil
+}
+
+// publish hands the event to the sinks, then marks it published while the
+// TxSinks write it, so their writes happen exactly once.
+func (r *Relay) publish(ctx context.Context, event model.Event, leaseUntil time.Time) error {
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return r.repo.Publish(ctx, event, leaseUntil, func(ctx context.Context, tx db.SQLTX) error {
+		for _, sink := range r.txSinks {
+			if err := sink.PublishTx(ctx, tx, event); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// fail records the failed attempt, postponing the event by the policy
+// backoff or giving up on it once its attempts are exhausted.
+func (r *Relay) fail(ctx context.Context, event model.Event, leaseUntil time.Time, err error) error {
+	event.Attempts++
+	attrs := []any{
+		slog.Int64("event", event.ID),
+		slog.Int64("link", event.LinkID),
+		slog.String("type", string(event.Type)),
+		slog.Int("attempts", event.Attempts),
+		slog.Any("error", err),
+	}
+
+	if event.Attempts >= r.policy.MaxAttempts {
+		r.logger.Error(ctx, "outbox event exhausted its attempts, giving up on it", attrs...)
+		return r.repo.Fail(ctx, event, leaseUntil, err.Error(), nil)
+	}
+
+	retryAt := time.Now().Add(r.policy.delay(event.Attempts))
+	r.logger.Warn(ctx, "error publishing event, retrying later", attrs...)
+	return r.repo.Fail(ctx, event, leaseUntil, err.Error(), &retryAt)
+}
+
+// split returns the sinks behind sink that are published to before an
+// event is marked published, and the TxSinks written while marking it.
+func split(sink Sink) ([]Sink, []TxSink) {
+	members := []Sink{sink}
+
+	if fanout, ok := sink.(*FanoutSink); ok {
+		members = fanout.sinks
+	}
+
+	sinks := []Sink{}
+	txSinks := []TxSink{}
+
+	for _, member := range members {
+		if txSink, ok := member.(TxSink); ok {
+			txSinks = append(txSinks, txSink)
+		} else {
+			sinks = append(sinks, member)
+		}
+	}
+
+	return sinks, txSinks
+}
@@ -0,0 +1,277 @@
+package outbox_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/outbox"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+	"github.com/zeon-code/tiny-url/internal/repository"
+)
+
+type fakeSink struct {
+	mu     sync.Mutex
+	events []model.Event
+	fail   map[int64]bool
+}
+
+func (s *fakeSink) Publish(ctx context.Context, event model.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fail[event.ID] {
+		return errors.New("rejected")
+	}
+
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *fakeSink) Close() error { return nil }
+
+func (s *fakeSink) received() []model.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]model.Event(nil), s.events...)
+}
+
+// fakeTxSink records the events it is handed within a transaction.
+type fakeTxSink struct {
+	fakeSink
+}
+
+func (s *fakeTxSink) PublishTx(ctx context.Context, tx db.SQLTX, event model.Event) error {
+	return s.Publish(ctx, event)
+}
+
+func newStores(t *testing.T) (repository.URLRepository, repository.OutboxRepository) {
+	fake := test.NewFakeDependencies(t)
+	client := test.NewSQLiteClient(t)
+
+	return repository.NewURLRepository(client, client, fake.Observer()), repository.NewOutboxRepository(client, fake.Observer())
+}
+
+func TestRelay(t *testing.T) {
+	ctx := context.Background()
+	policy := outbox.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	t.Run("flushes every pending event across batches", func(t *testing.T) {
+		urls, events := newStores(t)
+		sink := &fakeSink{}
+		relay := outbox.NewRelay(events, sink, policy, time.Hour, 2, test.NewFakeDependencies(t).Observer())
+
+		for range 3 {
+			_, err := urls.Create(ctx, "https://example.com", "", nil)
+			require.NoError(t, err)
+		}
+
+		published, err := relay.Flush(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, published)
+		assert.Len(t, sink.received(), 3)
+	})
+
+	t.Run("retries a rejected event before the next ones of its link", func(t *testing.T) {
+		urls, events := newStores(t)
+		sink := &fakeSink{fail: map[int64]bool{1: true}}
+		relay := outbox.NewRelay(events, sink, policy, time.Hour, 10, test.NewFakeDependencies(t).Observer())
+
+		first, err := urls.Create(ctx, "https://example.com/first", "", nil)
+		require.NoError(t, err)
+		_, err = urls.Create(ctx, "https://example.com/second", "", nil)
+		require.NoError(t, err)
+		require.NoError(t, urls.Delete(ctx, first.ID))
+
+		published, err := relay.Flush(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, published)
+
+		sink.mu.Lock()
+		sink.fail = nil
+		sink.mu.Unlock()
+
+		time.Sleep(policy.MaxBackoff)
+		published, err = relay.Flush(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, published)
+
+		ids := []int64{}
+
+		for _, event := range sink.received() {
+			ids = append(ids, event.ID)
+		}
+
+		assert.Equal(t, []int64{2, 1, 3}, ids)
+	})
+
+	t.Run("gives up on an event after its attempts", func(t *testing.T) {
+		urls, events := newStores(t)
+		sink := &fakeSink{fail: map[int64]bool{1: true}}
+		relay := outbox.NewRelay(events, sink, policy, time.Hour, 10, test.NewFakeDependencies(t).Observer())
+
+		created, err := urls.Create(ctx, "https://example.com", "", nil)
+		require.NoError(t, err)
+		require.NoError(t, urls.Delete(ctx, created.ID))
+
+		for range policy.MaxAttempts {
+			published, err := relay.Flush(ctx)
+			require.NoError(t, err)
+			assert.Zero(t, published)
+			time.Sleep(policy.MaxBackoff)
+		}
+
+		published, err := relay.Flush(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, published, "the next event of the link is published")
+
+		published, err = relay.Flush(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, published)
+		assert.Equal(t, int64(2), sink.received()[0].ID)
+	})
+
+	t.Run("writes to tx sinks only once the other sinks accepted the event", func(t *testing.T) {
+		urls, events := newStores(t)
+		plain := &fakeSink{fail: map[int64]bool{1: true}}
+		txSink := &fakeTxSink{}
+		relay := outbox.NewRelay(events, outbox.NewFanoutSink(plain, txSink), policy, time.Hour, 10, test.NewFakeDependencies(t).Observer())
+
+		_, err := urls.Create(ctx, "https://example.com", "", nil)
+		require.NoError(t, err)
+
+		published, err := relay.Flush(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, published)
+		assert.Empty(t, txSink.received())
+
+		plain.mu.Lock()
+		plain.fail = nil
+		plain.mu.Unlock()
+
+		time.Sleep(policy.MaxBackoff)
+		published, err = relay.Flush(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, published)
+		assert.Len(t, plain.received(), 1)
+		assert.Len(t, txSink.received(), 1)
+	})
+
+	t.Run("publishes in the background until closed", func(t *testing.T) {
+		urls, events := newStores(t)
+		sink := &fakeSink{}
+		relay := outbox.NewRelay(events, sink, policy, 5*time.Millisecond, 10, test.NewFakeDependencies(t).Observer())
+
+		_, err := urls.Create(ctx, "https://example.com", "", nil)
+		require.NoError(t, err)
+
+		relay.Start()
+
+		assert.Eventually(t, func() bool { return len(sink.received()) == 1 }, time.Second, 5*time.Millisecond)
+		assert.NoError(t, relay.Close())
+	})
+
+	t.Run("closes without being started", func(t *testing.T) {
+		_, events := newStores(t)
+		relay := outbox.NewRelay(events, &fakeSink{}, policy, time.Hour, 10, test.NewFakeDependencies(t).Observer())
+
+		assert.NoError(t, relay.Close())
+	})
+}
+
+func TestSinks(t *testing.T) {
+	ctx := context.Background()
+	event := model.Event{ID: 3, LinkID: 7, Type: model.EventLinkCreated, Payload: model.EventPayload(`{"id":7}`)}
+
+	t.Run("writer sink writes json lines", func(t *testing.T) {
+		var buffer bytes.Buffer
+		sink := outbox.NewWriterSink(&buffer)
+
+		require.NoError(t, sink.Publish(ctx, event))
+		require.NoError(t, sink.Publish(ctx, event))
+
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		require.Len(t, lines, 2)
+
+		var decoded map[string]any
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &decoded))
+		assert.Equal(t, "link.created", decoded["type"])
+		assert.Equal(t, map[string]any{"id": float64(7)}, decoded["payload"])
+	})
+
+	t.Run("file sink appends to the file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.jsonl")
+
+		for range 2 {
+			sink, err := outbox.NewFileSink(path)
+			require.NoError(t, err)
+			require.NoError(t, sink.Publish(ctx, event))
+			require.NoError(t, sink.Close())
+		}
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, 2, strings.Count(string(data), "\n"))
+	})
+
+	t.Run("http sink posts the event", func(t *testing.T) {
+		var headers http.Header
+		var body []byte
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			headers = r.Header.Clone()
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		sink := outbox.NewHTTPSink(server.URL, server.Client())
+
+		require.NoError(t, sink.Publish(ctx, event))
+		assert.Equal(t, "3", headers.Get("X-Event-ID"))
+		assert.Equal(t, "link.created", headers.Get("X-Event-Type"))
+		assert.Equal(t, "application/json", headers.Get("Content-Type"))
+		assert.Contains(t, string(body), `"payload":{"id":7}`)
+	})
+
+	t.Run("http sink fails on non 2xx answers", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		sink := outbox.NewHTTPSink(server.URL, server.Client())
+
+		assert.EqualError(t, sink.Publish(ctx, event), "webhook answered 503")
+	})
+
+	t.Run("fanout sink publishes to every sink until one fails", func(t *testing.T) {
+		first := &fakeSink{}
+		failing := &fakeSink{fail: map[int64]bool{event.ID: true}}
+		last := &fakeSink{}
+
+		assert.NoError(t, outbox.NewFanoutSink(outbox.NopSink{}, first, last).Publish(ctx, event))
+		assert.Len(t, first.received(), 1)
+		assert.Len(t, last.received(), 1)
+
+		assert.EqualError(t, outbox.NewFanoutSink(first, failing, last).Publish(ctx, event), "rejected")
+		assert.Len(t, first.received(), 2)
+		assert.Len(t, last.received(), 1)
+	})
+}
@@ -0,0 +1,207 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+// httpSinkTimeout bounds each delivery to the webhook sink.
+const httpSinkTimeout = 5 * time.Second
+
+// Sink receives the events published by the Relay. Publish must return an
+// error unless the event was accepted; the event is then published again
+// later. Events may be published more than once, so consumers should
+// deduplicate on Event.ID.
+type Sink interface {
+	Publish(context.Context, model.Event) error
+	Close() error
+}
+
+// TxSink is a Sink writing to the service database. The relay publishes to
+// it within the transaction marking the event published, instead of calling
+// Publish, so its writes happen exactly once.
+type TxSink interface {
+	Sink
+	PublishTx(context.Context, db.SQLTX, model.Event) error
+}
+
+// NewSinkFromConfig builds the sink selected by OUTBOX_SINK.
+func NewSinkFromConfig(conf config.OutboxConfiguration, observer observability.Observer) (Sink, error) {
+	sink, err := conf.Sink()
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch sink {
+	case "file":
+		path, err := conf.FilePath()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return NewFileSink(path)
+	case "http":
+		endpoint, err := conf.WebhookURL()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return NewHTTPSink(endpoint, &http.Client{Timeout: httpSinkTimeout}), nil
+	case "none":
+		return NopSink{}, nil
+	default:
+		return NewWriterSink(os.Stdout), nil
+	}
+}
+
+// WriterSink writes every event as a line of JSON.
+type WriterSink struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func NewWriterSink(writer io.Writer) *WriterSink {
+	return &WriterSink{writer: writer}
+}
+
+func (s *WriterSink) Publish(ctx context.Context, event model.Event) error {
+	data, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.writer.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the writer when it is an io.Closer other than the standard
+// streams.
+func (s *WriterSink) Close() error {
+	if closer, ok := s.writer.(io.Closer); ok && s.writer != os.Stdout && s.writer != os.Stderr {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// NewFileSink appends events to the file at path, creating it if needed.
+func NewFileSink(path string) (*WriterSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWriterSink(file), nil
+}
+
+// HTTPSink posts every event as JSON to a webhook. Any 2xx response
+// acknowledges the event; the X-Event-ID and X-Event-Type headers let the
+// receiver deduplicate and route it without decoding the body.
+type HTTPSink struct {
+	endpoint string
+	client   *http.Client
+}
+
+func NewHTTPSink(endpoint string, client *http.Client) *HTTPSink {
+	return &HTTPSink{endpoint: endpoint, client: client}
+}
+
+func (s *HTTPSink) Publish(ctx context.Context, event model.Event) error {
+	data, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(data))
+
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Event-ID", strconv.FormatInt(event.ID, 10))
+	request.Header.Set("X-Event-Type", string(event.Type))
+
+	response, err := s.client.Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *HTTPSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// NopSink accepts and discards every event, for deployments where only
+// the extra sinks of the relay consume the outbox.
+type NopSink struct{}
+
+func (NopSink) Publish(context.Context, model.Event) error { return nil }
+
+func (NopSink) Close() error { return nil }
+
+// FanoutSink publishes every event to each of its sinks in turn, stopping
+// at the first failure. The event is then published again to every sink,
+// including those that accepted it. The relay publishes to its TxSinks
+// while marking the event published, after the other sinks accepted it.
+type FanoutSink struct {
+	sinks []Sink
+}
+
+func NewFanoutSink(sinks ...Sink) *FanoutSink {
+	return &FanoutSink{sinks: sinks}
+}
+
+func (s *FanoutSink) Publish(ctx context.Context, event model.Event) error {
+	for _, sink := range s.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *FanoutSink) Close() error {
+	var err error
+
+	for _, sink := range s.sinks {
+		err = errors.Join(err, sink.Close())
+	}
+
+	return err
+}
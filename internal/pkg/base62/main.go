@@ -30,6 +30,32 @@ func Encode(n int64) string {
 	return string(buf[i:])
 }
 
+// IsValid reports whether s is a non-empty base62 string that decodes
+// into a positive int64 without overflowing.
+func IsValid(s string) bool {
+	if len(s) == 0 || len(s) > 11 {
+		return false
+	}
+
+	var n int64
+
+	for i := 0; i < len(s); i++ {
+		val, ok := index[s[i]]
+
+		if !ok {
+			return false
+		}
+
+		if n > (1<<63-1-int64(val))/base {
+			return false
+		}
+
+		n = n*base + int64(val)
+	}
+
+	return true
+}
+
 // Decode converts a base62 string back into an int64.
 // It panics on invalid characters.
 func Decode(s string) int64 {
@@ -25,6 +25,35 @@ func (k CacheKey) With(parts ...any) CacheKey {
 	return CacheKey{contexts: k.contexts, parts: next}
 }
 
+// Family returns a bounded-cardinality label for the key, made of its
+// contexts and first part (e.g. "url-service/id"). Unlike String, it never
+// includes identifiers, so it is safe to use as a metric attribute.
+func (k CacheKey) Family() string {
+	contexts := strings.Join(k.contexts, "-")
+
+	if len(k.parts) == 0 {
+		return contexts
+	}
+
+	if len(contexts) == 0 {
+		return k.parts[0]
+	}
+
+	return fmt.Sprintf("%s/%s", contexts, k.parts[0])
+}
+
+// FamilyPrefix returns the key prefix shared by every key of the given
+// family, e.g. "url-service/id" becomes "url-service:id:".
+func FamilyPrefix(family string) string {
+	contexts, part, found := strings.Cut(family, "/")
+
+	if !found {
+		return contexts + ":"
+	}
+
+	return fmt.Sprintf("%s:%s:", contexts, part)
+}
+
 func (k CacheKey) String() string {
 	parts := strings.Join(k.parts, ":")
 	contexts := strings.Join(k.contexts, "-")
@@ -0,0 +1,33 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/pkg/cache"
+)
+
+func TestCacheKey(t *testing.T) {
+	key := cache.NewCacheKey("url", "service")
+
+	t.Run("should compose key", func(t *testing.T) {
+		assert.Equal(t, "url-service:id:12345", key.With("id", 12345).String())
+	})
+
+	t.Run("should return family from context and first part", func(t *testing.T) {
+		assert.Equal(t, "url-service/id", key.With("id", 12345).Family())
+		assert.Equal(t, "url-service/list", key.With("list", "<", 10).Family())
+	})
+
+	t.Run("should return context as family without parts", func(t *testing.T) {
+		assert.Equal(t, "url-service", key.Family())
+	})
+
+	t.Run("should return first part as family without context", func(t *testing.T) {
+		assert.Equal(t, "id", cache.NewCacheKey().With("id", 1).Family())
+	})
+	t.Run("should return prefix shared by the family keys", func(t *testing.T) {
+		assert.Equal(t, "url-service:id:", cache.FamilyPrefix("url-service/id"))
+		assert.Equal(t, "url-service:", cache.FamilyPrefix("url-service"))
+	})
+}
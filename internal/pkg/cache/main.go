@@ -8,8 +8,19 @@ import (
 type cacheKey struct{}
 
 type CachePolicy struct {
-	TTL time.Duration
-	Key string
+	TTL    time.Duration
+	Key    string
+	Family string
+}
+
+// NewCachePolicy builds a policy caching entries under key for ttl, with
+// the key family used to label cache metrics.
+func NewCachePolicy(key CacheKey, ttl time.Duration) CachePolicy {
+	return CachePolicy{
+		TTL:    ttl,
+		Key:    key.String(),
+		Family: key.Family(),
+	}
 }
 
 type Cache struct {
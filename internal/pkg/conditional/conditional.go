@@ -0,0 +1,93 @@
+// Package conditional implements the validators and preconditions of
+// conditional requests (RFC 9110, section 13).
+package conditional
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETag returns the strong entity tag of a representation at version.
+func ETag(version string) string {
+	return `"` + version + `"`
+}
+
+// HashETag returns a strong entity tag derived from a representation's
+// content, for resources without a version of their own.
+func HashETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return ETag(hex.EncodeToString(sum[:16]))
+}
+
+// SetValidators sets the ETag and, unless modified is zero, the
+// Last-Modified headers of a response.
+func SetValidators(w http.ResponseWriter, etag string, modified time.Time) {
+	w.Header().Set("ETag", etag)
+
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// NotModified reports whether a GET or HEAD request can be answered with
+// 304 Not Modified. If-None-Match takes precedence over If-Modified-Since,
+// which is only evaluated when modified is known.
+func NotModified(r *http.Request, etag string, modified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		return matches(header, etag, false)
+	}
+
+	since, ok := parseDate(r.Header.Get("If-Modified-Since"))
+	return ok && !modified.IsZero() && !modified.Truncate(time.Second).After(since)
+}
+
+// PreconditionFailed reports whether a state-changing request must be
+// answered with 412 Precondition Failed, because the resource changed
+// since the client read it. If-Match takes precedence over
+// If-Unmodified-Since, and only strong entity tags match.
+func PreconditionFailed(r *http.Request, etag string, modified time.Time) bool {
+	if header := r.Header.Get("If-Match"); header != "" {
+		return !matches(header, etag, true)
+	}
+
+	since, ok := parseDate(r.Header.Get("If-Unmodified-Since"))
+	return ok && !modified.IsZero() && modified.Truncate(time.Second).After(since)
+}
+
+// matches reports whether the comma-separated entity tags of header
+// contain etag or "*". Strong comparison ignores weak tags.
+func matches(header string, etag string, strong bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" {
+			return true
+		}
+
+		if strong && (strings.HasPrefix(candidate, "W/") || strings.HasPrefix(etag, "W/")) {
+			continue
+		}
+
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseDate(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	at, err := http.ParseTime(value)
+	return at, err == nil
+}
@@ -0,0 +1,94 @@
+package conditional_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/pkg/conditional"
+)
+
+func TestConditional(t *testing.T) {
+	etag := conditional.ETag("v2")
+	modified := time.Date(2026, 3, 1, 10, 0, 0, 500, time.UTC)
+
+	request := func(method string, headers map[string]string) *http.Request {
+		req := httptest.NewRequest(method, "/", nil)
+
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+
+		return req
+	}
+
+	t.Run("set validators", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		conditional.SetValidators(rec, etag, modified)
+
+		assert.Equal(t, `"v2"`, rec.Header().Get("ETag"))
+		assert.Equal(t, "Sun, 01 Mar 2026 10:00:00 GMT", rec.Header().Get("Last-Modified"))
+
+		rec = httptest.NewRecorder()
+		conditional.SetValidators(rec, etag, time.Time{})
+
+		assert.Empty(t, rec.Header().Get("Last-Modified"))
+	})
+
+	t.Run("hash content", func(t *testing.T) {
+		assert.Equal(t, conditional.HashETag([]byte("a")), conditional.HashETag([]byte("a")))
+		assert.NotEqual(t, conditional.HashETag([]byte("a")), conditional.HashETag([]byte("b")))
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		for _, headers := range []map[string]string{
+			{"If-None-Match": `"v2"`},
+			{"If-None-Match": `"v1", W/"v2"`},
+			{"If-None-Match": "*"},
+			{"If-Modified-Since": "Sun, 01 Mar 2026 10:00:00 GMT"},
+			{"If-Modified-Since": "Sun, 01 Mar 2026 11:00:00 GMT"},
+		} {
+			assert.True(t, conditional.NotModified(request(http.MethodGet, headers), etag, modified), headers)
+		}
+	})
+
+	t.Run("modified", func(t *testing.T) {
+		for _, headers := range []map[string]string{
+			{},
+			{"If-None-Match": `"v1"`},
+			{"If-None-Match": `"v1"`, "If-Modified-Since": "Sun, 01 Mar 2026 11:00:00 GMT"},
+			{"If-Modified-Since": "Sun, 01 Mar 2026 09:59:59 GMT"},
+			{"If-Modified-Since": "yesterday"},
+		} {
+			assert.False(t, conditional.NotModified(request(http.MethodGet, headers), etag, modified), headers)
+		}
+
+		assert.False(t, conditional.NotModified(request(http.MethodPost, map[string]string{"If-None-Match": `"v2"`}), etag, modified))
+		assert.False(t, conditional.NotModified(request(http.MethodGet, map[string]string{"If-Modified-Since": "Sun, 01 Mar 2026 11:00:00 GMT"}), etag, time.Time{}))
+	})
+
+	t.Run("precondition holds", func(t *testing.T) {
+		for _, headers := range []map[string]string{
+			{},
+			{"If-Match": `"v2"`},
+			{"If-Match": `"v1", "v2"`},
+			{"If-Match": "*"},
+			{"If-Unmodified-Since": "Sun, 01 Mar 2026 10:00:00 GMT"},
+		} {
+			assert.False(t, conditional.PreconditionFailed(request(http.MethodDelete, headers), etag, modified), headers)
+		}
+	})
+
+	t.Run("precondition fails", func(t *testing.T) {
+		for _, headers := range []map[string]string{
+			{"If-Match": `"v1"`},
+			{"If-Match": `W/"v2"`},
+			{"If-Match": `"v1"`, "If-Unmodified-Since": "Sun, 01 Mar 2026 11:00:00 GMT"},
+			{"If-Unmodified-Since": "Sun, 01 Mar 2026 09:59:59 GMT"},
+		} {
+			assert.True(t, conditional.PreconditionFailed(request(http.MethodDelete, headers), etag, modified), headers)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+type LocalCacheConfiguration interface {
+	DatabaseConfiguration
+
+	MaxEntries() (int, error)
+	MaxBytes() (int64, error)
+}
+
+// LocalCacheConfig configures the in-process cache used instead of Redis by
+// single-instance deployments. Both bounds are optional; once either is
+// reached the least recently used entries are evicted.
+type LocalCacheConfig struct{}
+
+func NewLocalCacheConfig() LocalCacheConfig {
+	return LocalCacheConfig{}
+}
+
+func (c LocalCacheConfig) Driver() string {
+	return "local"
+}
+
+// DSN is empty: the local cache lives in the process memory.
+func (c LocalCacheConfig) DSN() (string, error) {
+	return "", nil
+}
+
+// MaxEntries returns CACHE_MAX_ENTRIES, defaulting to 10000.
+func (c LocalCacheConfig) MaxEntries() (int, error) {
+	value, exists := os.LookupEnv("CACHE_MAX_ENTRIES")
+
+	if !exists {
+		return 10000, nil
+	}
+
+	entries, err := strconv.Atoi(value)
+
+	if err != nil || entries <= 0 {
+		return 0, fmt.Errorf("CACHE_MAX_ENTRIES must be a positive interger value")
+	}
+
+	return entries, nil
+}
+
+// MaxBytes returns CACHE_MAX_BYTES, the total size of the cached values,
+// defaulting to 64 MiB.
+func (c LocalCacheConfig) MaxBytes() (int64, error) {
+	value, exists := os.LookupEnv("CACHE_MAX_BYTES")
+
+	if !exists {
+		return 64 << 20, nil
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("CACHE_MAX_BYTES must be a positive interger value")
+	}
+
+	return size, nil
+}
@@ -0,0 +1,64 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+)
+
+func TestLocalCacheConfiguration(t *testing.T) {
+	conf := config.NewLocalCacheConfig()
+
+	t.Run("should return cache driver", func(t *testing.T) {
+		assert.Equal(t, "local", conf.Driver())
+	})
+
+	t.Run("should return default bounds", func(t *testing.T) {
+		entries, err := conf.MaxEntries()
+		assert.NoError(t, err)
+		assert.Equal(t, 10000, entries)
+
+		size, err := conf.MaxBytes()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(64<<20), size)
+	})
+
+	t.Run("should return configured bounds", func(t *testing.T) {
+		os.Setenv("CACHE_MAX_ENTRIES", "10")
+		os.Setenv("CACHE_MAX_BYTES", "2048")
+		defer os.Unsetenv("CACHE_MAX_ENTRIES")
+		defer os.Unsetenv("CACHE_MAX_BYTES")
+
+		entries, err := conf.MaxEntries()
+		assert.NoError(t, err)
+		assert.Equal(t, 10, entries)
+
+		size, err := conf.MaxBytes()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2048), size)
+	})
+
+	t.Run("should return error when bounds are invalid", func(t *testing.T) {
+		os.Setenv("CACHE_MAX_ENTRIES", "0")
+		os.Setenv("CACHE_MAX_BYTES", "lots")
+		defer os.Unsetenv("CACHE_MAX_ENTRIES")
+		defer os.Unsetenv("CACHE_MAX_BYTES")
+
+		_, err := conf.MaxEntries()
+		assert.Error(t, err)
+
+		_, err = conf.MaxBytes()
+		assert.Error(t, err)
+	})
+
+	t.Run("should select local cache through CACHE_DRIVER", func(t *testing.T) {
+		assert.Equal(t, "redis", config.NewConfiguration().Cache().Driver())
+
+		os.Setenv("CACHE_DRIVER", "local")
+		defer os.Unsetenv("CACHE_DRIVER")
+
+		assert.Equal(t, "local", config.NewConfiguration().Cache().Driver())
+	})
+}
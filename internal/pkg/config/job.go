@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+type JobConfiguration interface {
+	ExpireInterval() (time.Duration, error)
+	PurgeInterval() (time.Duration, error)
+	Retention() (time.Duration, error)
+	BatchSize() (int, error)
+}
+
+// JobConfig reads how the background jobs run from environment variables
+// sharing the given prefix (e.g. JOBS_RETENTION).
+type JobConfig struct {
+	Prefix string
+}
+
+func NewJobConfig(prefix string) JobConfig {
+	return JobConfig{
+		Prefix: prefix,
+	}
+}
+
+// ExpireInterval returns <PREFIX>_EXPIRE_INTERVAL, how often expired links
+// are disabled, defaulting to 1 minute.
+func (c JobConfig) ExpireInterval() (time.Duration, error) {
+	return c.duration("EXPIRE_INTERVAL", 1*time.Minute)
+}
+
+// PurgeInterval returns <PREFIX>_PURGE_INTERVAL, how often deleted links
+// past their retention are purged, defaulting to 1 hour.
+func (c JobConfig) PurgeInterval() (time.Duration, error) {
+	return c.duration("PURGE_INTERVAL", 1*time.Hour)
+}
+
+// Retention returns <PREFIX>_RETENTION, how long deleted links are kept
+// before they are purged, defaulting to 30 days.
+func (c JobConfig) Retention() (time.Duration, error) {
+	return c.duration("RETENTION", 30*24*time.Hour)
+}
+
+// BatchSize returns <PREFIX>_BATCH_SIZE, how many links a job changes per
+// statement, defaulting to 500.
+func (c JobConfig) BatchSize() (int, error) {
+	return c.integer("BATCH_SIZE", 500)
+}
+
+func (c JobConfig) duration(suffix string, fallback time.Duration) (time.Duration, error) {
+	value, exists := os.LookupEnv(fmt.Sprintf("%s_%s", c.Prefix, suffix))
+
+	if !exists {
+		return fallback, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("%s_%s must be a positive duration", c.Prefix, suffix)
+	}
+
+	return duration, nil
+}
+
+func (c JobConfig) integer(suffix string, fallback int) (int, error) {
+	value, exists := os.LookupEnv(fmt.Sprintf("%s_%s", c.Prefix, suffix))
+
+	if !exists {
+		return fallback, nil
+	}
+
+	number, err := strconv.Atoi(value)
+
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("%s_%s must be a positive integer value", c.Prefix, suffix)
+	}
+
+	return number, nil
+}
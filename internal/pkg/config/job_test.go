@@ -0,0 +1,60 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+)
+
+func TestJobConfiguration(t *testing.T) {
+	conf := config.NewJobConfig("JOBS_TEST")
+
+	t.Run("should return default settings", func(t *testing.T) {
+		expire, err := conf.ExpireInterval()
+		assert.NoError(t, err)
+		assert.Equal(t, 1*time.Minute, expire)
+
+		purge, err := conf.PurgeInterval()
+		assert.NoError(t, err)
+		assert.Equal(t, 1*time.Hour, purge)
+
+		retention, err := conf.Retention()
+		assert.NoError(t, err)
+		assert.Equal(t, 30*24*time.Hour, retention)
+
+		size, err := conf.BatchSize()
+		assert.NoError(t, err)
+		assert.Equal(t, 500, size)
+	})
+
+	t.Run("should return configured settings", func(t *testing.T) {
+		os.Setenv("JOBS_TEST_RETENTION", "168h")
+		os.Setenv("JOBS_TEST_BATCH_SIZE", "50")
+		defer os.Unsetenv("JOBS_TEST_RETENTION")
+		defer os.Unsetenv("JOBS_TEST_BATCH_SIZE")
+
+		retention, err := conf.Retention()
+		assert.NoError(t, err)
+		assert.Equal(t, 168*time.Hour, retention)
+
+		size, err := conf.BatchSize()
+		assert.NoError(t, err)
+		assert.Equal(t, 50, size)
+	})
+
+	t.Run("should reject invalid settings", func(t *testing.T) {
+		os.Setenv("JOBS_TEST_EXPIRE_INTERVAL", "-1m")
+		os.Setenv("JOBS_TEST_BATCH_SIZE", "many")
+		defer os.Unsetenv("JOBS_TEST_EXPIRE_INTERVAL")
+		defer os.Unsetenv("JOBS_TEST_BATCH_SIZE")
+
+		_, err := conf.ExpireInterval()
+		assert.EqualError(t, err, "JOBS_TEST_EXPIRE_INTERVAL must be a positive duration")
+
+		_, err = conf.BatchSize()
+		assert.EqualError(t, err, "JOBS_TEST_BATCH_SIZE must be a positive integer value")
+	})
+}
@@ -1,11 +1,24 @@
 package config
 
+import (
+	"os"
+	"strings"
+)
+
 type Configuration interface {
 	Log() Log
 	Cache() DatabaseConfiguration
 	PrimaryDatabase() DatabaseConfiguration
-	ReplicaDatabase() DatabaseConfiguration
+	ReplicaDatabases() []DatabaseConfiguration
+	Replication() ReplicationConfiguration
+	MigrationDatabase() DatabaseConfiguration
 	Metric() MetricConfiguration
+	Server() ServerConfiguration
+	Admin() ServerConfiguration
+	Outbox() OutboxConfiguration
+	Webhook() WebhookConfiguration
+	Jobs() JobConfiguration
+	Pagination() PaginationConfiguration
 }
 
 type AppConfiguration struct{}
@@ -14,22 +27,118 @@ func NewConfiguration() Configuration {
 	return AppConfiguration{}
 }
 
+// Cache selects the backend through CACHE_DRIVER, either "redis" (the
+// default) or "local" for an in-process cache.
 func (c AppConfiguration) Cache() DatabaseConfiguration {
+	if cacheDriver, exists := os.LookupEnv("CACHE_DRIVER"); exists && strings.ToLower(cacheDriver) == "local" {
+		return NewLocalCacheConfig()
+	}
+
 	return RedisConfig{}
 }
 
+// PrimaryDatabase selects the backend through DB_DRIVER, either "postgres"
+// (the default) or "sqlite".
 func (c AppConfiguration) PrimaryDatabase() DatabaseConfiguration {
+	if databaseDriver("DB") == "sqlite" {
+		return NewSQLiteConfig("DB")
+	}
+
 	return NewPostgresConfig("DB")
 }
 
-func (c AppConfiguration) ReplicaDatabase() DatabaseConfiguration {
-	return NewPostgresConfig("DB_REPLICA")
+// ReplicaDatabases follows DB_DRIVER. SQLite has no replicas, so reads are
+// served by the primary database file.
+//
+// DB_REPLICA_HOSTS lists several replicas as comma-separated host:port
+// pairs sharing the remaining DB_REPLICA_* settings; without it a single
+// replica is read from DB_REPLICA_HOST and DB_REPLICA_PORT.
+func (c AppConfiguration) ReplicaDatabases() []DatabaseConfiguration {
+	if databaseDriver("DB") == "sqlite" {
+		return []DatabaseConfiguration{NewSQLiteConfig("DB")}
+	}
+
+	hosts, exists := os.LookupEnv("DB_REPLICA_HOSTS")
+
+	if !exists || strings.TrimSpace(hosts) == "" {
+		return []DatabaseConfiguration{NewPostgresConfig("DB_REPLICA")}
+	}
+
+	replicas := []DatabaseConfiguration{}
+
+	for _, endpoint := range strings.Split(hosts, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			replicas = append(replicas, NewPostgresConfigWithEndpoint("DB_REPLICA", endpoint))
+		}
+	}
+
+	return replicas
+}
+
+// Replication configures how replicas are monitored and balanced, through
+// the DB_REPLICA_MAX_LAG, DB_REPLICA_CHECK_INTERVAL and DB_REPLICA_BALANCE
+// variables.
+func (c AppConfiguration) Replication() ReplicationConfiguration {
+	return NewReplicationConfig("DB_REPLICA")
+}
+
+// MigrationDatabase connects to the primary database, using the
+// DB_MIGRATION_USER and DB_MIGRATION_PASSWORD credentials when set, since
+// schema changes usually need more privileges than the application role.
+func (c AppConfiguration) MigrationDatabase() DatabaseConfiguration {
+	if databaseDriver("DB") == "sqlite" {
+		return NewSQLiteConfig("DB")
+	}
+
+	return NewPostgresConfigWithCredentials("DB", "DB_MIGRATION")
 }
 
 func (c AppConfiguration) Metric() MetricConfiguration {
 	return NewOtelConfiguration()
 }
 
+func (c AppConfiguration) Server() ServerConfiguration {
+	return NewServerConfig("SERVER", ":8080")
+}
+
+// Admin configures the internal listener serving probes and profiling,
+// through the ADMIN_* variables.
+func (c AppConfiguration) Admin() ServerConfiguration {
+	return NewAdminServerConfig("ADMIN", ":9090")
+}
+
+// Outbox configures how domain events are relayed, through the OUTBOX_*
+// variables.
+func (c AppConfiguration) Outbox() OutboxConfiguration {
+	return NewOutboxConfig("OUTBOX")
+}
+
+// Webhook configures how webhook deliveries are attempted, through the
+// WEBHOOK_* variables.
+func (c AppConfiguration) Webhook() WebhookConfiguration {
+	return NewWebhookConfig("WEBHOOK")
+}
+
+// Jobs configures the background jobs, through the JOBS_* variables.
+func (c AppConfiguration) Jobs() JobConfiguration {
+	return NewJobConfig("JOBS")
+}
+
+// Pagination configures page sizes and cursor signing for listings,
+// through the PAGINATION_* variables.
+func (c AppConfiguration) Pagination() PaginationConfiguration {
+	return NewPaginationConfig("PAGINATION")
+}
+
 func (c AppConfiguration) Log() Log {
 	return newLogConfig()
 }
+
+// databaseDriver returns <PREFIX>_DRIVER, defaulting to "postgres".
+func databaseDriver(prefix string) string {
+	if value, exists := os.LookupEnv(prefix + "_DRIVER"); exists {
+		return strings.ToLower(value)
+	}
+
+	return "postgres"
+}
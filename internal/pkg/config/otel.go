@@ -12,6 +12,8 @@ type MetricConfiguration interface {
 	Environment() (string, error)
 	Host() (string, error)
 	Port() (int, error)
+	Fallback() string
+	File() (string, error)
 }
 
 type OtelConfiguration struct{}
@@ -48,6 +50,21 @@ func (c OtelConfiguration) Port() (int, error) {
 	return port, nil
 }
 
+// Fallback returns the exporter used while the OTLP collector cannot be
+// reached: "stdout", "file" or "none". Defaults to "none".
+func (c OtelConfiguration) Fallback() string {
+	if value, err := c.get("TELEMETRY_FALLBACK"); err == nil {
+		return value
+	}
+
+	return "none"
+}
+
+// File returns the path the "file" exporter appends telemetry to.
+func (c OtelConfiguration) File() (string, error) {
+	return c.get("TELEMETRY_FILE")
+}
+
 func (c OtelConfiguration) get(env string) (string, error) {
 	if value, exists := os.LookupEnv(env); exists {
 		return value, nil
@@ -74,4 +74,28 @@ func TestOtelConfiguration(t *testing.T) {
 		_, err := conf.Port()
 		assert.Error(t, err)
 	})
+	t.Run("should return none fallback by default", func(t *testing.T) {
+		assert.Equal(t, "none", conf.Fallback())
+	})
+
+	t.Run("should return telemetry fallback", func(t *testing.T) {
+		os.Setenv("TELEMETRY_FALLBACK", "stdout")
+		defer os.Unsetenv("TELEMETRY_FALLBACK")
+
+		assert.Equal(t, "stdout", conf.Fallback())
+	})
+
+	t.Run("should return telemetry file", func(t *testing.T) {
+		os.Setenv("TELEMETRY_FILE", "/tmp/telemetry.jsonl")
+		defer os.Unsetenv("TELEMETRY_FILE")
+
+		file, err := conf.File()
+		assert.NoError(t, err)
+		assert.Equal(t, "/tmp/telemetry.jsonl", file)
+	})
+
+	t.Run("should return error when file is not set", func(t *testing.T) {
+		_, err := conf.File()
+		assert.Error(t, err)
+	})
 }
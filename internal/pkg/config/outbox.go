@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type OutboxConfiguration interface {
+	Sink() (string, error)
+	FilePath() (string, error)
+	WebhookURL() (string, error)
+	PollInterval() (time.Duration, error)
+	BatchSize() (int, error)
+	MaxAttempts() (int, error)
+	Backoff() (time.Duration, error)
+	MaxBackoff() (time.Duration, error)
+}
+
+// OutboxConfig reads where the outbox relay publishes domain events from
+// environment variables sharing the given prefix (e.g. OUTBOX_SINK).
+type OutboxConfig struct {
+	Prefix string
+}
+
+func NewOutboxConfig(prefix string) OutboxConfig {
+	return OutboxConfig{
+		Prefix: prefix,
+	}
+}
+
+// Sink returns <PREFIX>_SINK: "stdout" (the default), "file", "http" or
+// "none".
+func (c OutboxConfig) Sink() (string, error) {
+	value, exists := os.LookupEnv(fmt.Sprintf("%s_SINK", c.Prefix))
+
+	if !exists {
+		return "stdout", nil
+	}
+
+	switch sink := strings.ToLower(value); sink {
+	case "stdout", "file", "http", "none":
+		return sink, nil
+	}
+
+	return "", fmt.Errorf("%s_SINK must be stdout, file, http or none", c.Prefix)
+}
+
+// FilePath returns <PREFIX>_FILE_PATH, the file events are appended to by
+// the file sink.
+func (c OutboxConfig) FilePath() (string, error) {
+	value, exists := os.LookupEnv(fmt.Sprintf("%s_FILE_PATH", c.Prefix))
+
+	if !exists || value == "" {
+		return "", fmt.Errorf("%s_FILE_PATH not found", c.Prefix)
+	}
+
+	return value, nil
+}
+
+// WebhookURL returns <PREFIX>_WEBHOOK_URL, the absolute http(s) URL events
+// are posted to by the http sink.
+func (c OutboxConfig) WebhookURL() (string, error) {
+	value, exists := os.LookupEnv(fmt.Sprintf("%s_WEBHOOK_URL", c.Prefix))
+
+	if !exists || value == "" {
+		return "", fmt.Errorf("%s_WEBHOOK_URL not found", c.Prefix)
+	}
+
+	endpoint, err := url.Parse(value)
+
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return "", fmt.Errorf("%s_WEBHOOK_URL must be an absolute http or https URL", c.Prefix)
+	}
+
+	return value, nil
+}
+
+// PollInterval returns <PREFIX>_POLL_INTERVAL, how often pending events are
+// looked up, defaulting to 1 second.
+func (c OutboxConfig) PollInterval() (time.Duration, error) {
+	return c.duration("POLL_INTERVAL", 1*time.Second)
+}
+
+// BatchSize returns <PREFIX>_BATCH_SIZE, how many events are claimed at
+// once, defaulting to 100.
+func (c OutboxConfig) BatchSize() (int, error) {
+	return c.integer("BATCH_SIZE", 100)
+}
+
+// MaxAttempts returns <PREFIX>_MAX_ATTEMPTS, how many times an event is
+// published before it is given up on, defaulting to 20.
+func (c OutboxConfig) MaxAttempts() (int, error) {
+	return c.integer("MAX_ATTEMPTS", 20)
+}
+
+// Backoff returns <PREFIX>_BACKOFF, the wait after the first failed
+// attempt, defaulting to 1 second. It doubles after every failure.
+func (c OutboxConfig) Backoff() (time.Duration, error) {
+	return c.duration("BACKOFF", 1*time.Second)
+}
+
+// MaxBackoff returns <PREFIX>_MAX_BACKOFF, the longest wait between two
+// attempts, defaulting to 1 hour.
+func (c OutboxConfig) MaxBackoff() (time.Duration, error) {
+	return c.duration("MAX_BACKOFF", 1*time.Hour)
+}
+
+func (c OutboxConfig) duration(suffix string, fallback time.Duration) (time.Duration, error) {
+	value, exists := os.LookupEnv(fmt.Sprintf("%s_%s", c.Prefix, suffix))
+
+	if !exists {
+		return fallback, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("%s_%s must be a positive duration", c.Prefix, suffix)
+	}
+
+	return duration, nil
+}
+
+func (c OutboxConfig) integer(suffix string, fallback int) (int, error) {
+	value, exists := os.LookupEnv(fmt.Sprintf("%s_%s", c.Prefix, suffix))
+
+	if !exists {
+		return fallback, nil
+	}
+
+	number, err := strconv.Atoi(value)
+
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("%s_%s must be a positive integer value", c.Prefix, suffix)
+	}
+
+	return number, nil
+}
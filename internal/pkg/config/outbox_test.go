@@ -0,0 +1,95 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+)
+
+func TestOutboxConfiguration(t *testing.T) {
+	conf := config.NewOutboxConfig("OUTBOX_TEST")
+
+	t.Run("should return default settings", func(t *testing.T) {
+		sink, err := conf.Sink()
+		assert.NoError(t, err)
+		assert.Equal(t, "stdout", sink)
+
+		interval, err := conf.PollInterval()
+		assert.NoError(t, err)
+		assert.Equal(t, 1*time.Second, interval)
+
+		size, err := conf.BatchSize()
+		assert.NoError(t, err)
+		assert.Equal(t, 100, size)
+
+		attempts, err := conf.MaxAttempts()
+		assert.NoError(t, err)
+		assert.Equal(t, 20, attempts)
+
+		backoff, err := conf.Backoff()
+		assert.NoError(t, err)
+		assert.Equal(t, 1*time.Second, backoff)
+
+		maxBackoff, err := conf.MaxBackoff()
+		assert.NoError(t, err)
+		assert.Equal(t, 1*time.Hour, maxBackoff)
+	})
+
+	t.Run("should return outbox sink", func(t *testing.T) {
+		os.Setenv("OUTBOX_TEST_SINK", "HTTP")
+		defer os.Unsetenv("OUTBOX_TEST_SINK")
+
+		sink, err := conf.Sink()
+		assert.NoError(t, err)
+		assert.Equal(t, "http", sink)
+	})
+
+	t.Run("should reject unknown sinks", func(t *testing.T) {
+		os.Setenv("OUTBOX_TEST_SINK", "kafka")
+		defer os.Unsetenv("OUTBOX_TEST_SINK")
+
+		_, err := conf.Sink()
+		assert.EqualError(t, err, "OUTBOX_TEST_SINK must be stdout, file, http or none")
+	})
+
+	t.Run("should require a file path", func(t *testing.T) {
+		_, err := conf.FilePath()
+		assert.EqualError(t, err, "OUTBOX_TEST_FILE_PATH not found")
+	})
+
+	t.Run("should return webhook url", func(t *testing.T) {
+		os.Setenv("OUTBOX_TEST_WEBHOOK_URL", "https://example.com/events")
+		defer os.Unsetenv("OUTBOX_TEST_WEBHOOK_URL")
+
+		endpoint, err := conf.WebhookURL()
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/events", endpoint)
+	})
+
+	t.Run("should reject relative webhook urls", func(t *testing.T) {
+		os.Setenv("OUTBOX_TEST_WEBHOOK_URL", "/events")
+		defer os.Unsetenv("OUTBOX_TEST_WEBHOOK_URL")
+
+		_, err := conf.WebhookURL()
+		assert.EqualError(t, err, "OUTBOX_TEST_WEBHOOK_URL must be an absolute http or https URL")
+	})
+
+	t.Run("should reject invalid batch sizes", func(t *testing.T) {
+		os.Setenv("OUTBOX_TEST_BATCH_SIZE", "0")
+		defer os.Unsetenv("OUTBOX_TEST_BATCH_SIZE")
+
+		_, err := conf.BatchSize()
+		assert.EqualError(t, err, "OUTBOX_TEST_BATCH_SIZE must be a positive integer value")
+	})
+
+	t.Run("should reject invalid backoffs", func(t *testing.T) {
+		os.Setenv("OUTBOX_TEST_BACKOFF", "-1s")
+		defer os.Unsetenv("OUTBOX_TEST_BACKOFF")
+
+		_, err := conf.Backoff()
+		assert.EqualError(t, err, "OUTBOX_TEST_BACKOFF must be a positive duration")
+	})
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+type PaginationConfiguration interface {
+	DefaultLimit() (int, error)
+	MinLimit() (int, error)
+	MaxLimit() (int, error)
+	CursorSecret() (string, bool, error)
+}
+
+// PaginationConfig reads how listings are paginated from environment
+// variables sharing the given prefix (e.g. PAGINATION_MAX_LIMIT).
+type PaginationConfig struct {
+	Prefix string
+}
+
+func NewPaginationConfig(prefix string) PaginationConfig {
+	return PaginationConfig{
+		Prefix: prefix,
+	}
+}
+
+// DefaultLimit returns <PREFIX>_DEFAULT_LIMIT, the page size used when a
+// request does not ask for one, defaulting to 50.
+func (c PaginationConfig) DefaultLimit() (int, error) {
+	return c.integer("DEFAULT_LIMIT", 50)
+}
+
+// MinLimit returns <PREFIX>_MIN_LIMIT, the smallest page size a request
+// may ask for, defaulting to 1.
+func (c PaginationConfig) MinLimit() (int, error) {
+	return c.integer("MIN_LIMIT", 1)
+}
+
+// MaxLimit returns <PREFIX>_MAX_LIMIT, the largest page size a request may
+// ask for, defaulting to 100.
+func (c PaginationConfig) MaxLimit() (int, error) {
+	return c.integer("MAX_LIMIT", 100)
+}
+
+// CursorSecret returns <PREFIX>_CURSOR_SECRET, the key signing page
+// cursors, and whether it is configured. Every instance serving the API
+// must share it for cursors to be accepted by any of them.
+func (c PaginationConfig) CursorSecret() (string, bool, error) {
+	env := fmt.Sprintf("%s_CURSOR_SECRET", c.Prefix)
+
+	if !hasSecret(env) {
+		return "", false, nil
+	}
+
+	secret, err := lookupSecret(env)
+
+	if err != nil {
+		return "", false, err
+	}
+
+	if secret == "" {
+		return "", false, fmt.Errorf("%s must not be empty", env)
+	}
+
+	return secret, true, nil
+}
+
+func (c PaginationConfig) integer(suffix string, fallback int) (int, error) {
+	value, exists := os.LookupEnv(fmt.Sprintf("%s_%s", c.Prefix, suffix))
+
+	if !exists {
+		return fallback, nil
+	}
+
+	number, err := strconv.Atoi(value)
+
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("%s_%s must be a positive integer value", c.Prefix, suffix)
+	}
+
+	return number, nil
+}
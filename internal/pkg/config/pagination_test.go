@@ -0,0 +1,60 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+)
+
+func TestPaginationConfiguration(t *testing.T) {
+	conf := config.NewPaginationConfig("PAGINATION_TEST")
+
+	t.Run("should return default settings", func(t *testing.T) {
+		limit, err := conf.DefaultLimit()
+		assert.NoError(t, err)
+		assert.Equal(t, 50, limit)
+
+		minimum, err := conf.MinLimit()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, minimum)
+
+		maximum, err := conf.MaxLimit()
+		assert.NoError(t, err)
+		assert.Equal(t, 100, maximum)
+
+		_, configured, err := conf.CursorSecret()
+		assert.NoError(t, err)
+		assert.False(t, configured)
+	})
+
+	t.Run("should return configured settings", func(t *testing.T) {
+		os.Setenv("PAGINATION_TEST_MAX_LIMIT", "500")
+		os.Setenv("PAGINATION_TEST_CURSOR_SECRET", "s3cr3t")
+		defer os.Unsetenv("PAGINATION_TEST_MAX_LIMIT")
+		defer os.Unsetenv("PAGINATION_TEST_CURSOR_SECRET")
+
+		maximum, err := conf.MaxLimit()
+		assert.NoError(t, err)
+		assert.Equal(t, 500, maximum)
+
+		secret, configured, err := conf.CursorSecret()
+		assert.NoError(t, err)
+		assert.True(t, configured)
+		assert.Equal(t, "s3cr3t", secret)
+	})
+
+	t.Run("should reject invalid settings", func(t *testing.T) {
+		os.Setenv("PAGINATION_TEST_DEFAULT_LIMIT", "0")
+		os.Setenv("PAGINATION_TEST_CURSOR_SECRET", "")
+		defer os.Unsetenv("PAGINATION_TEST_DEFAULT_LIMIT")
+		defer os.Unsetenv("PAGINATION_TEST_CURSOR_SECRET")
+
+		_, err := conf.DefaultLimit()
+		assert.EqualError(t, err, "PAGINATION_TEST_DEFAULT_LIMIT must be a positive integer value")
+
+		_, _, err = conf.CursorSecret()
+		assert.EqualError(t, err, "PAGINATION_TEST_CURSOR_SECRET must not be empty")
+	})
+}
@@ -3,8 +3,11 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type DatabaseConfiguration interface {
@@ -12,8 +15,20 @@ type DatabaseConfiguration interface {
 	Driver() string
 }
 
+// PostgresConfig reads connection settings from environment variables
+// sharing the given prefix (e.g. DB_HOST, DB_USER).
+//
+// When CredentialPrefix is set, the user and password are first looked up
+// under that prefix (e.g. DB_MIGRATION_USER), falling back to Prefix. This
+// lets privileged tasks reuse the connection settings with other credentials.
+//
+// When Endpoint is set, as "host" or "host:port" (IPv6 hosts in brackets,
+// as in "[::1]:5433"), it replaces the HOST and PORT variables, so several
+// servers can share the remaining settings.
 type PostgresConfig struct {
-	Prefix string
+	Prefix           string
+	CredentialPrefix string
+	Endpoint         string
 }
 
 func NewPostgresConfig(prefix string) PostgresConfig {
@@ -22,6 +37,20 @@ func NewPostgresConfig(prefix string) PostgresConfig {
 	}
 }
 
+func NewPostgresConfigWithCredentials(prefix string, credentialPrefix string) PostgresConfig {
+	return PostgresConfig{
+		Prefix:           prefix,
+		CredentialPrefix: credentialPrefix,
+	}
+}
+
+func NewPostgresConfigWithEndpoint(prefix string, endpoint string) PostgresConfig {
+	return PostgresConfig{
+		Prefix:   prefix,
+		Endpoint: endpoint,
+	}
+}
+
 func (c PostgresConfig) Driver() string {
 	return "postgres"
 }
@@ -69,16 +98,39 @@ func (c PostgresConfig) DSN() (string, error) {
 		sslMode = "sslmode=disable"
 	}
 
-	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?%s", user, password, host, port, name, sslMode), nil
+	credentials := url.UserPassword(user, password).String()
+
+	return fmt.Sprintf("postgres://%s@%s/%s?%s", credentials, net.JoinHostPort(host, strconv.Itoa(port)), name, sslMode), nil
+}
+
+// endpoint splits Endpoint into its host and port, reporting whether it
+// has a port. A bare IPv6 address, bracketed or not, has none.
+func (c PostgresConfig) endpoint() (string, string, bool) {
+	if host, port, err := net.SplitHostPort(c.Endpoint); err == nil {
+		return host, port, true
+	}
+
+	return strings.TrimSuffix(strings.TrimPrefix(c.Endpoint, "["), "]"), "", false
 }
 
 func (c PostgresConfig) Host() (string, error) {
+	if c.Endpoint != "" {
+		host, _, _ := c.endpoint()
+		return host, nil
+	}
+
 	return c.get("HOST")
 }
 
 func (c PostgresConfig) Port() (int, error) {
 	env, err := c.get("PORT")
 
+	if c.Endpoint != "" {
+		if _, port, found := c.endpoint(); found {
+			env, err = port, nil
+		}
+	}
+
 	if err != nil {
 		return 0, err
 	}
@@ -93,11 +145,24 @@ func (c PostgresConfig) Port() (int, error) {
 }
 
 func (c PostgresConfig) User() (string, error) {
+	if c.CredentialPrefix != "" {
+		if value, exists := os.LookupEnv(c.CredentialPrefix + "_USER"); exists {
+			return value, nil
+		}
+	}
+
 	return c.get("USER")
 }
 
+// Password returns the database password, read from <PREFIX>_PASSWORD_FILE
+// when set and falling back to <PREFIX>_PASSWORD. The credential prefix, if
+// any, is tried first.
 func (c PostgresConfig) Password() (string, error) {
-	return c.get("PASSWORD")
+	if c.CredentialPrefix != "" && hasSecret(c.CredentialPrefix+"_PASSWORD") {
+		return lookupSecret(c.CredentialPrefix + "_PASSWORD")
+	}
+
+	return lookupSecret(fmt.Sprintf("%s_%s", c.Prefix, "PASSWORD"))
 }
 
 func (c PostgresConfig) Name() (string, error) {
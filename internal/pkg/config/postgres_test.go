@@ -2,6 +2,7 @@ package config_test
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -43,6 +44,46 @@ func TestPostgresConfiguration(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("should return database password from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "password")
+		os.WriteFile(path, []byte("secret\n"), 0600)
+
+		os.Setenv("DB_TEST_PASSWORD", "password")
+		os.Setenv("DB_TEST_PASSWORD_FILE", path)
+		defer os.Unsetenv("DB_TEST_PASSWORD")
+		defer os.Unsetenv("DB_TEST_PASSWORD_FILE")
+
+		password, err := conf.Password()
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", password)
+	})
+
+	t.Run("should reload database password from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "password")
+		os.WriteFile(path, []byte("secret"), 0600)
+
+		os.Setenv("DB_TEST_PASSWORD_FILE", path)
+		defer os.Unsetenv("DB_TEST_PASSWORD_FILE")
+
+		password, err := conf.Password()
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", password)
+
+		os.WriteFile(path, []byte("rotated"), 0600)
+
+		password, err = conf.Password()
+		assert.NoError(t, err)
+		assert.Equal(t, "rotated", password)
+	})
+
+	t.Run("should return error when password file does not exist", func(t *testing.T) {
+		os.Setenv("DB_TEST_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+		defer os.Unsetenv("DB_TEST_PASSWORD_FILE")
+
+		_, err := conf.Password()
+		assert.Error(t, err)
+	})
+
 	t.Run("should return database user", func(t *testing.T) {
 		os.Setenv("DB_TEST_USER", "user")
 		defer os.Unsetenv("DB_TEST_USER")
@@ -115,3 +156,68 @@ func TestPostgresConfiguration(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestPostgresConfigurationWithCredentials(t *testing.T) {
+	conf := config.NewPostgresConfigWithCredentials("DB_TEST", "DB_TEST_MIGRATION")
+
+	t.Run("should prefer credentials from the credential prefix", func(t *testing.T) {
+		os.Setenv("DB_TEST_USER", "app")
+		os.Setenv("DB_TEST_PASSWORD", "app-password")
+		os.Setenv("DB_TEST_MIGRATION_USER", "admin")
+		os.Setenv("DB_TEST_MIGRATION_PASSWORD", "admin-password")
+		defer os.Unsetenv("DB_TEST_USER")
+		defer os.Unsetenv("DB_TEST_PASSWORD")
+		defer os.Unsetenv("DB_TEST_MIGRATION_USER")
+		defer os.Unsetenv("DB_TEST_MIGRATION_PASSWORD")
+
+		user, err := conf.User()
+		assert.NoError(t, err)
+		assert.Equal(t, "admin", user)
+
+		password, err := conf.Password()
+		assert.NoError(t, err)
+		assert.Equal(t, "admin-password", password)
+	})
+
+	t.Run("should read credential prefix password from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "password")
+		assert.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0o600))
+
+		os.Setenv("DB_TEST_MIGRATION_PASSWORD_FILE", path)
+		defer os.Unsetenv("DB_TEST_MIGRATION_PASSWORD_FILE")
+
+		password, err := conf.Password()
+		assert.NoError(t, err)
+		assert.Equal(t, "from-file", password)
+	})
+
+	t.Run("should fall back to the connection prefix", func(t *testing.T) {
+		os.Setenv("DB_TEST_USER", "app")
+		os.Setenv("DB_TEST_PASSWORD", "app-password")
+		defer os.Unsetenv("DB_TEST_USER")
+		defer os.Unsetenv("DB_TEST_PASSWORD")
+
+		user, err := conf.User()
+		assert.NoError(t, err)
+		assert.Equal(t, "app", user)
+
+		password, err := conf.Password()
+		assert.NoError(t, err)
+		assert.Equal(t, "app-password", password)
+	})
+
+	t.Run("should build dsn for an ipv6 endpoint", func(t *testing.T) {
+		os.Setenv("DB_TEST_USER", "app")
+		os.Setenv("DB_TEST_PASSWORD", "secret")
+		os.Setenv("DB_TEST_NAME", "tiny_url")
+		os.Setenv("DB_TEST_TLS_MODE", "false")
+		defer os.Unsetenv("DB_TEST_USER")
+		defer os.Unsetenv("DB_TEST_PASSWORD")
+		defer os.Unsetenv("DB_TEST_NAME")
+		defer os.Unsetenv("DB_TEST_TLS_MODE")
+
+		dsn, err := config.NewPostgresConfigWithEndpoint("DB_TEST", "[::1]:5433").DSN()
+		assert.NoError(t, err)
+		assert.Equal(t, "postgres://app:secret@[::1]:5433/tiny_url?sslmode=disable", dsn)
+	})
+}
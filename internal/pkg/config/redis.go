@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 )
@@ -32,8 +33,14 @@ func (c RedisConfig) DSN() (string, error) {
 		return "", err
 	}
 
-	if password, err := c.Password(); err == nil {
-		return fmt.Sprintf("redis://:%s@%s:%d/%d", password, host, port, name), nil
+	if hasSecret("CACHE_PASSWORD") {
+		password, err := c.Password()
+
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("redis://%s@%s:%d/%d", url.UserPassword("", password), host, port, name), nil
 	}
 
 	return fmt.Sprintf("redis://%s:%d/%d", host, port, name), nil
@@ -59,8 +66,10 @@ func (c RedisConfig) Port() (int, error) {
 	return port, nil
 }
 
+// Password returns the cache password, read from CACHE_PASSWORD_FILE when
+// set and falling back to CACHE_PASSWORD.
 func (c RedisConfig) Password() (string, error) {
-	return c.get("CACHE_PASSWORD")
+	return lookupSecret("CACHE_PASSWORD")
 }
 
 func (c RedisConfig) Name() (int, error) {
@@ -0,0 +1,72 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+)
+
+func TestRedisConfiguration(t *testing.T) {
+	conf := config.RedisConfig{}
+
+	t.Run("should return cache password", func(t *testing.T) {
+		os.Setenv("CACHE_PASSWORD", "password")
+		defer os.Unsetenv("CACHE_PASSWORD")
+
+		password, err := conf.Password()
+		assert.NoError(t, err)
+		assert.Equal(t, "password", password)
+	})
+
+	t.Run("should return cache password from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "password")
+		os.WriteFile(path, []byte("secret\n"), 0600)
+
+		os.Setenv("CACHE_PASSWORD_FILE", path)
+		defer os.Unsetenv("CACHE_PASSWORD_FILE")
+
+		password, err := conf.Password()
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", password)
+	})
+
+	t.Run("should return error when password is not set", func(t *testing.T) {
+		_, err := conf.Password()
+		assert.Error(t, err)
+	})
+
+	t.Run("should return dsn with password from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "password")
+		os.WriteFile(path, []byte("p@ss"), 0600)
+
+		os.Setenv("CACHE_HOST", "host")
+		os.Setenv("CACHE_PORT", "6379")
+		os.Setenv("CACHE_NAME", "0")
+		os.Setenv("CACHE_PASSWORD_FILE", path)
+		defer os.Unsetenv("CACHE_HOST")
+		defer os.Unsetenv("CACHE_PORT")
+		defer os.Unsetenv("CACHE_NAME")
+		defer os.Unsetenv("CACHE_PASSWORD_FILE")
+
+		dsn, err := conf.DSN()
+		assert.NoError(t, err)
+		assert.Equal(t, "redis://:p%40ss@host:6379/0", dsn)
+	})
+	t.Run("should return dsn error when password file is unreadable", func(t *testing.T) {
+		os.Setenv("CACHE_HOST", "host")
+		os.Setenv("CACHE_PORT", "6379")
+		os.Setenv("CACHE_NAME", "0")
+		os.Setenv("CACHE_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+		defer os.Unsetenv("CACHE_HOST")
+		defer os.Unsetenv("CACHE_PORT")
+		defer os.Unsetenv("CACHE_NAME")
+		defer os.Unsetenv("CACHE_PASSWORD_FILE")
+
+		dsn, err := conf.DSN()
+		assert.Error(t, err)
+		assert.Empty(t, dsn)
+	})
+}
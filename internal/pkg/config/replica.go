@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+type ReplicationConfiguration interface {
+	MaxLag() (time.Duration, error)
+	CheckInterval() (time.Duration, error)
+	Balance() (string, error)
+}
+
+// ReplicationConfig reads how read replicas are monitored from environment
+// variables sharing the given prefix (e.g. DB_REPLICA_MAX_LAG). Durations
+// use Go duration syntax such as "500ms" or "10s".
+type ReplicationConfig struct {
+	Prefix string
+}
+
+func NewReplicationConfig(prefix string) ReplicationConfig {
+	return ReplicationConfig{
+		Prefix: prefix,
+	}
+}
+
+// MaxLag returns <PREFIX>_MAX_LAG, how far behind the primary a replica may
+// fall before it is taken out of rotation, defaulting to 10 seconds.
+func (c ReplicationConfig) MaxLag() (time.Duration, error) {
+	return c.duration("MAX_LAG", 10*time.Second)
+}
+
+// CheckInterval returns <PREFIX>_CHECK_INTERVAL, how often replica lag is
+// measured, defaulting to 5 seconds.
+func (c ReplicationConfig) CheckInterval() (time.Duration, error) {
+	return c.duration("CHECK_INTERVAL", 5*time.Second)
+}
+
+// Balance returns <PREFIX>_BALANCE, how reads are spread across replicas:
+// "round_robin" (the default) or "least_connections".
+func (c ReplicationConfig) Balance() (string, error) {
+	value, exists := os.LookupEnv(fmt.Sprintf("%s_BALANCE", c.Prefix))
+
+	if !exists {
+		return "round_robin", nil
+	}
+
+	switch balance := strings.ToLower(value); balance {
+	case "round_robin", "least_connections":
+		return balance, nil
+	}
+
+	return "", fmt.Errorf("%s_BALANCE must be round_robin or least_connections", c.Prefix)
+}
+
+func (c ReplicationConfig) duration(suffix string, fallback time.Duration) (time.Duration, error) {
+	value, exists := os.LookupEnv(fmt.Sprintf("%s_%s", c.Prefix, suffix))
+
+	if !exists {
+		return fallback, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("%s_%s must be a positive duration", c.Prefix, suffix)
+	}
+
+	return duration, nil
+}
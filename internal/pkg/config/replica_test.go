@@ -0,0 +1,114 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+)
+
+func TestReplicationConfiguration(t *testing.T) {
+	conf := config.NewReplicationConfig("REPLICA_TEST")
+
+	t.Run("should return default settings", func(t *testing.T) {
+		maxLag, err := conf.MaxLag()
+		assert.NoError(t, err)
+		assert.Equal(t, 10*time.Second, maxLag)
+
+		interval, err := conf.CheckInterval()
+		assert.NoError(t, err)
+		assert.Equal(t, 5*time.Second, interval)
+	})
+
+	t.Run("should return replica max lag", func(t *testing.T) {
+		os.Setenv("REPLICA_TEST_MAX_LAG", "750ms")
+		defer os.Unsetenv("REPLICA_TEST_MAX_LAG")
+
+		maxLag, err := conf.MaxLag()
+		assert.NoError(t, err)
+		assert.Equal(t, 750*time.Millisecond, maxLag)
+	})
+
+	t.Run("should reject invalid durations", func(t *testing.T) {
+		os.Setenv("REPLICA_TEST_CHECK_INTERVAL", "0s")
+		defer os.Unsetenv("REPLICA_TEST_CHECK_INTERVAL")
+
+		_, err := conf.CheckInterval()
+		assert.EqualError(t, err, "REPLICA_TEST_CHECK_INTERVAL must be a positive duration")
+	})
+
+	t.Run("should return default balance", func(t *testing.T) {
+		balance, err := conf.Balance()
+		assert.NoError(t, err)
+		assert.Equal(t, "round_robin", balance)
+	})
+
+	t.Run("should return replica balance", func(t *testing.T) {
+		os.Setenv("REPLICA_TEST_BALANCE", "least_connections")
+		defer os.Unsetenv("REPLICA_TEST_BALANCE")
+
+		balance, err := conf.Balance()
+		assert.NoError(t, err)
+		assert.Equal(t, "least_connections", balance)
+	})
+
+	t.Run("should reject unknown balance", func(t *testing.T) {
+		os.Setenv("REPLICA_TEST_BALANCE", "random")
+		defer os.Unsetenv("REPLICA_TEST_BALANCE")
+
+		_, err := conf.Balance()
+		assert.EqualError(t, err, "REPLICA_TEST_BALANCE must be round_robin or least_connections")
+	})
+}
+
+func TestReplicaDatabases(t *testing.T) {
+	conf := config.NewConfiguration()
+
+	t.Run("should default to a single replica", func(t *testing.T) {
+		os.Setenv("DB_REPLICA_HOST", "replica")
+		defer os.Unsetenv("DB_REPLICA_HOST")
+
+		replicas := conf.ReplicaDatabases()
+		assert.Len(t, replicas, 1)
+
+		host, err := replicas[0].(config.PostgresConfig).Host()
+		assert.NoError(t, err)
+		assert.Equal(t, "replica", host)
+	})
+
+	t.Run("should list replicas from DB_REPLICA_HOSTS", func(t *testing.T) {
+		os.Setenv("DB_REPLICA_HOSTS", "replica-a:5433, replica-b, [::1]:5434, [fd00::2]")
+		os.Setenv("DB_REPLICA_PORT", "5432")
+		defer os.Unsetenv("DB_REPLICA_HOSTS")
+		defer os.Unsetenv("DB_REPLICA_PORT")
+
+		replicas := conf.ReplicaDatabases()
+		assert.Len(t, replicas, 4)
+
+		first := replicas[0].(config.PostgresConfig)
+		host, _ := first.Host()
+		port, _ := first.Port()
+		assert.Equal(t, "replica-a", host)
+		assert.Equal(t, 5433, port)
+
+		second := replicas[1].(config.PostgresConfig)
+		host, _ = second.Host()
+		port, _ = second.Port()
+		assert.Equal(t, "replica-b", host)
+		assert.Equal(t, 5432, port)
+
+		third := replicas[2].(config.PostgresConfig)
+		host, _ = third.Host()
+		port, _ = third.Port()
+		assert.Equal(t, "::1", host)
+		assert.Equal(t, 5434, port)
+
+		fourth := replicas[3].(config.PostgresConfig)
+		host, _ = fourth.Host()
+		port, _ = fourth.Port()
+		assert.Equal(t, "fd00::2", host)
+		assert.Equal(t, 5432, port)
+	})
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// lookupSecret resolves a credential from the environment.
+//
+// When a <env>_FILE variable is set, the value is read from the referenced
+// file, matching the convention used by Docker and Kubernetes secret mounts.
+// The file is read on every call so rotated secrets are picked up without a
+// restart. Otherwise the plain <env> variable is used.
+func lookupSecret(env string) (string, error) {
+	if path, exists := os.LookupEnv(env + "_FILE"); exists {
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			return "", fmt.Errorf("error reading secret file from %s_FILE: %w", env, err)
+		}
+
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	if value, exists := os.LookupEnv(env); exists {
+		return value, nil
+	}
+
+	return "", fmt.Errorf("Missing required environment variable %s", env)
+}
+
+// hasSecret reports whether the secret is configured, either directly or
+// through a <env>_FILE variable.
+func hasSecret(env string) bool {
+	_, direct := os.LookupEnv(env)
+	_, file := os.LookupEnv(env + "_FILE")
+
+	return direct || file
+}
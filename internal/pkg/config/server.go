@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+type ServerConfiguration interface {
+	Addr() string
+	ReadTimeout() (time.Duration, error)
+	ReadHeaderTimeout() (time.Duration, error)
+	WriteTimeout() (time.Duration, error)
+	IdleTimeout() (time.Duration, error)
+	MaxHeaderBytes() (int, error)
+	ShutdownTimeout() (time.Duration, error)
+	TLSMode() (bool, error)
+	TLSCertFile() (string, error)
+	TLSKeyFile() (string, error)
+}
+
+// ServerConfig reads HTTP listener settings from environment variables
+// sharing the given prefix (e.g. SERVER_ADDR, SERVER_READ_TIMEOUT).
+//
+// Every setting is optional and falls back to a safe default; durations
+// use Go duration syntax such as "5s" or "1m30s".
+type ServerConfig struct {
+	Prefix              string
+	DefaultAddr         string
+	DefaultWriteTimeout time.Duration
+}
+
+func NewServerConfig(prefix string, defaultAddr string) ServerConfig {
+	return ServerConfig{
+		Prefix:              prefix,
+		DefaultAddr:         defaultAddr,
+		DefaultWriteTimeout: 10 * time.Second,
+	}
+}
+
+// NewAdminServerConfig configures the admin listener. Its responses have
+// no write timeout by default, since profiles and traces stream for as
+// many seconds as requested.
+func NewAdminServerConfig(prefix string, defaultAddr string) ServerConfig {
+	return ServerConfig{
+		Prefix:      prefix,
+		DefaultAddr: defaultAddr,
+	}
+}
+
+func (c ServerConfig) Addr() string {
+	if value, exists := c.lookup("ADDR"); exists {
+		return value
+	}
+
+	return c.DefaultAddr
+}
+
+func (c ServerConfig) ReadTimeout() (time.Duration, error) {
+	return c.duration("READ_TIMEOUT", 5*time.Second)
+}
+
+func (c ServerConfig) ReadHeaderTimeout() (time.Duration, error) {
+	return c.duration("READ_HEADER_TIMEOUT", 2*time.Second)
+}
+
+// WriteTimeout returns <PREFIX>_WRITE_TIMEOUT, where zero disables the
+// timeout.
+func (c ServerConfig) WriteTimeout() (time.Duration, error) {
+	return c.duration("WRITE_TIMEOUT", c.DefaultWriteTimeout)
+}
+
+func (c ServerConfig) IdleTimeout() (time.Duration, error) {
+	return c.duration("IDLE_TIMEOUT", 60*time.Second)
+}
+
+func (c ServerConfig) ShutdownTimeout() (time.Duration, error) {
+	return c.duration("SHUTDOWN_TIMEOUT", 5*time.Second)
+}
+
+func (c ServerConfig) MaxHeaderBytes() (int, error) {
+	value, exists := c.lookup("MAX_HEADER_BYTES")
+
+	if !exists {
+		return http.DefaultMaxHeaderBytes, nil
+	}
+
+	size, err := strconv.Atoi(value)
+
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("%s_MAX_HEADER_BYTES must be a positive interger value", c.Prefix)
+	}
+
+	return size, nil
+}
+
+func (c ServerConfig) TLSMode() (bool, error) {
+	value, exists := c.lookup("TLS_MODE")
+
+	if !exists {
+		return false, nil
+	}
+
+	tlsMode, err := strconv.ParseBool(value)
+
+	if err != nil {
+		return false, fmt.Errorf("%s_TLS_MODE must be a boolean value", c.Prefix)
+	}
+
+	return tlsMode, nil
+}
+
+func (c ServerConfig) TLSCertFile() (string, error) {
+	return c.get("TLS_CERT_FILE")
+}
+
+func (c ServerConfig) TLSKeyFile() (string, error) {
+	return c.get("TLS_KEY_FILE")
+}
+
+func (c ServerConfig) duration(suffix string, fallback time.Duration) (time.Duration, error) {
+	value, exists := c.lookup(suffix)
+
+	if !exists {
+		return fallback, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("%s_%s must be a valid duration", c.Prefix, suffix)
+	}
+
+	return duration, nil
+}
+
+func (c ServerConfig) lookup(suffix string) (string, bool) {
+	return os.LookupEnv(fmt.Sprintf("%s_%s", c.Prefix, suffix))
+}
+
+func (c ServerConfig) get(suffix string) (string, error) {
+	if value, exists := c.lookup(suffix); exists {
+		return value, nil
+	}
+
+	return "", fmt.Errorf("Missing required environment variable %s_%s", c.Prefix, suffix)
+}
@@ -0,0 +1,121 @@
+package config_test
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+)
+
+func TestServerConfiguration(t *testing.T) {
+	conf := config.NewServerConfig("SERVER_TEST", ":8080")
+
+	t.Run("should return default address", func(t *testing.T) {
+		assert.Equal(t, ":8080", conf.Addr())
+	})
+
+	t.Run("should return server address", func(t *testing.T) {
+		os.Setenv("SERVER_TEST_ADDR", ":9999")
+		defer os.Unsetenv("SERVER_TEST_ADDR")
+
+		assert.Equal(t, ":9999", conf.Addr())
+	})
+
+	t.Run("should return default timeouts", func(t *testing.T) {
+		read, err := conf.ReadTimeout()
+		assert.NoError(t, err)
+		assert.Equal(t, 5*time.Second, read)
+
+		header, err := conf.ReadHeaderTimeout()
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Second, header)
+
+		write, err := conf.WriteTimeout()
+		assert.NoError(t, err)
+		assert.Equal(t, 10*time.Second, write)
+
+		idle, err := conf.IdleTimeout()
+		assert.NoError(t, err)
+		assert.Equal(t, 60*time.Second, idle)
+
+		shutdown, err := conf.ShutdownTimeout()
+		assert.NoError(t, err)
+		assert.Equal(t, 5*time.Second, shutdown)
+	})
+
+	t.Run("should not time out admin responses by default", func(t *testing.T) {
+		write, err := config.NewAdminServerConfig("ADMIN_TEST", ":9090").WriteTimeout()
+		assert.NoError(t, err)
+		assert.Zero(t, write)
+
+		os.Setenv("ADMIN_TEST_WRITE_TIMEOUT", "2m")
+		defer os.Unsetenv("ADMIN_TEST_WRITE_TIMEOUT")
+
+		write, err = config.NewAdminServerConfig("ADMIN_TEST", ":9090").WriteTimeout()
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Minute, write)
+	})
+
+	t.Run("should return server read timeout", func(t *testing.T) {
+		os.Setenv("SERVER_TEST_READ_TIMEOUT", "1m30s")
+		defer os.Unsetenv("SERVER_TEST_READ_TIMEOUT")
+
+		read, err := conf.ReadTimeout()
+		assert.NoError(t, err)
+		assert.Equal(t, 90*time.Second, read)
+	})
+
+	t.Run("should return error when timeout is not a duration", func(t *testing.T) {
+		os.Setenv("SERVER_TEST_SHUTDOWN_TIMEOUT", "later")
+		defer os.Unsetenv("SERVER_TEST_SHUTDOWN_TIMEOUT")
+
+		_, err := conf.ShutdownTimeout()
+		assert.Error(t, err)
+	})
+
+	t.Run("should return default max header bytes", func(t *testing.T) {
+		size, err := conf.MaxHeaderBytes()
+		assert.NoError(t, err)
+		assert.Equal(t, http.DefaultMaxHeaderBytes, size)
+	})
+
+	t.Run("should return error when max header bytes is not a positive integer", func(t *testing.T) {
+		os.Setenv("SERVER_TEST_MAX_HEADER_BYTES", "-1")
+		defer os.Unsetenv("SERVER_TEST_MAX_HEADER_BYTES")
+
+		_, err := conf.MaxHeaderBytes()
+		assert.Error(t, err)
+	})
+
+	t.Run("should disable tls by default", func(t *testing.T) {
+		isTLSMode, err := conf.TLSMode()
+		assert.NoError(t, err)
+		assert.False(t, isTLSMode)
+	})
+
+	t.Run("should return tls files", func(t *testing.T) {
+		os.Setenv("SERVER_TEST_TLS_CERT_FILE", "cert.pem")
+		os.Setenv("SERVER_TEST_TLS_KEY_FILE", "key.pem")
+		defer os.Unsetenv("SERVER_TEST_TLS_CERT_FILE")
+		defer os.Unsetenv("SERVER_TEST_TLS_KEY_FILE")
+
+		cert, err := conf.TLSCertFile()
+		assert.NoError(t, err)
+		assert.Equal(t, "cert.pem", cert)
+
+		key, err := conf.TLSKeyFile()
+		assert.NoError(t, err)
+		assert.Equal(t, "key.pem", key)
+	})
+
+	t.Run("should return error when tls files are not set", func(t *testing.T) {
+		_, err := conf.TLSCertFile()
+		assert.Error(t, err)
+
+		_, err = conf.TLSKeyFile()
+		assert.Error(t, err)
+	})
+}
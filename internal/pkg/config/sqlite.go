@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// SQLiteConfig reads the embedded database settings from environment
+// variables sharing the given prefix. It is meant for local development and
+// tests, where running Postgres is not worth the setup.
+type SQLiteConfig struct {
+	Prefix string
+}
+
+func NewSQLiteConfig(prefix string) SQLiteConfig {
+	return SQLiteConfig{
+		Prefix: prefix,
+	}
+}
+
+func (c SQLiteConfig) Driver() string {
+	return "sqlite"
+}
+
+// DSN returns the database file location with the pragmas the service
+// relies on. The file is created on first use.
+func (c SQLiteConfig) DSN() (string, error) {
+	return fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)", c.Path()), nil
+}
+
+// Path returns <PREFIX>_PATH, defaulting to tiny_url.db in the working
+// directory. Use ":memory:" for a throwaway database.
+func (c SQLiteConfig) Path() string {
+	if value, exists := os.LookupEnv(c.Prefix + "_PATH"); exists {
+		return value
+	}
+
+	return "tiny_url.db"
+}
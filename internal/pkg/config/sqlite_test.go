@@ -0,0 +1,51 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+)
+
+func TestSQLiteConfiguration(t *testing.T) {
+	conf := config.NewSQLiteConfig("DB_TEST")
+
+	t.Run("should return database driver", func(t *testing.T) {
+		assert.Equal(t, "sqlite", conf.Driver())
+	})
+
+	t.Run("should default to a file in the working directory", func(t *testing.T) {
+		dsn, err := conf.DSN()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "tiny_url.db", conf.Path())
+		assert.Contains(t, dsn, "file:tiny_url.db?")
+	})
+
+	t.Run("should return database path", func(t *testing.T) {
+		os.Setenv("DB_TEST_PATH", ":memory:")
+		defer os.Unsetenv("DB_TEST_PATH")
+
+		assert.Equal(t, ":memory:", conf.Path())
+	})
+}
+
+func TestDatabaseDriverSelection(t *testing.T) {
+	conf := config.NewConfiguration()
+
+	t.Run("should default to postgres", func(t *testing.T) {
+		assert.Equal(t, "postgres", conf.PrimaryDatabase().Driver())
+		assert.Equal(t, "postgres", conf.ReplicaDatabases()[0].Driver())
+		assert.Equal(t, "postgres", conf.MigrationDatabase().Driver())
+	})
+
+	t.Run("should select sqlite through DB_DRIVER", func(t *testing.T) {
+		os.Setenv("DB_DRIVER", "sqlite")
+		defer os.Unsetenv("DB_DRIVER")
+
+		assert.Equal(t, "sqlite", conf.PrimaryDatabase().Driver())
+		assert.Equal(t, "sqlite", conf.ReplicaDatabases()[0].Driver())
+		assert.Equal(t, "sqlite", conf.MigrationDatabase().Driver())
+	})
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+type WebhookConfiguration interface {
+	PollInterval() (time.Duration, error)
+	BatchSize() (int, error)
+	Timeout() (time.Duration, error)
+	MaxAttempts() (int, error)
+	Backoff() (time.Duration, error)
+	MaxBackoff() (time.Duration, error)
+}
+
+// WebhookConfig reads how webhook deliveries are attempted from
+// environment variables sharing the given prefix (e.g. WEBHOOK_TIMEOUT).
+type WebhookConfig struct {
+	Prefix string
+}
+
+func NewWebhookConfig(prefix string) WebhookConfig {
+	return WebhookConfig{
+		Prefix: prefix,
+	}
+}
+
+// PollInterval returns <PREFIX>_POLL_INTERVAL, how often due deliveries are
+// looked up, defaulting to 1 second.
+func (c WebhookConfig) PollInterval() (time.Duration, error) {
+	return c.duration("POLL_INTERVAL", 1*time.Second)
+}
+
+// BatchSize returns <PREFIX>_BATCH_SIZE, how many deliveries are claimed
+// at once, defaulting to 20.
+func (c WebhookConfig) BatchSize() (int, error) {
+	return c.integer("BATCH_SIZE", 20)
+}
+
+// Timeout returns <PREFIX>_TIMEOUT, how long an endpoint has to answer a
+// delivery, defaulting to 5 seconds.
+func (c WebhookConfig) Timeout() (time.Duration, error) {
+	return c.duration("TIMEOUT", 5*time.Second)
+}
+
+// MaxAttempts returns <PREFIX>_MAX_ATTEMPTS, how many times a delivery is
+// attempted before it moves to the dead-letter list, defaulting to 8.
+func (c WebhookConfig) MaxAttempts() (int, error) {
+	return c.integer("MAX_ATTEMPTS", 8)
+}
+
+// Backoff returns <PREFIX>_BACKOFF, the wait after the first failed
+// attempt, defaulting to 30 seconds. It doubles after every failure.
+func (c WebhookConfig) Backoff() (time.Duration, error) {
+	return c.duration("BACKOFF", 30*time.Second)
+}
+
+// MaxBackoff returns <PREFIX>_MAX_BACKOFF, the longest wait between two
+// attempts, defaulting to 1 hour.
+func (c WebhookConfig) MaxBackoff() (time.Duration, error) {
+	return c.duration("MAX_BACKOFF", 1*time.Hour)
+}
+
+func (c WebhookConfig) duration(suffix string, fallback time.Duration) (time.Duration, error) {
+	value, exists := os.LookupEnv(fmt.Sprintf("%s_%s", c.Prefix, suffix))
+
+	if !exists {
+		return fallback, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("%s_%s must be a positive duration", c.Prefix, suffix)
+	}
+
+	return duration, nil
+}
+
+func (c WebhookConfig) integer(suffix string, fallback int) (int, error) {
+	value, exists := os.LookupEnv(fmt.Sprintf("%s_%s", c.Prefix, suffix))
+
+	if !exists {
+		return fallback, nil
+	}
+
+	number, err := strconv.Atoi(value)
+
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("%s_%s must be a positive integer value", c.Prefix, suffix)
+	}
+
+	return number, nil
+}
@@ -0,0 +1,68 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+)
+
+func TestWebhookConfiguration(t *testing.T) {
+	conf := config.NewWebhookConfig("WEBHOOK_TEST")
+
+	t.Run("should return default settings", func(t *testing.T) {
+		interval, err := conf.PollInterval()
+		assert.NoError(t, err)
+		assert.Equal(t, 1*time.Second, interval)
+
+		size, err := conf.BatchSize()
+		assert.NoError(t, err)
+		assert.Equal(t, 20, size)
+
+		timeout, err := conf.Timeout()
+		assert.NoError(t, err)
+		assert.Equal(t, 5*time.Second, timeout)
+
+		attempts, err := conf.MaxAttempts()
+		assert.NoError(t, err)
+		assert.Equal(t, 8, attempts)
+
+		backoff, err := conf.Backoff()
+		assert.NoError(t, err)
+		assert.Equal(t, 30*time.Second, backoff)
+
+		maxBackoff, err := conf.MaxBackoff()
+		assert.NoError(t, err)
+		assert.Equal(t, 1*time.Hour, maxBackoff)
+	})
+
+	t.Run("should return configured settings", func(t *testing.T) {
+		os.Setenv("WEBHOOK_TEST_MAX_ATTEMPTS", "3")
+		os.Setenv("WEBHOOK_TEST_BACKOFF", "2s")
+		defer os.Unsetenv("WEBHOOK_TEST_MAX_ATTEMPTS")
+		defer os.Unsetenv("WEBHOOK_TEST_BACKOFF")
+
+		attempts, err := conf.MaxAttempts()
+		assert.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+
+		backoff, err := conf.Backoff()
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Second, backoff)
+	})
+
+	t.Run("should reject invalid settings", func(t *testing.T) {
+		os.Setenv("WEBHOOK_TEST_MAX_ATTEMPTS", "0")
+		os.Setenv("WEBHOOK_TEST_TIMEOUT", "soon")
+		defer os.Unsetenv("WEBHOOK_TEST_MAX_ATTEMPTS")
+		defer os.Unsetenv("WEBHOOK_TEST_TIMEOUT")
+
+		_, err := conf.MaxAttempts()
+		assert.EqualError(t, err, "WEBHOOK_TEST_MAX_ATTEMPTS must be a positive integer value")
+
+		_, err = conf.Timeout()
+		assert.EqualError(t, err, "WEBHOOK_TEST_TIMEOUT must be a positive duration")
+	})
+}
@@ -1,30 +1,41 @@
 package observability
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 
 	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/otel/attribute"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func NewInstrumentedDB(observer Observer, driver string, dsn string) (*sql.DB, error) {
-	db, err := otelsql.Open(
-		driver,
-		dsn,
+// TxOutcome is how a database transaction or savepoint ended.
+type TxOutcome string
+
+const (
+	TxCommitted  TxOutcome = "committed"
+	TxRolledBack TxOutcome = "rolled_back"
+)
+
+// NewInstrumentedDB opens a traced database pool and registers its
+// connection stats. system identifies the backend, e.g. "postgresql".
+func NewInstrumentedDB(observer Observer, system string, connector driver.Connector) (*sql.DB, error) {
+	systemAttribute := semconv.DBSystemKey.String(system)
+
+	db := otelsql.OpenDB(
+		connector,
 		otelsql.WithAttributes(
-			semconv.DBSystemPostgreSQL,
+			systemAttribute,
 			semconv.DBName("tiny_url"),
 		),
 	)
 
-	if err != nil {
-		return nil, err
-	}
-
 	reg, err := otelsql.RegisterDBStatsMetrics(
 		db,
 		otelsql.WithAttributes(
-			semconv.DBSystemPostgreSQL,
+			systemAttribute,
 		),
 	)
 
@@ -35,3 +46,19 @@ func NewInstrumentedDB(observer Observer, driver string, dsn string) (*sql.DB, e
 	observer.RegisterDB(reg)
 	return db, nil
 }
+
+// TraceTransaction records how a transaction, or a savepoint when scope is
+// "savepoint", ended as an event on the active span, along with the number
+// of attempts it took and the error that ended it.
+func TraceTransaction(ctx context.Context, scope string, outcome TxOutcome, attempts int, err error) {
+	span := trace.SpanFromContext(ctx)
+
+	span.AddEvent("db."+scope, trace.WithAttributes(
+		attribute.String("db.transaction.outcome", string(outcome)),
+		attribute.Int("db.transaction.attempts", attempts),
+	))
+
+	if err != nil {
+		span.RecordError(err)
+	}
+}
@@ -0,0 +1,163 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// newLocalExporters builds exporters that do not depend on a collector.
+//
+// "stdout" writes traces and metrics as JSON to the process output,
+// "file" appends them to the path given by MetricConfiguration.File(), and
+// "none" discards them. The returned file, when not nil, must be closed on
+// shutdown.
+func newLocalExporters(mode string, conf config.MetricConfiguration) (trace.SpanExporter, sdkmetric.Exporter, *os.File, error) {
+	var file *os.File
+	var writer io.Writer
+
+	switch mode {
+	case "none":
+		return nopSpanExporter{}, nopMetricExporter{}, nil, nil
+	case "stdout":
+		writer = os.Stdout
+	case "file":
+		path, err := conf.File()
+
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		if file, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644); err != nil {
+			return nil, nil, nil, err
+		}
+
+		writer = file
+	default:
+		return nil, nil, nil, fmt.Errorf("error unsupported telemetry exporter %q", mode)
+	}
+
+	spans, err := stdouttrace.New(stdouttrace.WithWriter(writer))
+
+	if err != nil {
+		return nil, nil, nil, errors.Join(err, closeFile(file))
+	}
+
+	metrics, err := stdoutmetric.New(stdoutmetric.WithWriter(writer))
+
+	if err != nil {
+		return nil, nil, nil, errors.Join(err, closeFile(file))
+	}
+
+	return spans, metrics, file, nil
+}
+
+func closeFile(file *os.File) error {
+	if file == nil {
+		return nil
+	}
+
+	return file.Close()
+}
+
+type nopSpanExporter struct{}
+
+func (nopSpanExporter) ExportSpans(context.Context, []trace.ReadOnlySpan) error { return nil }
+func (nopSpanExporter) Shutdown(context.Context) error                          { return nil }
+
+type nopMetricExporter struct{}
+
+func (nopMetricExporter) Temporality(k sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(k)
+}
+
+func (nopMetricExporter) Aggregation(k sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(k)
+}
+
+func (nopMetricExporter) Export(context.Context, *metricdata.ResourceMetrics) error { return nil }
+func (nopMetricExporter) ForceFlush(context.Context) error                          { return nil }
+func (nopMetricExporter) Shutdown(context.Context) error                            { return nil }
+
+// swappableSpanExporter forwards spans to an exporter that can be replaced
+// at runtime. It lets the tracer provider be installed once, exporting to
+// a fallback, and switch to the collector when it becomes reachable.
+type swappableSpanExporter struct {
+	current atomic.Pointer[spanExporterRef]
+}
+
+type spanExporterRef struct {
+	exporter trace.SpanExporter
+}
+
+func newSwappableSpanExporter(exporter trace.SpanExporter) *swappableSpanExporter {
+	e := &swappableSpanExporter{}
+	e.current.Store(&spanExporterRef{exporter: exporter})
+	return e
+}
+
+// Swap replaces the active exporter and returns the previous one, which
+// the caller is responsible for shutting down.
+func (e *swappableSpanExporter) Swap(exporter trace.SpanExporter) trace.SpanExporter {
+	return e.current.Swap(&spanExporterRef{exporter: exporter}).exporter
+}
+
+func (e *swappableSpanExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	return e.current.Load().exporter.ExportSpans(ctx, spans)
+}
+
+func (e *swappableSpanExporter) Shutdown(ctx context.Context) error {
+	return e.current.Load().exporter.Shutdown(ctx)
+}
+
+// swappableMetricExporter is the metric counterpart of
+// swappableSpanExporter.
+type swappableMetricExporter struct {
+	current atomic.Pointer[metricExporterRef]
+}
+
+type metricExporterRef struct {
+	exporter sdkmetric.Exporter
+}
+
+func newSwappableMetricExporter(exporter sdkmetric.Exporter) *swappableMetricExporter {
+	e := &swappableMetricExporter{}
+	e.current.Store(&metricExporterRef{exporter: exporter})
+	return e
+}
+
+// Swap replaces the active exporter and returns the previous one, which
+// the caller is responsible for shutting down.
+func (e *swappableMetricExporter) Swap(exporter sdkmetric.Exporter) sdkmetric.Exporter {
+	return e.current.Swap(&metricExporterRef{exporter: exporter}).exporter
+}
+
+func (e *swappableMetricExporter) Temporality(k sdkmetric.InstrumentKind) metricdata.Temporality {
+	return e.current.Load().exporter.Temporality(k)
+}
+
+func (e *swappableMetricExporter) Aggregation(k sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return e.current.Load().exporter.Aggregation(k)
+}
+
+func (e *swappableMetricExporter) Export(ctx context.Context, data *metricdata.ResourceMetrics) error {
+	return e.current.Load().exporter.Export(ctx, data)
+}
+
+func (e *swappableMetricExporter) ForceFlush(ctx context.Context) error {
+	return e.current.Load().exporter.ForceFlush(ctx)
+}
+
+func (e *swappableMetricExporter) Shutdown(ctx context.Context) error {
+	return e.current.Load().exporter.Shutdown(ctx)
+}
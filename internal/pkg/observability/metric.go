@@ -4,7 +4,69 @@ import (
 	"context"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// RedirectOutcome is the bounded set of results a redirect can have.
+type RedirectOutcome string
+
+const (
+	RedirectFound    RedirectOutcome = "found"
+	RedirectNotFound RedirectOutcome = "not_found"
+	RedirectExpired  RedirectOutcome = "expired"
+	RedirectError    RedirectOutcome = "error"
+)
+
+// ValidationReason is the bounded set of reasons a request can be rejected
+// before reaching the service layer.
+type ValidationReason string
+
+const (
+	ValidationContentType ValidationReason = "content_type"
+	ValidationAccept      ValidationReason = "accept"
+	ValidationBody        ValidationReason = "body"
+	ValidationID          ValidationReason = "id"
+	ValidationCode        ValidationReason = "code"
+	ValidationOwner       ValidationReason = "owner"
+	ValidationFilter      ValidationReason = "filter"
+	ValidationLimit       ValidationReason = "limit"
+	ValidationOrder       ValidationReason = "order"
+	ValidationCursor      ValidationReason = "cursor"
+)
+
+// CircuitState is the state of a circuit breaker guarding a dependency.
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitHalfOpen CircuitState = "half_open"
+	CircuitOpen     CircuitState = "open"
+)
+
+// WebhookOutcome is the bounded set of results a webhook delivery attempt
+// can have.
+type WebhookOutcome string
+
+const (
+	WebhookDelivered WebhookOutcome = "delivered"
+	// WebhookFailed is a failed attempt that will be retried.
+	WebhookFailed WebhookOutcome = "failed"
+	// WebhookDead is a failed attempt that moved the delivery to the
+	// dead-letter list.
+	WebhookDead WebhookOutcome = "dead"
+)
+
+// JobOutcome is the bounded set of results a background job run can have.
+type JobOutcome string
+
+const (
+	JobSucceeded JobOutcome = "succeeded"
+	JobFailed    JobOutcome = "failed"
+	// JobSkipped is a run left to another instance holding the job lease,
+	// or skipped because the lease could not be checked.
+	JobSkipped JobOutcome = "skipped"
 )
 
 // Metric defines a vendor-agnostic interface for emitting
@@ -12,6 +74,8 @@ import (
 type MetricClient interface {
 	// MemoryHit records the duration to resolve a value when it is found in memory cache.
 	// The duration includes cache lookup and value deserialization, but excludes fallback logic.
+	// Like every Memory* method, it is labelled by the cache key family (see cache.CacheKey.Family),
+	// never by the full key.
 	MemoryHit(context.Context, string, time.Duration)
 
 	// MemoryMiss records the duration to resolve a value when it is not found in memory cache.
@@ -23,8 +87,45 @@ type MetricClient interface {
 	// not be used (e.g. stale, malformed, or failed validation).
 	MemoryInvalid(context.Context, string)
 
+	// MemoryPayload records the size in bytes of a cache entry read or written.
+	MemoryPayload(context.Context, string, int)
+
+	// MemoryError records a cache backend failure for the given operation
+	// (e.g. "get", "set", "del"). Misses are not errors.
+	MemoryError(context.Context, string, string)
+
 	// CacheBypassed records that cache logic was intentionally skipped.
 	MemoryBypassed(context.Context)
+
+	// Redirect records a redirect request and its end-to-end latency,
+	// labelled by outcome. Short codes are never recorded as attributes.
+	Redirect(context.Context, RedirectOutcome, time.Duration)
+
+	// LinkCreated records a successfully created link.
+	LinkCreated(context.Context)
+
+	// ValidationRejected records a request rejected by input validation,
+	// labelled by the operation and the reason.
+	ValidationRejected(context.Context, string, ValidationReason)
+
+	// CacheCircuit records a state change of the cache circuit breaker. The
+	// current state is exported as a gauge (0 closed, 1 half-open, 2 open)
+	// alongside a counter of transitions by target state.
+	CacheCircuit(context.Context, CircuitState)
+
+	// ReplicaLag records how far behind the primary a read replica is,
+	// labelled by replica name.
+	ReplicaLag(context.Context, string, time.Duration)
+
+	// WebhookDelivery records a webhook delivery attempt and its latency,
+	// labelled by outcome. Subscription endpoints are never recorded as
+	// attributes.
+	WebhookDelivery(context.Context, WebhookOutcome, time.Duration)
+
+	// JobRun records a background job run and its duration, labelled by
+	// job name and outcome. Successful runs also record their completion
+	// time, so stalled jobs can be alerted on.
+	JobRun(context.Context, string, JobOutcome, time.Duration)
 }
 
 type OtelMetricClient struct {
@@ -34,6 +135,34 @@ type OtelMetricClient struct {
 	memoryMissLatency  metric.Float64Histogram
 	memoryInvalidCount metric.Int64Counter
 	memoryBypassCount  metric.Int64Counter
+	memoryLookupCount  metric.Int64Counter
+	memoryPayloadSize  metric.Int64Histogram
+	memoryErrorCount   metric.Int64Counter
+
+	redirectCount     metric.Int64Counter
+	redirectLatency   metric.Float64Histogram
+	linkCreatedCount  metric.Int64Counter
+	validationRejects metric.Int64Counter
+
+	cacheCircuitState       metric.Int64Gauge
+	cacheCircuitTransitions metric.Int64Counter
+
+	replicaLag metric.Float64Gauge
+
+	webhookDeliveryCount   metric.Int64Counter
+	webhookDeliveryLatency metric.Float64Histogram
+
+	jobRunCount       metric.Int64Counter
+	jobRunDuration    metric.Float64Histogram
+	jobLastSuccessful metric.Int64Gauge
+}
+
+// NewNoopMetricClient returns a MetricClient that discards every
+// measurement. It is used where metrics are optional and an instrument
+// cannot be created.
+func NewNoopMetricClient() MetricClient {
+	client, _ := NewMetricClient(noop.NewMeterProvider().Meter(serviceName))
+	return client
 }
 
 func NewMetricClient(meter metric.Meter) (*OtelMetricClient, error) {
@@ -72,27 +201,188 @@ func NewMetricClient(meter metric.Meter) (*OtelMetricClient, error) {
 		return nil, err
 	}
 
+	client.memoryLookupCount, err = meter.Int64Counter(
+		"tiny_url.memory.lookup.count",
+		metric.WithDescription("Memory lookups by key family and result, used to derive hit ratio"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.memoryPayloadSize, err = meter.Int64Histogram(
+		"tiny_url.memory.payload.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Memory entry payload size by key family"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.memoryErrorCount, err = meter.Int64Counter(
+		"tiny_url.memory.error.count",
+		metric.WithDescription("Memory backend errors by key family and operation"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.redirectCount, err = meter.Int64Counter(
+		"tiny_url.redirect.count",
+		metric.WithDescription("Redirect requests by outcome"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.redirectLatency, err = meter.Float64Histogram(
+		"tiny_url.redirect.latency",
+		metric.WithUnit("ms"),
+		metric.WithDescription("End-to-end redirect latency by outcome"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.linkCreatedCount, err = meter.Int64Counter(
+		"tiny_url.link.created.count",
+		metric.WithDescription("Links successfully created"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.validationRejects, err = meter.Int64Counter(
+		"tiny_url.validation.rejected.count",
+		metric.WithDescription("Requests rejected by input validation"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.cacheCircuitState, err = meter.Int64Gauge(
+		"tiny_url.cache.circuit.state",
+		metric.WithDescription("Cache circuit breaker state: 0 closed, 1 half-open, 2 open"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.cacheCircuitTransitions, err = meter.Int64Counter(
+		"tiny_url.cache.circuit.transition.count",
+		metric.WithDescription("Cache circuit breaker state changes by target state"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.replicaLag, err = meter.Float64Gauge(
+		"tiny_url.db.replica.lag",
+		metric.WithUnit("s"),
+		metric.WithDescription("Replication lag of each read replica"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.webhookDeliveryCount, err = meter.Int64Counter(
+		"tiny_url.webhook.delivery.count",
+		metric.WithDescription("Webhook delivery attempts by outcome"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.webhookDeliveryLatency, err = meter.Float64Histogram(
+		"tiny_url.webhook.delivery.latency",
+		metric.WithUnit("ms"),
+		metric.WithDescription("Webhook delivery attempt latency by outcome"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.jobRunCount, err = meter.Int64Counter(
+		"tiny_url.job.run.count",
+		metric.WithDescription("Background job runs by job and outcome"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.jobRunDuration, err = meter.Float64Histogram(
+		"tiny_url.job.run.duration",
+		metric.WithUnit("ms"),
+		metric.WithDescription("Background job run duration by job and outcome"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.jobLastSuccessful, err = meter.Int64Gauge(
+		"tiny_url.job.last_success",
+		metric.WithUnit("s"),
+		metric.WithDescription("Unix time of the last successful run of each background job"),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return client, nil
 }
 
-func (m *OtelMetricClient) MemoryHit(ctx context.Context, name string, d time.Duration) {
-	m.memoryHitLatency.Record(
+func (m *OtelMetricClient) MemoryHit(ctx context.Context, family string, d time.Duration) {
+	attrs := metric.WithAttributes(familyAttribute(family))
+
+	m.memoryHitLatency.Record(ctx, float64(d.Milliseconds()), attrs)
+	m.memoryLookupCount.Add(ctx, 1, metric.WithAttributes(familyAttribute(family), attribute.String("result", "hit")))
+}
+
+func (m *OtelMetricClient) MemoryMiss(ctx context.Context, family string, d time.Duration) {
+	attrs := metric.WithAttributes(familyAttribute(family))
+
+	m.memoryMissLatency.Record(ctx, float64(d.Milliseconds()), attrs)
+	m.memoryLookupCount.Add(ctx, 1, metric.WithAttributes(familyAttribute(family), attribute.String("result", "miss")))
+}
+
+func (m *OtelMetricClient) MemoryInvalid(ctx context.Context, family string) {
+	m.memoryInvalidCount.Add(
 		ctx,
-		float64(d.Milliseconds()),
+		1,
+		metric.WithAttributes(familyAttribute(family)),
 	)
 }
 
-func (m *OtelMetricClient) MemoryMiss(ctx context.Context, name string, d time.Duration) {
-	m.memoryMissLatency.Record(
+func (m *OtelMetricClient) MemoryPayload(ctx context.Context, family string, size int) {
+	m.memoryPayloadSize.Record(
 		ctx,
-		float64(d.Milliseconds()),
+		int64(size),
+		metric.WithAttributes(familyAttribute(family)),
 	)
 }
 
-func (m *OtelMetricClient) MemoryInvalid(ctx context.Context, name string) {
-	m.memoryInvalidCount.Add(
+func (m *OtelMetricClient) MemoryError(ctx context.Context, family string, operation string) {
+	m.memoryErrorCount.Add(
 		ctx,
 		1,
+		metric.WithAttributes(
+			familyAttribute(family),
+			attribute.String("operation", operation),
+		),
 	)
 }
 
@@ -102,3 +392,72 @@ func (m *OtelMetricClient) MemoryBypassed(ctx context.Context) {
 		1,
 	)
 }
+
+func (m *OtelMetricClient) Redirect(ctx context.Context, outcome RedirectOutcome, d time.Duration) {
+	attrs := metric.WithAttributes(attribute.String("outcome", string(outcome)))
+
+	m.redirectCount.Add(ctx, 1, attrs)
+	m.redirectLatency.Record(ctx, float64(d.Microseconds())/1000, attrs)
+}
+
+func (m *OtelMetricClient) LinkCreated(ctx context.Context) {
+	m.linkCreatedCount.Add(
+		ctx,
+		1,
+	)
+}
+
+func (m *OtelMetricClient) ValidationRejected(ctx context.Context, operation string, reason ValidationReason) {
+	m.validationRejects.Add(
+		ctx,
+		1,
+		metric.WithAttributes(
+			attribute.String("operation", operation),
+			attribute.String("reason", string(reason)),
+		),
+	)
+}
+
+func (m *OtelMetricClient) CacheCircuit(ctx context.Context, state CircuitState) {
+	var value int64
+
+	switch state {
+	case CircuitHalfOpen:
+		value = 1
+	case CircuitOpen:
+		value = 2
+	}
+
+	m.cacheCircuitState.Record(ctx, value)
+	m.cacheCircuitTransitions.Add(ctx, 1, metric.WithAttributes(attribute.String("state", string(state))))
+}
+
+func (m *OtelMetricClient) ReplicaLag(ctx context.Context, replica string, lag time.Duration) {
+	m.replicaLag.Record(ctx, lag.Seconds(), metric.WithAttributes(attribute.String("replica", replica)))
+}
+
+func (m *OtelMetricClient) WebhookDelivery(ctx context.Context, outcome WebhookOutcome, d time.Duration) {
+	attrs := metric.WithAttributes(attribute.String("outcome", string(outcome)))
+
+	m.webhookDeliveryCount.Add(ctx, 1, attrs)
+	m.webhookDeliveryLatency.Record(ctx, float64(d.Microseconds())/1000, attrs)
+}
+
+func (m *OtelMetricClient) JobRun(ctx context.Context, job string, outcome JobOutcome, d time.Duration) {
+	attrs := metric.WithAttributes(attribute.String("job", job), attribute.String("outcome", string(outcome)))
+
+	m.jobRunCount.Add(ctx, 1, attrs)
+	m.jobRunDuration.Record(ctx, float64(d.Microseconds())/1000, attrs)
+
+	if outcome == JobSucceeded {
+		m.jobLastSuccessful.Record(ctx, time.Now().Unix(), metric.WithAttributes(attribute.String("job", job)))
+	}
+}
+
+func familyAttribute(family string) attribute.KeyValue {
+	if family == "" {
+		family = "unknown"
+	}
+
+	return attribute.String("family", family)
+}
@@ -4,11 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/zeon-code/tiny-url/internal/pkg/config"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/metric"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
@@ -24,6 +31,8 @@ type Observer interface {
 	Startup(ctx context.Context) error
 	Shutdown(ctx context.Context) error
 	Metric() (MetricClient, error)
+	MetricHandler() http.Handler
+	TelemetryMode() string
 	RegisterDB(dbStats metric.Registration)
 }
 
@@ -33,7 +42,16 @@ type observer struct {
 
 	tracer  *trace.TracerProvider
 	metric  *sdkmetric.MeterProvider
+	scrape  http.Handler
+	files   []*os.File
 	dbStats []metric.Registration
+
+	modes         []string
+	otlpFallback  string
+	otlpEnabled   bool
+	otlpConnected atomic.Bool
+	stopRetry     context.CancelFunc
+	retryDone     chan struct{}
 }
 
 func NewObserver(appVersion string, conf config.Configuration) *observer {
@@ -43,6 +61,24 @@ func NewObserver(appVersion string, conf config.Configuration) *observer {
 	}
 }
 
+// Startup configures the global tracer and meter providers.
+//
+// The exporters are selected through MetricConfiguration.Integration(), a
+// comma-separated list:
+//
+//   - "prometheus" registers a pull-based reader served by MetricHandler.
+//   - "stdout" and "file" write traces and metrics locally.
+//   - "none" disables export explicitly.
+//   - "otlp" (or its alias "datadog") pushes to the OTLP collector at
+//     TELEMETRY_HOST.
+//
+// Any other value is a configuration error.
+//
+// When the collector cannot be reached, telemetry is exported through the
+// exporter named by MetricConfiguration.Fallback() and the connection is
+// retried in the background with exponential backoff until it succeeds or
+// the observer shuts down. Providers are always installed, so a collector
+// outage never leaves the service on the global no-op providers.
 func (o *observer) Startup(ctx context.Context) error {
 	conf := o.Conf.Metric()
 
@@ -52,90 +88,254 @@ func (o *observer) Startup(ctx context.Context) error {
 		return err
 	}
 
-	addr, err := conf.Host()
+	integrations, err := conf.Integration()
 
 	if err != nil {
 		return err
 	}
 
-	port, err := conf.Port()
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceName(serviceName),
+			semconv.DeploymentEnvironment(env),
+			semconv.ServiceVersion(o.AppVersion),
+		),
+	)
 
 	if err != nil {
 		return err
 	}
 
-	exportTracerOptions := []otlptracegrpc.Option{
-		otlptracegrpc.WithEndpoint(fmt.Sprintf("%s:%d", addr, port)),
+	tracerOptions := []trace.TracerProviderOption{trace.WithResource(res)}
+	meterOptions := []sdkmetric.Option{sdkmetric.WithResource(res)}
+
+	var spans *swappableSpanExporter
+	var metrics *swappableMetricExporter
+
+	for _, integration := range strings.Split(integrations, ",") {
+		switch mode := strings.ToLower(strings.TrimSpace(integration)); mode {
+		case "prometheus":
+			reader, handler, err := newPrometheusReader()
+
+			if err != nil {
+				return err
+			}
+
+			o.scrape = handler
+			o.modes = append(o.modes, mode)
+			meterOptions = append(meterOptions, sdkmetric.WithReader(reader))
+		case "stdout", "file", "none":
+			tracerExporter, meterExporter, file, err := newLocalExporters(mode, conf)
+
+			if err != nil {
+				return err
+			}
+
+			o.trackFile(file)
+			o.modes = append(o.modes, mode)
+			tracerOptions = append(tracerOptions, trace.WithBatcher(tracerExporter, trace.WithBatchTimeout(1*time.Second)))
+			meterOptions = append(meterOptions, sdkmetric.WithReader(
+				sdkmetric.NewPeriodicReader(meterExporter, sdkmetric.WithInterval(1*time.Second)),
+			))
+		case "otlp", "datadog":
+			if o.otlpEnabled {
+				continue
+			}
+
+			o.otlpFallback = conf.Fallback()
+			tracerExporter, meterExporter, file, err := newLocalExporters(o.otlpFallback, conf)
+
+			if err != nil {
+				return err
+			}
+
+			o.trackFile(file)
+			o.otlpEnabled = true
+			spans = newSwappableSpanExporter(tracerExporter)
+			metrics = newSwappableMetricExporter(meterExporter)
+			tracerOptions = append(tracerOptions, trace.WithBatcher(spans, trace.WithBatchTimeout(1*time.Second)))
+			meterOptions = append(meterOptions, sdkmetric.WithReader(
+				sdkmetric.NewPeriodicReader(metrics, sdkmetric.WithInterval(1*time.Second)),
+			))
+		default:
+			return fmt.Errorf("unsupported TELEMETRY_INTEGRATION value %q", mode)
+		}
 	}
 
-	exportMetricOptions := []otlpmetricgrpc.Option{
-		otlpmetricgrpc.WithEndpoint(fmt.Sprintf("%s:%d", addr, port)),
-	}
+	o.tracer = trace.NewTracerProvider(tracerOptions...)
+	o.metric = sdkmetric.NewMeterProvider(meterOptions...)
 
-	if env == "local" {
-		exportTracerOptions = append(exportTracerOptions, otlptracegrpc.WithInsecure())
-		exportMetricOptions = append(exportMetricOptions, otlpmetricgrpc.WithInsecure())
+	otel.SetTracerProvider(o.tracer)
+	otel.SetMeterProvider(o.metric)
+
+	if !o.otlpEnabled {
+		return nil
 	}
 
-	tracerExporter, err := otlptracegrpc.New(ctx, exportTracerOptions...)
+	endpoint, err := otlpEndpoint(conf)
 
 	if err != nil {
 		return err
 	}
 
-	meterExporter, err := otlpmetricgrpc.New(ctx, exportMetricOptions...)
+	if err := o.connectOTLP(ctx, env, endpoint, spans, metrics); err != nil {
+		o.Logger().Warn(ctx, "telemetry collector unavailable, using fallback exporter",
+			slog.String("fallback", o.otlpFallback),
+			slog.Any("error", err),
+		)
 
-	if err != nil {
-		return err
+		retryCtx, cancel := context.WithCancel(context.Background())
+		o.stopRetry = cancel
+		o.retryDone = make(chan struct{})
+
+		go o.retryOTLP(retryCtx, env, endpoint, spans, metrics)
 	}
 
-	tracerResource, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceName(serviceName),
-			semconv.DeploymentEnvironment(env),
-			semconv.ServiceVersion(o.AppVersion),
-		),
-	)
+	return nil
+}
+
+// TelemetryMode reports the active export modes as a comma-separated list.
+// An OTLP integration running on its fallback is reported as
+// "fallback:<exporter>" until the collector connection succeeds.
+func (o *observer) TelemetryMode() string {
+	modes := append([]string{}, o.modes...)
+
+	if o.otlpEnabled {
+		if o.otlpConnected.Load() {
+			modes = append(modes, "otlp")
+		} else {
+			modes = append(modes, "fallback:"+o.otlpFallback)
+		}
+	}
+
+	if len(modes) == 0 {
+		return "none"
+	}
+
+	return strings.Join(modes, ",")
+}
+
+// connectOTLP checks the collector is reachable and, when it is, replaces
+// the fallback exporters with OTLP exporters. Once they are swapped in the
+// connection succeeded: failing to shut down the replaced exporters is only
+// logged, so callers do not connect again and leak the installed ones.
+func (o *observer) connectOTLP(ctx context.Context, env string, endpoint string, spans *swappableSpanExporter, metrics *swappableMetricExporter) error {
+	dialer := net.Dialer{Timeout: 2 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", endpoint)
 
 	if err != nil {
 		return err
 	}
 
-	meterResource, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceName(serviceName),
-			semconv.DeploymentEnvironment(env),
-			semconv.ServiceVersion(o.AppVersion),
-		),
-	)
+	conn.Close()
+
+	tracerExporter, meterExporter, err := newOTLPExporters(ctx, env, endpoint)
 
 	if err != nil {
 		return err
 	}
 
-	o.tracer = trace.NewTracerProvider(
-		trace.WithBatcher(tracerExporter, trace.WithBatchTimeout(1*time.Second)),
-		trace.WithResource(tracerResource),
-	)
+	if err := ctx.Err(); err != nil {
+		return errors.Join(err, tracerExporter.Shutdown(context.Background()), meterExporter.Shutdown(context.Background()))
+	}
 
-	meterReader := sdkmetric.NewPeriodicReader(
-		meterExporter,
-		sdkmetric.WithInterval(1*time.Second),
+	replaced := errors.Join(
+		spans.Swap(tracerExporter).Shutdown(ctx),
+		metrics.Swap(meterExporter).Shutdown(ctx),
 	)
 
-	o.metric = sdkmetric.NewMeterProvider(
-		sdkmetric.WithReader(meterReader),
-		sdkmetric.WithResource(meterResource),
-	)
+	o.otlpConnected.Store(true)
+
+	if replaced != nil {
+		o.Logger().Warn(ctx, "error shutting down fallback telemetry exporters", slog.Any("error", replaced))
+	}
 
-	otel.SetTracerProvider(o.tracer)
-	otel.SetMeterProvider(o.metric)
 	return nil
 }
 
+// retryOTLP reconnects to the collector in the background. It closes
+// retryDone on exit so Shutdown can wait for it before stopping the
+// providers the swapped exporters belong to.
+func (o *observer) retryOTLP(ctx context.Context, env string, endpoint string, spans *swappableSpanExporter, metrics *swappableMetricExporter) {
+	defer close(o.retryDone)
+
+	backoff := 1 * time.Second
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		if err := o.connectOTLP(ctx, env, endpoint, spans, metrics); err == nil {
+			o.Logger().Info(ctx, "telemetry collector connected", slog.String("endpoint", endpoint))
+			return
+		}
+
+		backoff = min(backoff*2, 1*time.Minute)
+	}
+}
+
+func (o *observer) trackFile(file *os.File) {
+	if file != nil {
+		o.files = append(o.files, file)
+	}
+}
+
+func otlpEndpoint(conf config.MetricConfiguration) (string, error) {
+	addr, err := conf.Host()
+
+	if err != nil {
+		return "", err
+	}
+
+	port, err := conf.Port()
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s:%d", addr, port), nil
+}
+
+func newOTLPExporters(ctx context.Context, env string, endpoint string) (*otlptrace.Exporter, *otlpmetricgrpc.Exporter, error) {
+	exportTracerOptions := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(endpoint),
+	}
+
+	exportMetricOptions := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(endpoint),
+	}
+
+	if env == "local" {
+		exportTracerOptions = append(exportTracerOptions, otlptracegrpc.WithInsecure())
+		exportMetricOptions = append(exportMetricOptions, otlpmetricgrpc.WithInsecure())
+	}
+
+	tracerExporter, err := otlptracegrpc.New(ctx, exportTracerOptions...)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meterExporter, err := otlpmetricgrpc.New(ctx, exportMetricOptions...)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tracerExporter, meterExporter, nil
+}
+
 func (o *observer) Shutdown(ctx context.Context) error {
 	var err error
 
+	if o.stopRetry != nil {
+		o.stopRetry()
+		<-o.retryDone
+	}
+
 	if o.tracer != nil {
 		err = errors.Join(err, o.tracer.Shutdown(ctx))
 	}
@@ -144,6 +344,10 @@ func (o *observer) Shutdown(ctx context.Context) error {
 		err = errors.Join(err, o.metric.Shutdown(ctx))
 	}
 
+	for _, file := range o.files {
+		err = errors.Join(err, file.Close())
+	}
+
 	if o.dbStats != nil {
 		for _, dbStats := range o.dbStats {
 			err = errors.Join(err, dbStats.Unregister())
@@ -166,6 +370,16 @@ func (o *observer) Metric() (MetricClient, error) {
 	)
 }
 
+// MetricHandler returns the HTTP handler exposing metrics for scraping.
+// It responds with 404 when no pull-based exporter is configured.
+func (o *observer) MetricHandler() http.Handler {
+	if o.scrape == nil {
+		return http.NotFoundHandler()
+	}
+
+	return o.scrape
+}
+
 func (o *observer) RegisterDB(dbStats metric.Registration) {
 	o.dbStats = append(o.dbStats, dbStats)
 }
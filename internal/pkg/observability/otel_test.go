@@ -0,0 +1,139 @@
+package observability_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+func TestObserver(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should serve prometheus metrics without a collector", func(t *testing.T) {
+		os.Setenv("ENV", "test")
+		os.Setenv("TELEMETRY_INTEGRATION", "prometheus")
+		defer os.Unsetenv("ENV")
+		defer os.Unsetenv("TELEMETRY_INTEGRATION")
+
+		observer := observability.NewObserver("test", config.NewConfiguration())
+		require.NoError(t, observer.Startup(ctx))
+		defer observer.Shutdown(ctx)
+
+		metric, err := observer.Metric()
+		require.NoError(t, err)
+		metric.MemoryHit(ctx, "key", time.Millisecond)
+
+		rec := httptest.NewRecorder()
+		observer.MetricHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "tiny_url_memory_hit_latency")
+	})
+
+	t.Run("should require collector host for otlp integration", func(t *testing.T) {
+		os.Setenv("ENV", "test")
+		os.Setenv("TELEMETRY_INTEGRATION", "otlp")
+		defer os.Unsetenv("ENV")
+		defer os.Unsetenv("TELEMETRY_INTEGRATION")
+
+		observer := observability.NewObserver("test", config.NewConfiguration())
+
+		assert.Error(t, observer.Startup(ctx))
+	})
+
+	t.Run("should reject an unknown integration", func(t *testing.T) {
+		os.Setenv("ENV", "test")
+		os.Setenv("TELEMETRY_INTEGRATION", "prometheus,otpl")
+		defer os.Unsetenv("ENV")
+		defer os.Unsetenv("TELEMETRY_INTEGRATION")
+
+		observer := observability.NewObserver("test", config.NewConfiguration())
+
+		assert.ErrorContains(t, observer.Startup(ctx), "otpl")
+	})
+
+	t.Run("should not serve metrics when no scrape exporter is configured", func(t *testing.T) {
+		observer := observability.NewObserver("test", config.NewConfiguration())
+
+		rec := httptest.NewRecorder()
+		observer.MetricHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+	t.Run("should fall back when the collector is unreachable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+
+		file := filepath.Join(t.TempDir(), "telemetry.jsonl")
+
+		os.Setenv("ENV", "local")
+		os.Setenv("TELEMETRY_INTEGRATION", "otlp")
+		os.Setenv("TELEMETRY_HOST", "127.0.0.1")
+		os.Setenv("TELEMETRY_PORT", strconv.Itoa(port))
+		os.Setenv("TELEMETRY_FALLBACK", "file")
+		os.Setenv("TELEMETRY_FILE", file)
+		defer os.Unsetenv("ENV")
+		defer os.Unsetenv("TELEMETRY_INTEGRATION")
+		defer os.Unsetenv("TELEMETRY_HOST")
+		defer os.Unsetenv("TELEMETRY_PORT")
+		defer os.Unsetenv("TELEMETRY_FALLBACK")
+		defer os.Unsetenv("TELEMETRY_FILE")
+
+		observer := observability.NewObserver("test", config.NewConfiguration())
+		require.NoError(t, observer.Startup(ctx))
+
+		assert.Equal(t, "fallback:file", observer.TelemetryMode())
+		assert.NoError(t, observer.Shutdown(ctx))
+		assert.FileExists(t, file)
+	})
+
+	t.Run("should connect to a reachable collector", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+
+		os.Setenv("ENV", "local")
+		os.Setenv("TELEMETRY_INTEGRATION", "otlp,prometheus")
+		os.Setenv("TELEMETRY_HOST", "127.0.0.1")
+		os.Setenv("TELEMETRY_PORT", strconv.Itoa(listener.Addr().(*net.TCPAddr).Port))
+		defer os.Unsetenv("ENV")
+		defer os.Unsetenv("TELEMETRY_INTEGRATION")
+		defer os.Unsetenv("TELEMETRY_HOST")
+		defer os.Unsetenv("TELEMETRY_PORT")
+
+		observer := observability.NewObserver("test", config.NewConfiguration())
+		require.NoError(t, observer.Startup(ctx))
+
+		assert.Equal(t, "prometheus,otlp", observer.TelemetryMode())
+
+		shutdownCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		observer.Shutdown(shutdownCtx)
+	})
+
+	t.Run("should report none mode", func(t *testing.T) {
+		os.Setenv("ENV", "test")
+		os.Setenv("TELEMETRY_INTEGRATION", "none")
+		defer os.Unsetenv("ENV")
+		defer os.Unsetenv("TELEMETRY_INTEGRATION")
+
+		observer := observability.NewObserver("test", config.NewConfiguration())
+		require.NoError(t, observer.Startup(ctx))
+		defer observer.Shutdown(ctx)
+
+		assert.Equal(t, "none", observer.TelemetryMode())
+	})
+}
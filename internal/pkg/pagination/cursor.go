@@ -0,0 +1,165 @@
+package pagination
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	json "github.com/json-iterator/go"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidLimit  = errors.New("invalid limit")
+)
+
+// Cursor is the position a page starts from. It binds the order and the
+// filters of the listing, so a client cannot change them halfway through.
+type Cursor struct {
+	Order     Order     `json:"o"`
+	Direction Direction `json:"d"`
+	Key       int64     `json:"k"`
+	Filter    string    `json:"f,omitempty"`
+}
+
+// Query returns the query selecting the page the cursor points to.
+func (c Cursor) Query(limit int) Query {
+	return Query{Limit: limit, Order: c.Order, Direction: c.Direction, Key: &c.Key}
+}
+
+// Pager validates page sizes and signs cursors, which clients receive as
+// opaque tokens: the base64 JSON cursor and its HMAC-SHA256, joined by a
+// dot.
+type Pager struct {
+	DefaultLimit int
+	MinLimit     int
+	MaxLimit     int
+	secret       []byte
+}
+
+// NewPagerFromConfig builds a pager from conf. Without a configured
+// secret, cursors are signed with a random key and are only accepted by
+// this process.
+func NewPagerFromConfig(conf config.PaginationConfiguration, observer observability.Observer) (Pager, error) {
+	defaultLimit, err := conf.DefaultLimit()
+
+	if err != nil {
+		return Pager{}, err
+	}
+
+	minLimit, err := conf.MinLimit()
+
+	if err != nil {
+		return Pager{}, err
+	}
+
+	maxLimit, err := conf.MaxLimit()
+
+	if err != nil {
+		return Pager{}, err
+	}
+
+	if minLimit > defaultLimit || defaultLimit > maxLimit {
+		return Pager{}, fmt.Errorf("default page size %d must be between the minimum %d and the maximum %d", defaultLimit, minLimit, maxLimit)
+	}
+
+	secret, configured, err := conf.CursorSecret()
+
+	if err != nil {
+		return Pager{}, err
+	}
+
+	if !configured {
+		key := make([]byte, 32)
+
+		if _, err := rand.Read(key); err != nil {
+			return Pager{}, fmt.Errorf("error generating cursor secret: %w", err)
+		}
+
+		secret = string(key)
+		observer.Logger().Warn(context.Background(), "cursor secret not configured, page cursors are only valid on this instance until it restarts")
+	}
+
+	return NewPager([]byte(secret), defaultLimit, minLimit, maxLimit), nil
+}
+
+func NewPager(secret []byte, defaultLimit int, minLimit int, maxLimit int) Pager {
+	return Pager{
+		DefaultLimit: defaultLimit,
+		MinLimit:     minLimit,
+		MaxLimit:     maxLimit,
+		secret:       secret,
+	}
+}
+
+// Limit parses the page size requested by value, falling back to the
+// default when it is empty.
+func (p Pager) Limit(value string) (int, error) {
+	if value == "" {
+		return p.DefaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+
+	if err != nil || limit < p.MinLimit || limit > p.MaxLimit {
+		return 0, fmt.Errorf("%w: %q is not between %d and %d", ErrInvalidLimit, value, p.MinLimit, p.MaxLimit)
+	}
+
+	return limit, nil
+}
+
+// Sign encodes the cursor as an opaque token.
+func (p Pager) Sign(cursor Cursor) string {
+	payload, _ := json.Marshal(cursor)
+	encoding := base64.RawURLEncoding
+
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(p.mac(payload))
+}
+
+// Parse decodes a token returned by Sign, rejecting any token that was
+// not signed with the same secret.
+func (p Pager) Parse(token string) (Cursor, error) {
+	var cursor Cursor
+	encoding := base64.RawURLEncoding
+	body, signature, found := strings.Cut(token, ".")
+
+	if !found {
+		return cursor, ErrInvalidCursor
+	}
+
+	payload, err := encoding.DecodeString(body)
+
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	mac, err := encoding.DecodeString(signature)
+
+	if err != nil || !hmac.Equal(mac, p.mac(payload)) {
+		return cursor, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	if (cursor.Order != Ascending && cursor.Order != Descending) || (cursor.Direction != Forward && cursor.Direction != Backward) {
+		return cursor, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+func (p Pager) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
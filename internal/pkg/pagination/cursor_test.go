@@ -0,0 +1,55 @@
+package pagination_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/pkg/pagination"
+)
+
+func TestPager(t *testing.T) {
+	pager := pagination.NewPager([]byte("secret"), 20, 5, 50)
+
+	t.Run("parse signed cursor", func(t *testing.T) {
+		cursor := pagination.Cursor{Order: pagination.Ascending, Direction: pagination.Backward, Key: 42, Filter: "owner=acme"}
+
+		parsed, err := pager.Parse(pager.Sign(cursor))
+
+		assert.NoError(t, err)
+		assert.Equal(t, cursor, parsed)
+	})
+
+	t.Run("reject tampered cursor", func(t *testing.T) {
+		token := pager.Sign(pagination.Cursor{Order: pagination.Descending, Direction: pagination.Forward, Key: 42})
+		forged := pagination.NewPager([]byte("other"), 20, 5, 50).Sign(pagination.Cursor{Order: pagination.Descending, Direction: pagination.Forward, Key: 42})
+		body, signature, _ := strings.Cut(token, ".")
+
+		for _, value := range []string{"", "42", body, forged, body + "." + signature[1:], "e30." + signature} {
+			_, err := pager.Parse(value)
+			assert.ErrorIs(t, err, pagination.ErrInvalidCursor, value)
+		}
+	})
+
+	t.Run("parse limit", func(t *testing.T) {
+		limit, err := pager.Limit("")
+		assert.NoError(t, err)
+		assert.Equal(t, 20, limit)
+
+		limit, err = pager.Limit("50")
+		assert.NoError(t, err)
+		assert.Equal(t, 50, limit)
+
+		for _, value := range []string{"4", "51", "-1", "ten"} {
+			_, err := pager.Limit(value)
+			assert.ErrorIs(t, err, pagination.ErrInvalidLimit, value)
+		}
+	})
+
+	t.Run("select keys relative to the cursor", func(t *testing.T) {
+		assert.Equal(t, "<", pagination.Query{}.Operator())
+		assert.Equal(t, ">", pagination.Query{Order: pagination.Descending, Direction: pagination.Backward}.Operator())
+		assert.Equal(t, ">", pagination.Query{Order: pagination.Ascending, Direction: pagination.Forward}.Operator())
+		assert.Equal(t, "<", pagination.Query{Order: pagination.Ascending, Direction: pagination.Backward}.Operator())
+	})
+}
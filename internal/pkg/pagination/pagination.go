@@ -2,70 +2,171 @@ package pagination
 
 import (
 	"bytes"
-	"fmt"
-	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 
 	json "github.com/json-iterator/go"
-	"github.com/zeon-code/tiny-url/internal/pkg/base62"
 )
 
 type CursorKey[T any] func(T) int64
 
+// Order is the order of a listing by its cursor key.
+type Order string
+
+const (
+	Descending Order = "desc"
+	Ascending  Order = "asc"
+)
+
+// Direction tells whether a page follows or precedes its cursor key.
+type Direction string
+
+const (
+	Forward  Direction = "next"
+	Backward Direction = "previous"
+)
+
+// Query selects one page of a listing ordered by a unique key. The zero
+// value selects the first page in descending order.
+type Query struct {
+	Limit     int
+	Order     Order
+	Direction Direction
+	Key       *int64
+}
+
+// Operator returns the comparison selecting the keys of the page relative
+// to Key: keys after it in the listing order when moving forward, before
+// it when moving backward.
+func (q Query) Operator() string {
+	if (q.Order == Ascending) == (q.Direction == Backward) {
+		return "<"
+	}
+
+	return ">"
+}
+
+// Scan returns the order keys are read in, nearest to Key first: the
+// listing order when moving forward and its reverse when moving backward.
+func (q Query) Scan() Order {
+	if q.Direction != Backward {
+		return q.Order
+	}
+
+	if q.Order == Ascending {
+		return Descending
+	}
+
+	return Ascending
+}
+
+// Fetch returns how many items to read for the page: one more than its
+// limit, which tells whether the listing goes on past the page.
+func (q Query) Fetch() int {
+	return q.Limit + 1
+}
+
 type Page struct {
 	Next     string `json:"next,omitempty"`
 	Previous string `json:"previous,omitempty"`
 	Size     int    `json:"size"`
 }
 
+// Links renders the RFC 8288 Link header pointing to the next and previous
+// pages, as references to base carrying the cursor and page size. It is
+// empty when there are no such pages.
+func (p Page) Links(base url.URL, limit int) string {
+	links := []string{}
+
+	link := func(cursor string, rel string) string {
+		base.RawQuery = url.Values{"cursor": {cursor}, "limit": {strconv.Itoa(limit)}}.Encode()
+		return "<" + base.RequestURI() + `>; rel="` + rel + `"`
+	}
+
+	if p.Next != "" {
+		links = append(links, link(p.Next, "next"))
+	}
+
+	if p.Previous != "" {
+		links = append(links, link(p.Previous, "prev"))
+	}
+
+	return strings.Join(links, ", ")
+}
+
 type Pagination[T any] struct {
-	Limit  int    `json:"-"`
-	Cursor *int64 `json:"-"`
+	Query  Query  `json:"-"`
+	Filter string `json:"-"`
 	Items  []T    `json:"items"`
 	Page   Page   `json:"page"`
+
+	hasNext     bool
+	hasPrevious bool
 }
 
-func NewPagination[T any](items []T, limit int, cursor *int64) Pagination[T] {
-	return Pagination[T]{
+// NewPagination builds the page read with query: up to query.Fetch()
+// items in scan order. It keeps query.Limit of them in listing order and
+// tells from the extra one whether the listing goes on in the direction
+// read. filter is carried by the cursors of the surrounding pages, so
+// following them lists the same items.
+func NewPagination[T any](items []T, query Query, filter string) Pagination[T] {
+	more := len(items) > query.Limit
+	items = slices.Clone(items[:min(len(items), query.Limit)])
+
+	p := Pagination[T]{
 		Items:  items,
-		Limit:  limit,
-		Cursor: cursor,
+		Query:  query,
+		Filter: filter,
+	}
+
+	if query.Direction == Backward {
+		slices.Reverse(p.Items)
+		p.hasNext, p.hasPrevious = query.Key != nil, more
+	} else {
+		p.hasNext, p.hasPrevious = more, query.Key != nil
 	}
+
+	return p
 }
 
-func (p Pagination[T]) Encode(cursorKey CursorKey[T]) ([]byte, error) {
-	buf := bytes.NewBuffer(nil)
-	enc := json.NewEncoder(buf)
-	p.Page = Page{Size: len(p.Items)}
+// HasNext reports whether a page follows this one.
+func (p Pagination[T]) HasNext() bool {
+	return p.hasNext && len(p.Items) > 0
+}
 
-	enc.SetEscapeHTML(false)
+// HasPrevious reports whether a page precedes this one. Pages reached
+// through a forward cursor always have one, as the cursor was issued by
+// it.
+func (p Pagination[T]) HasPrevious() bool {
+	return p.hasPrevious && len(p.Items) > 0
+}
 
-	if len(p.Items) > 0 {
-		if p.Limit <= len(p.Items) {
-			last := p.Items[len(p.Items)-1]
-			p.Page.Next = fmt.Sprintf("<%s", base62.Encode(cursorKey(last)))
-		}
+// WithCursors fills the page with its size and the signed cursors of the
+// pages around it, when they exist.
+func (p Pagination[T]) WithCursors(pager Pager, cursorKey CursorKey[T]) Pagination[T] {
+	p.Page = Page{Size: len(p.Items)}
 
-		if p.Cursor != nil {
-			first := p.Items[0]
-			p.Page.Previous = fmt.Sprintf(">%s", base62.Encode(cursorKey(first)))
-		}
+	if p.HasNext() {
+		last := p.Items[len(p.Items)-1]
+		p.Page.Next = pager.Sign(Cursor{Order: p.Query.Order, Direction: Forward, Key: cursorKey(last), Filter: p.Filter})
 	}
 
-	err := enc.Encode(p)
-	return buf.Bytes(), err
-}
+	if p.HasPrevious() {
+		first := p.Items[0]
+		p.Page.Previous = pager.Sign(Cursor{Order: p.Query.Order, Direction: Backward, Key: cursorKey(first), Filter: p.Filter})
+	}
 
-func GetCursor(r *http.Request) (string, *int64) {
-	if value := r.URL.Query().Get("cursor"); value != "" {
-		direction := value[0:1]
-		c := base62.Decode(value[1:])
+	return p
+}
 
-		if direction != "<" && direction != ">" {
-			direction = "<"
-		}
+func (p Pagination[T]) Encode() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	enc := json.NewEncoder(buf)
 
-		return direction, &c
-	}
+	enc.SetEscapeHTML(false)
 
-	return "<", nil
+	err := enc.Encode(p)
+	return buf.Bytes(), err
 }
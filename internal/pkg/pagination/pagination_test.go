@@ -0,0 +1,111 @@
+package pagination_test
+
+import (
+	"net/url"
+	"testing"
+
+	json "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeon-code/tiny-url/internal/pkg/pagination"
+)
+
+type item struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestPagination(t *testing.T) {
+	pager := pagination.NewPager([]byte("secret"), 2, 1, 10)
+	itemKey := func(i item) int64 { return i.ID }
+	intKey := func(i int64) int64 { return i }
+	key := func(k int64) *int64 { return &k }
+
+	sign := func(direction pagination.Direction, key int64) string {
+		return pager.Sign(pagination.Cursor{Order: pagination.Descending, Direction: direction, Key: key, Filter: "q=a"})
+	}
+
+	t.Run("first page with more items", func(t *testing.T) {
+		query := pagination.Query{Limit: 2, Order: pagination.Descending, Direction: pagination.Forward}
+		page := pagination.NewPagination([]int64{9, 8, 7}, query, "q=a").WithCursors(pager, intKey)
+
+		assert.Equal(t, []int64{9, 8}, page.Items)
+		assert.True(t, page.HasNext())
+		assert.False(t, page.HasPrevious())
+		assert.Equal(t, pagination.Page{Next: sign(pagination.Forward, 8), Size: 2}, page.Page)
+	})
+
+	t.Run("single page", func(t *testing.T) {
+		query := pagination.Query{Limit: 2, Order: pagination.Descending, Direction: pagination.Forward}
+		page := pagination.NewPagination([]int64{9, 8}, query, "q=a").WithCursors(pager, intKey)
+
+		assert.Equal(t, []int64{9, 8}, page.Items)
+		assert.Equal(t, pagination.Page{Size: 2}, page.Page, "a full page is not followed by an empty one")
+	})
+
+	t.Run("last page reached forward", func(t *testing.T) {
+		query := pagination.Query{Limit: 2, Order: pagination.Descending, Direction: pagination.Forward, Key: key(8)}
+		page := pagination.NewPagination([]int64{7}, query, "q=a").WithCursors(pager, intKey)
+
+		assert.Equal(t, []int64{7}, page.Items)
+		assert.Equal(t, pagination.Page{Previous: sign(pagination.Backward, 7), Size: 1}, page.Page)
+	})
+
+	t.Run("previous page in listing order", func(t *testing.T) {
+		query := pagination.Query{Limit: 2, Order: pagination.Descending, Direction: pagination.Backward, Key: key(5)}
+		page := pagination.NewPagination([]int64{6, 7, 8}, query, "q=a").WithCursors(pager, intKey)
+
+		assert.Equal(t, []int64{7, 6}, page.Items)
+		assert.True(t, page.HasNext())
+		assert.True(t, page.HasPrevious())
+		assert.Equal(t, pagination.Page{Next: sign(pagination.Forward, 6), Previous: sign(pagination.Backward, 7), Size: 2}, page.Page)
+	})
+
+	t.Run("first page reached backward", func(t *testing.T) {
+		query := pagination.Query{Limit: 2, Order: pagination.Descending, Direction: pagination.Backward, Key: key(7)}
+		page := pagination.NewPagination([]int64{8, 9}, query, "q=a").WithCursors(pager, intKey)
+
+		assert.Equal(t, []int64{9, 8}, page.Items)
+		assert.Equal(t, pagination.Page{Next: sign(pagination.Forward, 8), Size: 2}, page.Page)
+	})
+
+	t.Run("empty page", func(t *testing.T) {
+		query := pagination.Query{Limit: 2, Order: pagination.Descending, Direction: pagination.Backward, Key: key(7)}
+		page := pagination.NewPagination([]item{}, query, "").WithCursors(pager, itemKey)
+
+		assert.False(t, page.HasNext())
+		assert.False(t, page.HasPrevious())
+		assert.Equal(t, pagination.Page{}, page.Page)
+	})
+
+	t.Run("does not modify the items read", func(t *testing.T) {
+		items := []item{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}
+		query := pagination.Query{Limit: 2, Order: pagination.Ascending, Direction: pagination.Backward, Key: key(3)}
+		page := pagination.NewPagination(items, query, "")
+
+		assert.Equal(t, []item{{ID: 2, Name: "b"}, {ID: 1, Name: "a"}}, page.Items)
+		assert.Equal(t, []item{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, items)
+	})
+
+	t.Run("encode page", func(t *testing.T) {
+		var payload map[string]any
+		query := pagination.Query{Limit: 1, Order: pagination.Ascending, Direction: pagination.Forward}
+		page := pagination.NewPagination([]item{{ID: 1, Name: "<a>"}, {ID: 2, Name: "b"}}, query, "").WithCursors(pager, itemKey)
+
+		data, err := page.Encode()
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &payload))
+
+		assert.Contains(t, string(data), `"name":"<a>"`)
+		assert.Equal(t, []any{map[string]any{"id": float64(1), "name": "<a>"}}, payload["items"])
+		assert.Equal(t, map[string]any{"next": page.Page.Next, "size": float64(1)}, payload["page"])
+	})
+
+	t.Run("link pages", func(t *testing.T) {
+		base := url.URL{Path: "/api/v1/url/", RawQuery: "q=a"}
+		page := pagination.Page{Next: "n", Previous: "p"}
+
+		assert.Equal(t, `</api/v1/url/?cursor=n&limit=2>; rel="next", </api/v1/url/?cursor=p&limit=2>; rel="prev"`, page.Links(base, 2))
+		assert.Empty(t, pagination.Page{}.Links(base, 2))
+	})
+}
@@ -4,12 +4,17 @@ import (
 	"database/sql"
 	"log/slog"
 	"net/http"
+	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
 	"github.com/zeon-code/tiny-url/internal/db"
 	"github.com/zeon-code/tiny-url/internal/http/handler"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
 	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/pkg/pagination"
 	"github.com/zeon-code/tiny-url/internal/repository"
 	"github.com/zeon-code/tiny-url/internal/service"
 )
@@ -21,8 +26,8 @@ type FakeDependencies struct {
 	DBMetric  *FakeMetric
 
 	// Cache
-	CacheBackend *FakeRedis
-	CacheMetric  *FakeMetric
+	CacheClient *db.LocalCacheClient
+	CacheMetric *FakeMetric
 
 	// Memory
 	MemoryMetric *FakeMetric
@@ -31,7 +36,9 @@ type FakeDependencies struct {
 	HTTPMetric *FakeMetric
 }
 
-func NewFakeDependencies() FakeDependencies {
+// NewFakeDependencies builds the fakes of a test. The local cache is closed
+// when the test ends, stopping its sweep goroutine.
+func NewFakeDependencies(t testing.TB) FakeDependencies {
 	var sqldb *sql.DB
 
 	fake := FakeDependencies{
@@ -39,25 +46,42 @@ func NewFakeDependencies() FakeDependencies {
 		CacheMetric:  NewFakeMetric(),
 		MemoryMetric: NewFakeMetric(),
 		HTTPMetric:   NewFakeMetric(),
-		CacheBackend: NewFakeRedisBackend(),
 	}
 
+	fake.CacheClient = db.NewLocalCacheClient(1000, 1<<20, NewFakeObserver(fake.CacheMetric))
+	t.Cleanup(func() { fake.CacheClient.Close() })
+
 	sqldb, fake.DBMock, _ = sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 	fake.DBBackend = sqlx.NewDb(sqldb, "postgres")
 
 	return fake
 }
 
+// NewSQLiteClient opens a migrated in-memory SQLite database for tests that
+// need real SQL. The database is closed when the test ends.
+func NewSQLiteClient(t testing.TB) db.SQLClient {
+	t.Setenv("DB_SQLITE_TEST_PATH", ":memory:")
+
+	client, err := db.NewDBClient(config.NewSQLiteConfig("DB_SQLITE_TEST"), NewFakeObserver(NewFakeMetric()))
+
+	if err != nil {
+		t.Fatalf("error opening the sqlite test database: %v", err)
+	}
+
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
 func (d FakeDependencies) Observer() observability.Observer {
-	return NewFakeObserver(&FakeMetric{})
+	return NewFakeObserver(d.HTTPMetric)
 }
 
 func (d FakeDependencies) DB() *db.PostgresClient {
 	return db.NewPostgresClient(d.DBBackend, NewFakeObserver(d.DBMetric))
 }
 
-func (d FakeDependencies) Cache() *db.RedisClient {
-	return db.NewRedisClient(d.CacheBackend, NewFakeObserver(d.CacheMetric))
+func (d FakeDependencies) Cache() *db.LocalCacheClient {
+	return d.CacheClient
 }
 
 func (d FakeDependencies) Memory() db.SQLReader {
@@ -70,13 +94,23 @@ func (d FakeDependencies) Logger() *slog.Logger {
 }
 
 func (d FakeDependencies) Repositories() repository.Repositories {
-	return repository.NewRepositories(d.DB(), d.Memory(), d.Observer())
+	return repository.NewRepositories(d.DB(), d.Memory(), d.Cache(), d.Observer())
 }
 
 func (d FakeDependencies) Services() service.Services {
 	return service.NewServices(d.Repositories(), d.Observer())
 }
 
+// Pager signs cursors with a fixed secret, so tests can build the cursors
+// a listing returns.
+func (d FakeDependencies) Pager() pagination.Pager {
+	return pagination.NewPager([]byte("test"), 50, 1, 100)
+}
+
 func (d FakeDependencies) Router() http.Handler {
-	return handler.NewRouter(d.Services(), d.Observer())
+	return handler.NewRouter(d.Services(), d.Pager(), d.Observer())
+}
+
+func (d FakeDependencies) AdminRouter() http.Handler {
+	return handler.NewAdminRouter(d.Services(), d.Observer(), model.Build{Version: "test", StartedAt: time.Now()})
 }
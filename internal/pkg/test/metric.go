@@ -2,36 +2,130 @@ package test
 
 import (
 	"context"
+	"sync"
 	"time"
+
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
 )
 
 type FakeMetric struct {
-	LastMemoryInvalid     bool
-	LastMemoryHitKey      string
-	LastMemoryHitLatency  time.Duration
-	LastMemoryMissKey     string
-	LastMemoryMissLatency time.Duration
-	LastMemoryBypass      bool
+	mu sync.Mutex
+
+	LastMemoryInvalid        bool
+	LastMemoryHitFamily      string
+	LastMemoryHitLatency     time.Duration
+	LastMemoryMissFamily     string
+	LastMemoryMissLatency    time.Duration
+	LastMemoryBypass         bool
+	LastMemoryPayloadSize    int
+	LastMemoryErrorOperation string
+
+	LastRedirectOutcome       observability.RedirectOutcome
+	LastRedirectLatency       time.Duration
+	LinkCreatedCount          int
+	LastValidationOperation   string
+	LastValidationRejectCause observability.ValidationReason
+
+	CacheCircuitStates []observability.CircuitState
+	LastReplicaLag     time.Duration
+
+	WebhookOutcomes []observability.WebhookOutcome
+	JobRuns         []JobRun
+}
+
+// JobRun is a background job run recorded by FakeMetric.
+type JobRun struct {
+	Job     string
+	Outcome observability.JobOutcome
 }
 
 func NewFakeMetric() *FakeMetric {
 	return &FakeMetric{}
 }
 
-func (m *FakeMetric) MemoryHit(ctx context.Context, key string, duration time.Duration) {
-	m.LastMemoryHitKey = key
+func (m *FakeMetric) MemoryHit(ctx context.Context, family string, duration time.Duration) {
+	m.LastMemoryHitFamily = family
 	m.LastMemoryHitLatency = duration
 }
 
-func (m *FakeMetric) MemoryMiss(ctx context.Context, key string, duration time.Duration) {
-	m.LastMemoryMissKey = key
+func (m *FakeMetric) MemoryMiss(ctx context.Context, family string, duration time.Duration) {
+	m.LastMemoryMissFamily = family
 	m.LastMemoryMissLatency = duration
 }
 
-func (m *FakeMetric) MemoryInvalid(ctx context.Context, key string) {
+func (m *FakeMetric) MemoryInvalid(ctx context.Context, family string) {
 	m.LastMemoryInvalid = true
 }
 
+func (m *FakeMetric) MemoryPayload(ctx context.Context, family string, size int) {
+	m.LastMemoryPayloadSize = size
+}
+
+func (m *FakeMetric) MemoryError(ctx context.Context, family string, operation string) {
+	m.LastMemoryErrorOperation = operation
+}
+
 func (m *FakeMetric) MemoryBypassed(ctx context.Context) {
 	m.LastMemoryBypass = true
 }
+
+func (m *FakeMetric) Redirect(ctx context.Context, outcome observability.RedirectOutcome, duration time.Duration) {
+	m.LastRedirectOutcome = outcome
+	m.LastRedirectLatency = duration
+}
+
+func (m *FakeMetric) LinkCreated(ctx context.Context) {
+	m.LinkCreatedCount++
+}
+
+func (m *FakeMetric) ValidationRejected(ctx context.Context, operation string, reason observability.ValidationReason) {
+	m.LastValidationOperation = operation
+	m.LastValidationRejectCause = reason
+}
+
+func (m *FakeMetric) CacheCircuit(ctx context.Context, state observability.CircuitState) {
+	m.CacheCircuitStates = append(m.CacheCircuitStates, state)
+}
+
+// ReplicaLag may be called concurrently, as replicas are checked in
+// parallel.
+func (m *FakeMetric) ReplicaLag(ctx context.Context, replica string, lag time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.LastReplicaLag = lag
+}
+
+// WebhookDelivery may be called from the dispatcher goroutine while a test
+// reads the outcomes through Webhooks.
+func (m *FakeMetric) WebhookDelivery(ctx context.Context, outcome observability.WebhookOutcome, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.WebhookOutcomes = append(m.WebhookOutcomes, outcome)
+}
+
+// Webhooks returns a copy of the webhook delivery outcomes recorded so far.
+func (m *FakeMetric) Webhooks() []observability.WebhookOutcome {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]observability.WebhookOutcome(nil), m.WebhookOutcomes...)
+}
+
+// JobRun may be called from scheduler goroutines while a test reads the
+// runs through Jobs.
+func (m *FakeMetric) JobRun(ctx context.Context, job string, outcome observability.JobOutcome, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.JobRuns = append(m.JobRuns, JobRun{Job: job, Outcome: outcome})
+}
+
+// Jobs returns a copy of the job runs recorded so far.
+func (m *FakeMetric) Jobs() []JobRun {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]JobRun(nil), m.JobRuns...)
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/zeon-code/tiny-url/internal/pkg/observability"
 	"go.opentelemetry.io/otel/metric"
@@ -33,4 +34,12 @@ func (o *FakeObserver) Metric() (observability.MetricClient, error) {
 	return o.metrics, nil
 }
 
+func (o *FakeObserver) MetricHandler() http.Handler {
+	return http.NotFoundHandler()
+}
+
+func (o *FakeObserver) TelemetryMode() string {
+	return "none"
+}
+
 func (o *FakeObserver) RegisterDB(dbStats metric.Registration) {}
@@ -5,8 +5,11 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/zeon-code/tiny-url/internal/db"
 )
 
+// FakeRedis is a scripted RedisBackend used to test RedisClient. Other
+// tests use the LocalCacheClient provided by FakeDependencies.
 type FakeRedis struct {
 	Err   error
 	Value any
@@ -17,12 +20,18 @@ type FakeRedis struct {
 	LastSetKey        string
 	LastSetValue      any
 	LastSetExpiration time.Duration
+	LastScanMatch     string
 }
 
 func NewFakeRedisBackend() *FakeRedis {
 	return &FakeRedis{}
 }
 
+// Client returns a RedisClient backed by the fake.
+func (r *FakeRedis) Client() *db.RedisClient {
+	return db.NewRedisClient(r, NewFakeObserver(NewFakeMetric()))
+}
+
 func (r *FakeRedis) Get(ctx context.Context, key string) *redis.StringCmd {
 	r.LastGetKey = key
 
@@ -53,6 +62,13 @@ func (r *FakeRedis) SetNX(ctx context.Context, key string, value interface{}, ex
 	return redis.NewBoolResult(v, r.Err)
 }
 
+func (r *FakeRedis) Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd {
+	r.LastScanMatch = match
+
+	v, _ := r.Value.([]string)
+	return redis.NewScanCmdResult(v, 0, r.Err)
+}
+
 func (r *FakeRedis) Close() error {
 	return nil
 }
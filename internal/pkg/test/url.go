@@ -10,19 +10,21 @@ func (d FakeDependencies) MockUrlCreate() {
 	now := time.Now()
 
 	updateQuery := "UPDATE urls SET code = $1 WHERE id = $2"
-	insertQuery := "INSERT INTO urls (target, code) VALUES ($1, '') RETURNING id, target, code, created_at, updated_at"
+	insertQuery := "INSERT INTO urls (target, code, owner, expires_at, domain) VALUES ($1, '', $2, $3, $4) RETURNING id, target, code, owner, created_at, updated_at, expires_at"
+	outboxQuery := "INSERT INTO outbox (link_id, event_type, payload) VALUES ($1, $2, $3)"
 
 	rows := sqlmock.NewRows([]string{"id", "target", "code", "created_at", "updated_at"}).
 		AddRow(int64(1), "target", "", now, now)
 
 	d.DBMock.ExpectBegin()
-	d.DBMock.ExpectQuery(insertQuery).WithArgs(sqlmock.AnyArg()).WillReturnRows(rows)
+	d.DBMock.ExpectQuery(insertQuery).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnRows(rows)
 	d.DBMock.ExpectExec(updateQuery).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	d.DBMock.ExpectExec(outboxQuery).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
 	d.DBMock.ExpectCommit()
 }
 
 func (d FakeDependencies) MockUrlList() {
-	query := "SELECT id, code, target FROM urls ORDER BY id DESC LIMIT $1"
+	query := "SELECT id, code, target FROM urls WHERE deleted_at IS NULL ORDER BY id DESC LIMIT $1"
 
 	rows := sqlmock.NewRows([]string{"id", "code", "target"}).
 		AddRow(int64(5), "5", "target5").
@@ -35,21 +37,21 @@ func (d FakeDependencies) MockUrlList() {
 }
 
 func (d FakeDependencies) MockPaginatedUrlList() {
-	query := "SELECT id, code, target FROM urls WHERE id > $1 ORDER BY id DESC LIMIT $2"
+	query := "SELECT id, code, target FROM urls WHERE deleted_at IS NULL AND id > $1 ORDER BY id ASC LIMIT $2"
 
 	rows := sqlmock.NewRows([]string{"id", "code", "target"}).
-		AddRow(int64(6), "6", "target6").
-		AddRow(int64(5), "5", "target5").
-		AddRow(int64(4), "4", "target4").
+		AddRow(int64(2), "2", "target2").
 		AddRow(int64(3), "3", "target3").
-		AddRow(int64(2), "2", "target2")
+		AddRow(int64(4), "4", "target4").
+		AddRow(int64(5), "5", "target5").
+		AddRow(int64(6), "6", "target6")
 
 	d.DBMock.ExpectQuery(query).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnRows(rows)
 }
 
 func (d FakeDependencies) MockUrlGetById() {
 	at, _ := time.Parse(time.RFC3339, "2026-01-29T15:23:24Z")
-	query := "SELECT * FROM urls WHERE id = $1"
+	query := "SELECT id, code, target, domain, owner, created_at, updated_at, disabled_at, expires_at, deleted_at FROM urls WHERE id = $1 AND deleted_at IS NULL"
 
 	rows := sqlmock.NewRows([]string{"id", "code", "target", "created_at", "updated_at"}).
 		AddRow(int64(1), "1", "target1", at, at)
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/cache"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+// CacheRepository evicts cached reads after writes made outside of the
+// request path, so they stop serving the previous state before their TTL.
+type CacheRepository interface {
+	Evict(context.Context, ...cache.CacheKey) error
+	EvictFamily(context.Context, cache.CacheKey) (int64, error)
+}
+
+type CacheStore struct {
+	cache  db.CacheClient
+	logger observability.Logger
+}
+
+func NewCacheRepository(cache db.CacheClient, observer observability.Observer) CacheRepository {
+	return CacheStore{
+		cache:  cache,
+		logger: observer.Logger().With("repository", "cache"),
+	}
+}
+
+// Evict deletes every given key. Keys missing from the cache are ignored.
+func (s CacheStore) Evict(ctx context.Context, keys ...cache.CacheKey) error {
+	var err error
+
+	for _, key := range keys {
+		if errDel := s.cache.Del(ctx, key.String()); errDel != nil && !errors.Is(errDel, db.ErrCacheNotFound) {
+			err = errors.Join(err, errDel)
+		}
+	}
+
+	return err
+}
+
+// EvictFamily deletes every key of the family of key, e.g. every cached
+// page of a listing, and returns how many were deleted.
+func (s CacheStore) EvictFamily(ctx context.Context, key cache.CacheKey) (int64, error) {
+	return s.cache.Purge(ctx, cache.FamilyPrefix(key.Family()))
+}
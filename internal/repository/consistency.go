@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+// ConsistencyRepository issues read-your-writes tokens. A token taken after
+// a write and attached to later reads with db.WithConsistencyToken makes
+// those reads observe the write, even when they are served by a replica.
+type ConsistencyRepository interface {
+	Token(context.Context) (string, error)
+}
+
+type ConsistencyStore struct {
+	memory db.SQLReader
+	logger observability.Logger
+}
+
+func NewConsistencyRepository(memory db.SQLReader, observer observability.Observer) ConsistencyRepository {
+	return ConsistencyStore{
+		memory: memory,
+		logger: observer.Logger().With("repository", "consistency"),
+	}
+}
+
+// Token returns an empty token when reads are not served by replicas, as
+// every read already observes every write.
+func (r ConsistencyStore) Token(ctx context.Context) (string, error) {
+	if reader, ok := r.memory.(db.ConsistentReader); ok {
+		return reader.Token(ctx)
+	}
+
+	return "", nil
+}
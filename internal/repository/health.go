@@ -3,25 +3,37 @@ package repository
 import (
 	"context"
 	"errors"
+	"log/slog"
 
 	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/model"
 	"github.com/zeon-code/tiny-url/internal/pkg/observability"
 )
 
+// HealthRepository checks the dependencies of the service.
+//
+// Ping fails only when the primary database is unreachable, since writes
+// and the replica fallback depend on it. Degraded lists the optional
+// dependencies currently unavailable; the service keeps serving without
+// them. Replicas reports every read replica, when reads use replicas.
 type HealthRepository interface {
 	Ping(context.Context) (string, error)
+	Degraded(context.Context) []string
+	Replicas(context.Context) []model.ReplicaHealth
 }
 
 type HealthStore struct {
 	primary db.SQLClient
 	memory  db.SQLReader
+	cache   db.CacheClient
 	logger  observability.Logger
 }
 
-func NewHealthRepository(primary db.SQLClient, memory db.SQLReader, observer observability.Observer) HealthRepository {
+func NewHealthRepository(primary db.SQLClient, memory db.SQLReader, cache db.CacheClient, observer observability.Observer) HealthRepository {
 	return HealthStore{
 		primary: primary,
 		memory:  memory,
+		cache:   cache,
 		logger:  observer.Logger().With("repository", "health"),
 	}
 }
@@ -31,9 +43,75 @@ func (r HealthStore) Ping(ctx context.Context) (string, error) {
 		return "db_primary_unavailable", errors.New("error dependency not ready")
 	}
 
-	if err := r.memory.Ping(ctx); err != nil {
-		return "db_replica_unavailable", errors.New("error dependency not ready")
+	return "", nil
+}
+
+// Degraded reports replicas from their last background check when reads
+// are balanced across replicas, and pings the reader otherwise.
+func (r HealthStore) Degraded(ctx context.Context) []string {
+	var reasons []string
+
+	if replicas := r.replicas(); replicas != nil {
+		reasons = append(reasons, replicaReasons(replicas)...)
+	} else if err := r.memory.Ping(ctx); err != nil {
+		r.logger.Warn(ctx, "error replica database is not available", slog.Any("error", err))
+		reasons = append(reasons, "db_replica_unavailable")
 	}
 
-	return "", nil
+	if err := r.cache.Ping(ctx); err != nil {
+		r.logger.Warn(ctx, "error cache is not available", slog.Any("error", err))
+		reasons = append(reasons, "cache_unavailable")
+	}
+
+	return reasons
+}
+
+func (r HealthStore) Replicas(ctx context.Context) []model.ReplicaHealth {
+	replicas := r.replicas()
+
+	if replicas == nil {
+		return nil
+	}
+
+	health := make([]model.ReplicaHealth, 0, len(replicas))
+
+	for _, replica := range replicas {
+		health = append(health, model.ReplicaHealth{
+			Name:       replica.Name,
+			Status:     string(replica.State),
+			LagSeconds: replica.Lag.Seconds(),
+		})
+	}
+
+	return health
+}
+
+func (r HealthStore) replicas() []db.ReplicaStatus {
+	if reader, ok := r.memory.(interface{ Replicas() []db.ReplicaStatus }); ok {
+		return reader.Replicas()
+	}
+
+	return nil
+}
+
+// replicaReasons returns one reason per kind of unhealthy replica.
+func replicaReasons(replicas []db.ReplicaStatus) []string {
+	var unavailable, lagging bool
+
+	for _, replica := range replicas {
+		unavailable = unavailable || replica.State == db.ReplicaUnavailable
+		lagging = lagging || replica.State == db.ReplicaLagging
+	}
+
+	var reasons []string
+
+	if unavailable {
+		reasons = append(reasons, "db_replica_unavailable")
+	}
+
+	if lagging {
+		reasons = append(reasons, "db_replica_lagging")
+	}
+
+	return reasons
 }
@@ -3,20 +3,64 @@ package repository_test
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/model"
 	"github.com/zeon-code/tiny-url/internal/pkg/test"
 	"github.com/zeon-code/tiny-url/internal/repository"
 )
 
 func TestHealthService(t *testing.T) {
 	t.Run("ping", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
-		repo := repository.NewHealthRepository(fake.DB(), fake.Memory(), fake.Observer())
+		fake := test.NewFakeDependencies(t)
+		repo := repository.NewHealthRepository(fake.DB(), fake.Memory(), fake.Cache(), fake.Observer())
 
 		reason, err := repo.Ping(context.Background())
 
 		assert.NoError(t, err)
 		assert.Equal(t, "", reason)
+		assert.Empty(t, repo.Degraded(context.Background()))
+		assert.Nil(t, repo.Replicas(context.Background()))
+	})
+
+	t.Run("unavailable cache degrades without failing readiness", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		repo := repository.NewHealthRepository(fake.DB(), fake.Memory(), fake.Cache(), fake.Observer())
+
+		fake.CacheClient.Close()
+		reason, err := repo.Ping(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, "", reason)
+		assert.Equal(t, []string{"cache_unavailable"}, repo.Degraded(context.Background()))
+	})
+
+	t.Run("reports replicas and degrades while one is out of rotation", func(t *testing.T) {
+		fake, healthy, lagging := test.NewFakeDependencies(t), test.NewFakeDependencies(t), test.NewFakeDependencies(t)
+		lagQuery := "SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0 " +
+			"ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END"
+
+		healthy.DBMock.ExpectQuery(lagQuery).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(0.25))
+		lagging.DBMock.ExpectQuery(lagQuery).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(30))
+
+		reader, err := db.NewReplicaReader(fake.DB(), []db.Replica{
+			{Name: "replica-a:5432", Client: healthy.DB()},
+			{Name: "replica-b:5432", Client: lagging.DB()},
+		}, db.ReplicaPolicy{MaxLag: 10 * time.Second, CheckInterval: time.Minute}, fake.Observer())
+		require.NoError(t, err)
+		defer reader.Close()
+
+		memory, _ := db.NewMemoryDatabase(reader, fake.Cache(), fake.Observer())
+		repo := repository.NewHealthRepository(fake.DB(), memory, fake.Cache(), fake.Observer())
+
+		assert.Equal(t, []string{"db_replica_lagging"}, repo.Degraded(context.Background()))
+		assert.Equal(t, []model.ReplicaHealth{
+			{Name: "replica-a:5432", Status: "healthy", LagSeconds: 0.25},
+			{Name: "replica-b:5432", Status: "lagging", LagSeconds: 30},
+		}, repo.Replicas(context.Background()))
 	})
 }
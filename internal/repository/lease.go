@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/cache"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+// LeaseCacheKey is the root of the keys holding leases.
+var LeaseCacheKey = cache.NewCacheKey("job", "lease")
+
+// LeaseRepository grants named leases shared by every instance of the
+// service, so work meant to run once per cluster runs on a single one.
+type LeaseRepository interface {
+	Acquire(context.Context, string, time.Duration) (bool, error)
+}
+
+type LeaseStore struct {
+	cache  db.CacheClient
+	holder string
+	logger observability.Logger
+}
+
+func NewLeaseRepository(cache db.CacheClient, observer observability.Observer) LeaseRepository {
+	holder, err := os.Hostname()
+
+	if err != nil {
+		holder = "unknown"
+	}
+
+	return LeaseStore{
+		cache:  cache,
+		holder: holder,
+		logger: observer.Logger().With("repository", "lease"),
+	}
+}
+
+// Acquire takes the named lease for ttl and reports whether it was granted.
+// A granted lease is never released early: it expires after ttl, which
+// keeps other instances from taking it again within the same period.
+//
+// Leases are as shared as the cache is: a local cache only excludes work
+// within the process.
+func (s LeaseStore) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	return s.cache.SetNX(ctx, s.holder, LeaseCacheKey.With(name).String(), ttl)
+}
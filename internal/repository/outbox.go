@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+// OutboxRepository hands the domain events recorded in the outbox to a
+// publisher and keeps track of the ones delivered.
+type OutboxRepository interface {
+	Claim(context.Context, time.Time, time.Time, int) ([]model.Event, error)
+	Publish(context.Context, model.Event, time.Time, func(context.Context, db.SQLTX) error) error
+	Fail(context.Context, model.Event, time.Time, string, *time.Time) error
+}
+
+// OutboxStore reads the outbox from the primary database: events must be
+// seen as soon as the transaction recording them commits.
+type OutboxStore struct {
+	db     db.SQLClient
+	logger observability.Logger
+}
+
+func NewOutboxRepository(database db.SQLClient, observer observability.Observer) OutboxRepository {
+	return OutboxStore{
+		db:     database,
+		logger: observer.Logger().With("repository", "outbox"),
+	}
+}
+
+// Claim returns up to limit pending events, oldest first, and leases them
+// until leaseUntil, so other relays skip them while they are published and
+// they are published again if the relay stops before marking them. Events
+// held back by a leased or postponed earlier event of their link are left
+// out, so every link sees its events in order; a link's events are always
+// recorded in order, since each one is written while its urls row is
+// locked.
+func (s OutboxStore) Claim(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]model.Event, error) {
+	events := []model.Event{}
+
+	err := s.db.WithTx(ctx, nil, func(tx db.SQLTX) error {
+		events = []model.Event{}
+		query := fmt.Sprintf(
+			"SELECT id, link_id, event_type, payload, attempts, created_at FROM outbox o "+
+				"WHERE published_at IS NULL AND dead_at IS NULL AND (locked_until IS NULL OR locked_until <= $1) "+
+				"AND NOT EXISTS (SELECT 1 FROM outbox earlier WHERE earlier.link_id = o.link_id AND earlier.id < o.id "+
+				"AND earlier.published_at IS NULL AND earlier.dead_at IS NULL AND earlier.locked_until > $1) "+
+				"ORDER BY id LIMIT $2%s",
+			s.db.Dialect().ForUpdate(),
+		)
+
+		if err := tx.Select(ctx, &events, query, now.UTC(), limit); err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			query = "UPDATE outbox SET locked_until = $1 WHERE id = $2"
+
+			if err := tx.Exec(ctx, query, leaseUntil.UTC(), event.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return events, err
+}
+
+// Publish marks an event claimed until leaseUntil published. publishTx,
+// when given, runs in the same transaction, so publishers writing to the
+// database record an event exactly when it is marked published.
+//
+// Returns ErrClaimLost when the lease expired and another relay claimed
+// the event.
+func (s OutboxStore) Publish(ctx context.Context, event model.Event, leaseUntil time.Time, publishTx func(context.Context, db.SQLTX) error) error {
+	return s.db.WithTx(ctx, nil, func(tx db.SQLTX) error {
+		var id int64
+		query := fmt.Sprintf("UPDATE outbox SET published_at = %s, locked_until = NULL WHERE id = $1 AND locked_until = $2 AND published_at IS NULL RETURNING id", s.db.Dialect().Now())
+
+		if err := tx.Get(ctx, &id, query, event.ID, leaseUntil.UTC()); err != nil {
+			if errors.Is(err, db.ErrDBResourceNotFound) {
+				return ErrClaimLost
+			}
+
+			return err
+		}
+
+		if publishTx == nil {
+			return nil
+		}
+
+		return publishTx(ctx, tx)
+	})
+}
+
+// Fail records a failed attempt at publishing an event claimed until
+// leaseUntil. The event is attempted again from retryAt, holding back the
+// later events of its link until then, or, without retryAt, is given up
+// on, letting them through. The later events of the link claimed along
+// with it are released.
+//
+// Returns ErrClaimLost when the lease expired and another relay claimed
+// the event.
+func (s OutboxStore) Fail(ctx context.Context, event model.Event, leaseUntil time.Time, cause string, retryAt *time.Time) error {
+	return s.db.WithTx(ctx, nil, func(tx db.SQLTX) error {
+		var id int64
+		query := fmt.Sprintf("UPDATE outbox SET attempts = $1, last_error = $2, locked_until = NULL, dead_at = %s WHERE id = $3 AND locked_until = $4 AND published_at IS NULL RETURNING id", s.db.Dialect().Now())
+		args := []any{event.Attempts, cause, event.ID, leaseUntil.UTC()}
+
+		if retryAt != nil {
+			query = "UPDATE outbox SET attempts = $1, last_error = $2, locked_until = $3 WHERE id = $4 AND locked_until = $5 AND published_at IS NULL RETURNING id"
+			args = []any{event.Attempts, cause, retryAt.UTC(), event.ID, leaseUntil.UTC()}
+		}
+
+		if err := tx.Get(ctx, &id, query, args...); err != nil {
+			if errors.Is(err, db.ErrDBResourceNotFound) {
+				return ErrClaimLost
+			}
+
+			return err
+		}
+
+		query = "UPDATE outbox SET locked_until = NULL WHERE link_id = $1 AND id > $2 AND locked_until = $3 AND published_at IS NULL"
+		return tx.Exec(ctx, query, event.LinkID, event.ID, leaseUntil.UTC())
+	})
+}
+
+// recordEvent writes an event describing url to the outbox, within the
+// transaction changing it.
+func recordEvent(ctx context.Context, tx db.SQLTX, eventType model.EventType, url model.URL) error {
+	payload, err := json.Marshal(url)
+
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO outbox (link_id, event_type, payload) VALUES ($1, $2, $3)"
+	return tx.Exec(ctx, query, url.ID, eventType, model.EventPayload(payload))
+}
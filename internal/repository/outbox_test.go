@@ -0,0 +1,265 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+	"github.com/zeon-code/tiny-url/internal/repository"
+)
+
+func TestOutboxRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	lease := now.Add(time.Minute)
+	claimQuery := "SELECT id, link_id, event_type, payload, attempts, created_at FROM outbox o " +
+		"WHERE published_at IS NULL AND dead_at IS NULL AND (locked_until IS NULL OR locked_until <= $1) " +
+		"AND NOT EXISTS (SELECT 1 FROM outbox earlier WHERE earlier.link_id = o.link_id AND earlier.id < o.id " +
+		"AND earlier.published_at IS NULL AND earlier.dead_at IS NULL AND earlier.locked_until > $1) " +
+		"ORDER BY id LIMIT $2 FOR UPDATE"
+	leaseQuery := "UPDATE outbox SET locked_until = $1 WHERE id = $2"
+	publishedQuery := "UPDATE outbox SET published_at = now(), locked_until = NULL WHERE id = $1 AND locked_until = $2 AND published_at IS NULL RETURNING id"
+	retryQuery := "UPDATE outbox SET attempts = $1, last_error = $2, locked_until = $3 WHERE id = $4 AND locked_until = $5 AND published_at IS NULL RETURNING id"
+	deadQuery := "UPDATE outbox SET attempts = $1, last_error = $2, locked_until = NULL, dead_at = now() WHERE id = $3 AND locked_until = $4 AND published_at IS NULL RETURNING id"
+	releaseQuery := "UPDATE outbox SET locked_until = NULL WHERE link_id = $1 AND id > $2 AND locked_until = $3 AND published_at IS NULL"
+	columns := []string{"id", "link_id", "event_type", "payload", "attempts", "created_at"}
+
+	// relay claims the pending events and marks them published.
+	relay := func(t *testing.T, repo repository.OutboxRepository) []model.Event {
+		now := time.Now()
+		events, err := repo.Claim(ctx, now, now.Add(time.Minute), 10)
+		require.NoError(t, err)
+
+		for _, event := range events {
+			require.NoError(t, repo.Publish(ctx, event, now.Add(time.Minute), nil))
+		}
+
+		return events
+	}
+
+	t.Run("claims pending events in order", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		repo := repository.NewOutboxRepository(fake.DB(), fake.Observer())
+
+		rows := sqlmock.NewRows(columns).
+			AddRow(int64(1), int64(7), "link.created", []byte(`{"id":7}`), 0, now).
+			AddRow(int64(2), int64(7), "link.updated", []byte(`{"id":7}`), 2, now)
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(claimQuery).WithArgs(now.UTC(), 10).WillReturnRows(rows)
+		fake.DBMock.ExpectExec(leaseQuery).WithArgs(lease.UTC(), int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+		fake.DBMock.ExpectExec(leaseQuery).WithArgs(lease.UTC(), int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+		fake.DBMock.ExpectCommit()
+
+		events, err := repo.Claim(ctx, now, lease, 10)
+
+		assert.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, []int64{1, 2}, []int64{events[0].ID, events[1].ID})
+		assert.Equal(t, model.EventLinkCreated, events[0].Type)
+		assert.JSONEq(t, `{"id":7}`, string(events[0].Payload))
+		assert.Equal(t, 2, events[1].Attempts)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("marks an event published along with the publisher writes", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		repo := repository.NewOutboxRepository(fake.DB(), fake.Observer())
+		event := model.Event{ID: 1, LinkID: 7}
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(publishedQuery).WithArgs(int64(1), lease.UTC()).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(1)))
+		fake.DBMock.ExpectExec("DELETE FROM webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+		fake.DBMock.ExpectCommit()
+
+		err := repo.Publish(ctx, event, lease, func(ctx context.Context, tx db.SQLTX) error {
+			return tx.Exec(ctx, "DELETE FROM webhook_deliveries")
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("reports a claim taken over by another relay", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		repo := repository.NewOutboxRepository(fake.DB(), fake.Observer())
+		event := model.Event{ID: 1, LinkID: 7}
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(publishedQuery).WithArgs(int64(1), lease.UTC()).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		fake.DBMock.ExpectRollback()
+
+		called := false
+		err := repo.Publish(ctx, event, lease, func(ctx context.Context, tx db.SQLTX) error {
+			called = true
+			return nil
+		})
+
+		assert.ErrorIs(t, err, repository.ErrClaimLost)
+		assert.False(t, called)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("postpones a failed event and releases its link", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		repo := repository.NewOutboxRepository(fake.DB(), fake.Observer())
+		event := model.Event{ID: 1, LinkID: 7, Attempts: 1}
+		retryAt := now.Add(time.Second)
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(retryQuery).WithArgs(1, "sink down", retryAt.UTC(), int64(1), lease.UTC()).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(1)))
+		fake.DBMock.ExpectExec(releaseQuery).WithArgs(int64(7), int64(1), lease.UTC()).WillReturnResult(sqlmock.NewResult(0, 1))
+		fake.DBMock.ExpectCommit()
+
+		assert.NoError(t, repo.Fail(ctx, event, lease, "sink down", &retryAt))
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("gives up on an event without a retry", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		repo := repository.NewOutboxRepository(fake.DB(), fake.Observer())
+		event := model.Event{ID: 1, LinkID: 7, Attempts: 20}
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(deadQuery).WithArgs(20, "sink down", int64(1), lease.UTC()).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(1)))
+		fake.DBMock.ExpectExec(releaseQuery).WithArgs(int64(7), int64(1), lease.UTC()).WillReturnResult(sqlmock.NewResult(0, 1))
+		fake.DBMock.ExpectCommit()
+
+		assert.NoError(t, repo.Fail(ctx, event, lease, "sink down", nil))
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("records link changes and relays them on SQLite", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		client := test.NewSQLiteClient(t)
+
+		urls := repository.NewURLRepository(client, client, fake.Observer())
+		outbox := repository.NewOutboxRepository(client, fake.Observer())
+
+		created, err := urls.Create(ctx, "https://example.com", "", nil)
+		require.NoError(t, err)
+		require.NoError(t, urls.Disable(ctx, created.ID))
+		require.NoError(t, urls.Delete(ctx, created.ID))
+
+		received := relay(t, outbox)
+		require.Len(t, received, 3)
+
+		types := []model.EventType{}
+
+		for _, event := range received {
+			assert.Equal(t, created.ID, event.LinkID)
+			types = append(types, event.Type)
+		}
+
+		assert.Equal(t, []model.EventType{model.EventLinkCreated, model.EventLinkUpdated, model.EventLinkDeleted}, types)
+		assert.Contains(t, string(received[0].Payload), `"target":"https://example.com"`)
+		assert.Contains(t, string(received[1].Payload), `"disabled_at"`)
+
+		assert.Empty(t, relay(t, outbox))
+	})
+
+	t.Run("holds back a link behind a claimed or postponed event on SQLite", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		client := test.NewSQLiteClient(t)
+
+		urls := repository.NewURLRepository(client, client, fake.Observer())
+		outbox := repository.NewOutboxRepository(client, fake.Observer())
+
+		first, err := urls.Create(ctx, "https://example.com/first", "", nil)
+		require.NoError(t, err)
+		require.NoError(t, urls.Delete(ctx, first.ID))
+
+		now := time.Now()
+		lease := now.Add(time.Minute)
+		claimed, err := outbox.Claim(ctx, now, lease, 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 2)
+
+		again, err := outbox.Claim(ctx, now, now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		assert.Empty(t, again, "leased events are skipped")
+
+		claimed[0].Attempts++
+		retryAt := now.Add(time.Hour)
+		require.NoError(t, outbox.Fail(ctx, claimed[0], lease, "sink down", &retryAt))
+		assert.ErrorIs(t, outbox.Publish(ctx, claimed[1], lease, nil), repository.ErrClaimLost, "the failure releases the later events")
+
+		second, err := urls.Create(ctx, "https://example.com/second", "", nil)
+		require.NoError(t, err)
+
+		again, err = outbox.Claim(ctx, now, now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, again, 1, "the link of the postponed event stays held back")
+		assert.Equal(t, second.ID, again[0].LinkID)
+		require.NoError(t, outbox.Publish(ctx, again[0], now.Add(time.Minute), nil))
+
+		later := retryAt.Add(time.Second)
+		again, err = outbox.Claim(ctx, later, later.Add(time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, again, 2)
+		assert.Equal(t, claimed[0].ID, again[0].ID)
+		assert.Equal(t, 1, again[0].Attempts)
+
+		again[0].Attempts++
+		require.NoError(t, outbox.Fail(ctx, again[0], later.Add(time.Minute), "sink down", nil))
+
+		again, err = outbox.Claim(ctx, later, later.Add(time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, again, 1, "giving up on an event lets its link through")
+		assert.Equal(t, claimed[1].ID, again[0].ID)
+	})
+
+	t.Run("records an event when clicks reach a threshold", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		client := test.NewSQLiteClient(t)
+
+		urls := repository.NewURLRepository(client, client, fake.Observer())
+		outbox := repository.NewOutboxRepository(client, fake.Observer())
+
+		created, err := urls.Create(ctx, "https://example.com", "acme", nil)
+		require.NoError(t, err)
+
+		for i := range 11 {
+			clicks, err := urls.Click(ctx, created.ID, 1)
+			require.NoError(t, err)
+			assert.Equal(t, int64(i+1), clicks)
+		}
+
+		clicks, err := urls.Click(ctx, created.ID, 1_000)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1_011), clicks)
+
+		received := relay(t, outbox)
+		require.Len(t, received, 4)
+		assert.Equal(t, model.EventLinkClickThreshold, received[1].Type)
+		assert.Contains(t, string(received[1].Payload), `"clicks":10`)
+		assert.Contains(t, string(received[1].Payload), `"owner":"acme"`)
+		assert.Contains(t, string(received[2].Payload), `"clicks":100`, "every threshold crossed at once is recorded")
+		assert.Contains(t, string(received[3].Payload), `"clicks":1000`)
+
+		url, err := urls.GetByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Zero(t, url.Clicks, "link reads leave the click count out")
+
+		_, err = urls.Click(ctx, 42, 1)
+		assert.ErrorIs(t, err, db.ErrDBResourceNotFound)
+	})
+
+	t.Run("does not record events for rolled back changes", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		client := test.NewSQLiteClient(t)
+
+		urls := repository.NewURLRepository(client, client, fake.Observer())
+		outbox := repository.NewOutboxRepository(client, fake.Observer())
+
+		assert.ErrorIs(t, urls.Delete(ctx, 42), db.ErrDBResourceNotFound)
+
+		assert.Empty(t, relay(t, outbox))
+	})
+}
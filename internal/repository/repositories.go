@@ -1,16 +1,30 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
 
 	"github.com/zeon-code/tiny-url/internal/db"
 	"github.com/zeon-code/tiny-url/internal/pkg/config"
 	"github.com/zeon-code/tiny-url/internal/pkg/observability"
 )
 
+// ErrClaimLost is returned when the outcome of a claimed item is recorded
+// after its lease expired and another worker claimed it.
+var ErrClaimLost = errors.New("error claim lost to another worker")
+
 type Repositories struct {
-	Url    URLRepository
-	Health HealthRepository
+	Url         URLRepository
+	Health      HealthRepository
+	Consistency ConsistencyRepository
+	Outbox      OutboxRepository
+	Webhook     WebhookRepository
+	Cache       CacheRepository
+	Lease       LeaseRepository
 
 	database db.SQLClient
 	memory   db.SQLReader
@@ -38,19 +52,23 @@ func (r Repositories) Shutdown() error {
 // provided application configuration and logger.
 //
 // It initializes metric, cache, primary database, and replica database clients.
-// If the replica database configuration is missing or fails to initialize, the
-// primary database client is used as a fallback to ensure read availability.
+// If every replica database configuration is missing, fails to initialize or
+// points at the primary database, the primary database client is used for
+// reads as well. Otherwise reads are balanced across the replicas, falling
+// back to the primary while every replica is unhealthy or lagging, or has
+// not replayed the write a consistency token refers to.
+//
+// Reads failing with a transient error, such as a dropped connection, are
+// retried with backoff below the cache.
 //
-// The function panics if critical dependencies (cache or primary database)
-// cannot be created, as the application cannot operate without them.
+// The cache is optional: when it cannot be reached, reads bypass it while it
+// reconnects in the background. The function panics if the primary database
+// or memory client cannot be created, as the application cannot operate
+// without them.
 //
 // Returns a fully initialized Repositories instance
 func NewRepositoriesFromConfig(conf config.Configuration, observer observability.Observer) Repositories {
-	cache, err := db.NewCacheClient(conf.Cache(), observer)
-
-	if err != nil {
-		panic("error building cache client: " + err.Error())
-	}
+	cache := db.NewReconnectingCacheClientFromConfig(conf.Cache(), observer)
 
 	primary, err := db.NewDBClient(conf.PrimaryDatabase(), observer)
 
@@ -59,19 +77,88 @@ func NewRepositoriesFromConfig(conf config.Configuration, observer observability
 		panic("error building primary database client: " + err.Error())
 	}
 
-	replica, err := db.NewDBClient(conf.ReplicaDatabase(), observer)
+	reader := newReplicaReader(primary, conf.PrimaryDatabase(), conf.ReplicaDatabases(), conf.Replication(), observer)
+	reader = db.NewRetryingReader(reader, db.DefaultRetryPolicy, observer)
+
+	memory, err := db.NewMemoryDatabase(reader, cache, observer)
 
 	if err != nil {
-		replica = primary
+		panic("error building memory client" + err.Error())
+	}
+
+	return NewRepositories(primary, memory, cache, observer)
+}
+
+// newReplicaReader balances reads across every replica that can be built.
+// Replicas pointing at the primary are skipped, and replicas failing to
+// initialize are logged and skipped. Without any usable replica, or with
+// invalid replication settings, reads are served by the primary.
+func newReplicaReader(primary db.SQLClient, primaryConf config.DatabaseConfiguration, confs []config.DatabaseConfiguration, replication config.ReplicationConfiguration, observer observability.Observer) db.SQLReader {
+	ctx := context.Background()
+	logger := observer.Logger().With("repository", "repositories")
+	replicas := []db.Replica{}
+
+	for i, conf := range confs {
+		if sameDatabase(primaryConf, conf) {
+			continue
+		}
+
+		name := replicaName(conf, i)
+		client, err := db.NewDBClient(conf, observer)
+
+		if err != nil {
+			logger.Warn(ctx, "error building replica client, skipping it", slog.String("replica", name), slog.Any("error", err))
+			continue
+		}
+
+		replicas = append(replicas, db.Replica{Name: name, Client: client})
 	}
 
-	memory, err := db.NewMemoryDatabase(replica, cache, observer)
+	if len(replicas) == 0 {
+		return primary
+	}
+
+	reader, err := db.NewReplicaReaderFromConfig(primary, replicas, replication, observer)
 
 	if err != nil {
-		panic("error building memory client" + err.Error())
+		logger.Warn(ctx, "error building replica reader, reading from primary", slog.Any("error", err))
+
+		for _, replica := range replicas {
+			replica.Client.Close()
+		}
+
+		return primary
 	}
 
-	return NewRepositories(primary, memory, observer)
+	return reader
+}
+
+// replicaName identifies a replica by its address when it has one, which
+// keeps logs, metrics and readiness reports readable.
+func replicaName(conf config.DatabaseConfiguration, index int) string {
+	if addressable, ok := conf.(interface {
+		Host() (string, error)
+		Port() (int, error)
+	}); ok {
+		host, errHost := addressable.Host()
+		port, errPort := addressable.Port()
+
+		if errHost == nil && errPort == nil {
+			return net.JoinHostPort(host, strconv.Itoa(port))
+		}
+	}
+
+	return fmt.Sprintf("replica-%d", index+1)
+}
+
+// sameDatabase reports whether both configurations point at the same
+// database, in which case a single client is shared. This is always the case
+// for SQLite, which has no replicas.
+func sameDatabase(a config.DatabaseConfiguration, b config.DatabaseConfiguration) bool {
+	dsnA, errA := a.DSN()
+	dsnB, errB := b.DSN()
+
+	return errA == nil && errB == nil && a.Driver() == b.Driver() && dsnA == dsnB
 }
 
 // NewRepositories constructs a Repositories container using the provided
@@ -79,10 +166,17 @@ func NewRepositoriesFromConfig(conf config.Configuration, observer observability
 //
 // The database client is used for write operations, while the memory client
 // (typically backed by cache and/or replicas) is used for read operations.
-func NewRepositories(primary db.SQLClient, memory db.SQLReader, observer observability.Observer) Repositories {
+// The cache client is checked for health and used to evict entries and
+// grant job leases; it is owned by memory.
+func NewRepositories(primary db.SQLClient, memory db.SQLReader, cache db.CacheClient, observer observability.Observer) Repositories {
 	return Repositories{
-		Url:    NewURLRepository(primary, memory, observer),
-		Health: NewHealthRepository(primary, memory, observer),
+		Url:         NewURLRepository(primary, memory, observer),
+		Health:      NewHealthRepository(primary, memory, cache, observer),
+		Consistency: NewConsistencyRepository(memory, observer),
+		Outbox:      NewOutboxRepository(primary, observer),
+		Webhook:     NewWebhookRepository(primary, observer),
+		Cache:       NewCacheRepository(cache, observer),
+		Lease:       NewLeaseRepository(cache, observer),
 
 		database: primary,
 		memory:   memory,
@@ -10,8 +10,8 @@ import (
 
 func TestRepositories(t *testing.T) {
 	t.Run("Should define url repository", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
-		repositories := repository.NewRepositories(fake.DB(), fake.Memory(), fake.Observer())
+		fake := test.NewFakeDependencies(t)
+		repositories := repository.NewRepositories(fake.DB(), fake.Memory(), fake.Cache(), fake.Observer())
 
 		assert.NotNil(t, repositories.Url)
 	})
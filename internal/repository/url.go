@@ -1,21 +1,34 @@
 package repository
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/zeon-code/tiny-url/internal/db"
 	"github.com/zeon-code/tiny-url/internal/model"
 	"github.com/zeon-code/tiny-url/internal/pkg/base62"
 	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/pkg/pagination"
 )
 
 type URLRepository interface {
-	Create(context.Context, string) (*model.URL, error)
-	List(context.Context, int, string, *int64) ([]model.URL, error)
+	Create(context.Context, string, string, *time.Time) (*model.URL, error)
+	List(context.Context, pagination.Query, model.URLFilter) ([]model.URL, error)
 	GetByID(context.Context, int64) (*model.URL, error)
+	Disable(context.Context, int64) error
+	Delete(context.Context, int64) error
+	Expire(context.Context, time.Time, int) ([]model.URL, error)
+	Purge(context.Context, time.Time, int) ([]int64, error)
+	Click(context.Context, int64, int64) (int64, error)
 }
 
+// URLStore persists links in any SQLClient. Queries use $N placeholders and
+// RETURNING, which every supported dialect accepts; the remaining
+// differences are resolved through db.Dialect.
 type URLStore struct {
 	db     db.SQLClient
 	memory db.SQLReader
@@ -30,60 +43,125 @@ func NewURLRepository(database db.SQLClient, memory db.SQLReader, observer obser
 	}
 }
 
-func (s URLStore) Create(ctx context.Context, target string) (*model.URL, error) {
-	tx, err := s.db.BeginTx(ctx, nil)
+// Create inserts the link on behalf of owner, which may be empty, and
+// assigns its code in a single transaction, which also records a
+// link.created event in the outbox. Links without expiresAt never expire.
+func (s URLStore) Create(ctx context.Context, target string, owner string, expiresAt *time.Time) (*model.URL, error) {
+	var url model.URL
 
-	if err != nil {
-		return nil, err
+	if expiresAt != nil {
+		utc := expiresAt.UTC()
+		expiresAt = &utc
 	}
 
-	var url model.URL
-	query := "INSERT INTO urls (target, code) VALUES ($1, '') RETURNING id, target, code, created_at, updated_at"
+	err := s.db.WithTx(ctx, nil, func(tx db.SQLTX) error {
+		query := "INSERT INTO urls (target, code, owner, expires_at, domain) VALUES ($1, '', $2, $3, $4) RETURNING id, target, code, owner, created_at, updated_at, expires_at"
 
-	if err := tx.Get(ctx, &url, query, target); err != nil {
-		tx.Rollback()
-		return nil, err
-	}
+		if err := tx.Get(ctx, &url, query, target, owner, expiresAt, model.TargetDomain(target)); err != nil {
+			return err
+		}
 
-	query = "UPDATE urls SET code = $1 WHERE id = $2"
-	url.Code = base62.Encode(url.ID)
+		query = "UPDATE urls SET code = $1 WHERE id = $2"
+		url.Code = base62.Encode(url.ID)
 
-	if err := tx.Exec(ctx, query, url.Code, url.ID); err != nil {
-		tx.Rollback()
-		return nil, err
-	}
+		if err := tx.Exec(ctx, query, url.Code, url.ID); err != nil {
+			return err
+		}
 
-	if err := tx.Commit(); err != nil {
-		tx.Rollback()
+		return recordEvent(ctx, tx, model.EventLinkCreated, url)
+	})
+
+	if err != nil {
 		return nil, err
 	}
 
 	return &url, nil
 }
 
-func (s URLStore) List(ctx context.Context, limit int, direction string, cursor *int64) ([]model.URL, error) {
-	var err error
+// List reads the links matching filter past the cursor of page, up to
+// page.Fetch() of them in scan order, nearest to the cursor first. Wrap
+// them with pagination.NewPagination to get the page in listing order.
+func (s URLStore) List(ctx context.Context, page pagination.Query, filter model.URLFilter) ([]model.URL, error) {
 	urls := []model.URL{}
-	query := "SELECT id, code, target FROM urls"
+	conditions, args := s.filterConditions(filter)
+	query := "SELECT id, code, target FROM urls WHERE deleted_at IS NULL" + conditions
+	order := "DESC"
 
-	if cursor != nil {
-		query = fmt.Sprintf("%s WHERE id %s $1 ORDER BY id DESC LIMIT $2", query, direction)
-		err = s.memory.Select(ctx, &urls, query, cursor, limit)
-	} else {
-		query = fmt.Sprintf("%s ORDER BY id DESC LIMIT $1 ", query)
-		err = s.memory.Select(ctx, &urls, query, limit)
+	if page.Scan() == pagination.Ascending {
+		order = "ASC"
 	}
 
-	if err != nil {
+	if page.Key != nil {
+		args = append(args, *page.Key)
+		query = fmt.Sprintf("%s AND id %s $%d", query, page.Operator(), len(args))
+	}
+
+	args = append(args, page.Fetch())
+	query = fmt.Sprintf("%s ORDER BY id %s LIMIT $%d", query, order, len(args))
+
+	if err := s.memory.Select(ctx, &urls, query, args...); err != nil {
 		return urls, err
 	}
 
 	return urls, nil
 }
 
+// filterConditions returns the conditions narrowing a listing to filter,
+// each prefixed with AND, along with their arguments. Times are bound in
+// UTC, which SQLite compares as text.
+func (s URLStore) filterConditions(filter model.URLFilter) (string, []any) {
+	var conditions strings.Builder
+	args := []any{}
+	dialect := s.db.Dialect()
+
+	bind := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Query != "" {
+		fmt.Fprintf(&conditions, " AND %s", dialect.Contains("target", bind(db.EscapeLike(filter.Query))))
+	}
+
+	if filter.Domain != "" {
+		domain := strings.ToLower(filter.Domain)
+		fmt.Fprintf(&conditions, " AND (domain = %s OR domain LIKE %s ESCAPE '\\')", bind(domain), bind("%."+db.EscapeLike(domain)))
+	}
+
+	if filter.CodePrefix != "" {
+		fmt.Fprintf(&conditions, " AND %s", dialect.HasPrefix("code", bind(filter.CodePrefix)))
+	}
+
+	if filter.Owner != "" {
+		fmt.Fprintf(&conditions, " AND owner = %s", bind(filter.Owner))
+	}
+
+	if filter.CreatedAfter != nil {
+		fmt.Fprintf(&conditions, " AND created_at >= %s", bind(filter.CreatedAfter.UTC()))
+	}
+
+	if filter.CreatedBefore != nil {
+		fmt.Fprintf(&conditions, " AND created_at < %s", bind(filter.CreatedBefore.UTC()))
+	}
+
+	switch filter.Status {
+	case model.URLActive:
+		fmt.Fprintf(&conditions, " AND disabled_at IS NULL AND (expires_at IS NULL OR expires_at > %s)", bind(time.Now().UTC()))
+	case model.URLDisabled:
+		fmt.Fprintf(&conditions, " AND disabled_at IS NOT NULL AND (expires_at IS NULL OR expires_at > %s)", bind(time.Now().UTC()))
+	case model.URLExpired:
+		fmt.Fprintf(&conditions, " AND expires_at <= %s", bind(time.Now().UTC()))
+	}
+
+	return conditions.String(), args
+}
+
+// GetByID reads the link without its click count, which changes on every
+// redirect without changing the link, so cached reads and their validators
+// stay valid.
 func (s URLStore) GetByID(ctx context.Context, id int64) (*model.URL, error) {
 	var url model.URL
-	query := "SELECT * FROM urls WHERE id = $1"
+	query := "SELECT id, code, target, domain, owner, created_at, updated_at, disabled_at, expires_at, deleted_at FROM urls WHERE id = $1 AND deleted_at IS NULL"
 
 	if err := s.memory.Get(ctx, &url, query, id); err != nil {
 		return nil, err
@@ -91,3 +169,140 @@ func (s URLStore) GetByID(ctx context.Context, id int64) (*model.URL, error) {
 
 	return &url, nil
 }
+
+// Disable marks the link as disabled so it no longer redirects, while
+// keeping it visible to the management API. A link.updated event is
+// recorded in the same transaction.
+//
+// Returns db.ErrDBResourceNotFound when the link does not exist or was deleted.
+func (s URLStore) Disable(ctx context.Context, id int64) error {
+	now := s.db.Dialect().Now()
+	query := fmt.Sprintf("UPDATE urls SET disabled_at = %s, updated_at = %s WHERE id = $1 AND deleted_at IS NULL RETURNING %s", now, now, urlColumns)
+
+	return s.mutate(ctx, model.EventLinkUpdated, query, id)
+}
+
+// Delete soft-deletes the link. Deleted links are hidden from every read
+// path and are purged permanently by the retention job. A link.deleted
+// event is recorded in the same transaction.
+//
+// Returns db.ErrDBResourceNotFound when the link does not exist or was
+// already deleted.
+func (s URLStore) Delete(ctx context.Context, id int64) error {
+	now := s.db.Dialect().Now()
+	query := fmt.Sprintf("UPDATE urls SET deleted_at = %s, updated_at = %s WHERE id = $1 AND deleted_at IS NULL RETURNING %s", now, now, urlColumns)
+
+	return s.mutate(ctx, model.EventLinkDeleted, query, id)
+}
+
+// Expire disables up to limit active links whose expiry passed at now,
+// recording a link.expired event for each of them in the same transaction.
+// It returns the expired links, in ID order.
+func (s URLStore) Expire(ctx context.Context, now time.Time, limit int) ([]model.URL, error) {
+	var urls []model.URL
+
+	err := s.db.WithTx(ctx, nil, func(tx db.SQLTX) error {
+		urls = []model.URL{}
+		query := fmt.Sprintf(
+			"UPDATE urls SET disabled_at = $1, updated_at = $1 WHERE id IN "+
+				"(SELECT id FROM urls WHERE expires_at <= $1 AND disabled_at IS NULL AND deleted_at IS NULL ORDER BY id LIMIT $2) RETURNING %s",
+			urlColumns,
+		)
+
+		if err := tx.Select(ctx, &urls, query, now.UTC(), limit); err != nil {
+			return err
+		}
+
+		slices.SortFunc(urls, func(a, b model.URL) int { return cmp.Compare(a.ID, b.ID) })
+
+		for _, url := range urls {
+			if err := recordEvent(ctx, tx, model.EventLinkExpired, url); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// Purge permanently removes up to limit links soft-deleted before the given
+// time and returns their IDs. Their link.deleted event was recorded when
+// they were deleted, so purging records none.
+func (s URLStore) Purge(ctx context.Context, before time.Time, limit int) ([]int64, error) {
+	ids := []int64{}
+	query := "DELETE FROM urls WHERE id IN " +
+		"(SELECT id FROM urls WHERE deleted_at IS NOT NULL AND deleted_at < $1 ORDER BY id LIMIT $2) RETURNING id"
+
+	err := s.db.WithTx(ctx, nil, func(tx db.SQLTX) error {
+		return tx.Select(ctx, &ids, query, before.UTC(), limit)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Click adds clicks to the click count of the link and returns the new
+// count. A link.click_threshold event is recorded in the same transaction
+// for every one of model.ClickThresholds the count reached, carrying that
+// threshold as its count. The link's updated_at is left as is, as clicks
+// do not change it.
+//
+// Returns db.ErrDBResourceNotFound when the link does not exist or was deleted.
+func (s URLStore) Click(ctx context.Context, id int64, clicks int64) (int64, error) {
+	var url model.URL
+	query := fmt.Sprintf("UPDATE urls SET clicks = clicks + $1 WHERE id = $2 AND deleted_at IS NULL RETURNING %s, clicks", urlColumns)
+
+	err := s.db.WithTx(ctx, nil, func(tx db.SQLTX) error {
+		if err := tx.Get(ctx, &url, query, clicks, id); err != nil {
+			return err
+		}
+
+		for _, threshold := range model.ClickThresholds {
+			if url.Clicks-clicks >= threshold || url.Clicks < threshold {
+				continue
+			}
+
+			reached := url
+			reached.Clicks = threshold
+
+			if err := recordEvent(ctx, tx, model.EventLinkClickThreshold, reached); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return url.Clicks, nil
+}
+
+// urlColumns lists the columns returned by mutations to describe the link
+// in its event.
+const urlColumns = "id, code, target, owner, created_at, updated_at, disabled_at, expires_at"
+
+// mutate runs a single-row update returning urlColumns and records the
+// resulting link in an event of the given type.
+func (s URLStore) mutate(ctx context.Context, eventType model.EventType, query string, args ...any) error {
+	return s.db.WithTx(ctx, nil, func(tx db.SQLTX) error {
+		var url model.URL
+
+		if err := tx.Get(ctx, &url, query, args...); err != nil {
+			return err
+		}
+
+		return recordEvent(ctx, tx, eventType, url)
+	})
+}
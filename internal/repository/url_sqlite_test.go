@@ -0,0 +1,245 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/pagination"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+	"github.com/zeon-code/tiny-url/internal/repository"
+)
+
+func TestUrlRepositoryOnSQLite(t *testing.T) {
+	ctx := context.Background()
+
+	newRepository := func(t *testing.T) (repository.URLRepository, db.SQLClient) {
+		fake := test.NewFakeDependencies(t)
+		client := test.NewSQLiteClient(t)
+
+		memory, err := db.NewMemoryDatabase(client, fake.Cache(), fake.Observer())
+		assert.NoError(t, err)
+
+		return repository.NewURLRepository(client, memory, fake.Observer()), client
+	}
+
+	t.Run("create and get url", func(t *testing.T) {
+		repo, _ := newRepository(t)
+
+		created, err := repo.Create(ctx, "https://example.com", "", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), created.ID)
+		assert.Equal(t, "1", created.Code)
+		assert.NotNil(t, created.CreatedAt)
+
+		url, err := repo.GetByID(ctx, created.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com", url.Target)
+		assert.Equal(t, "1", url.Code)
+		assert.True(t, url.IsActive())
+	})
+
+	t.Run("list urls with cursor", func(t *testing.T) {
+		repo, _ := newRepository(t)
+
+		for _, target := range []string{"https://a.com", "https://b.com", "https://c.com", "https://d.com", "https://e.com"} {
+			_, err := repo.Create(ctx, target, "", nil)
+			assert.NoError(t, err)
+		}
+
+		list := func(page pagination.Query) ([]int64, bool, bool) {
+			urls, err := repo.List(ctx, page, model.URLFilter{})
+			assert.NoError(t, err)
+
+			listing := pagination.NewPagination(urls, page, "")
+			ids := []int64{}
+
+			for _, url := range listing.Items {
+				ids = append(ids, url.ID)
+			}
+
+			return ids, listing.HasPrevious(), listing.HasNext()
+		}
+
+		page := func(direction pagination.Direction, key int64) pagination.Query {
+			return pagination.Query{Limit: 2, Order: pagination.Descending, Direction: direction, Key: &key}
+		}
+
+		ids, previous, next := list(pagination.Query{Limit: 2, Order: pagination.Descending, Direction: pagination.Forward})
+		assert.Equal(t, []int64{5, 4}, ids)
+		assert.False(t, previous)
+		assert.True(t, next)
+
+		ids, previous, next = list(page(pagination.Forward, 4))
+		assert.Equal(t, []int64{3, 2}, ids)
+		assert.True(t, previous)
+		assert.True(t, next)
+
+		ids, previous, next = list(page(pagination.Forward, 2))
+		assert.Equal(t, []int64{1}, ids)
+		assert.True(t, previous)
+		assert.False(t, next)
+
+		ids, previous, next = list(page(pagination.Backward, 1))
+		assert.Equal(t, []int64{3, 2}, ids, "the page right before the cursor")
+		assert.True(t, previous)
+		assert.True(t, next)
+
+		ids, previous, next = list(page(pagination.Backward, 3))
+		assert.Equal(t, []int64{5, 4}, ids)
+		assert.False(t, previous)
+		assert.True(t, next)
+
+		ascending := page(pagination.Backward, 3)
+		ascending.Order = pagination.Ascending
+
+		ids, previous, next = list(ascending)
+		assert.Equal(t, []int64{1, 2}, ids)
+		assert.False(t, previous)
+		assert.True(t, next)
+	})
+
+	t.Run("disable and delete url", func(t *testing.T) {
+		repo, _ := newRepository(t)
+
+		created, err := repo.Create(ctx, "https://example.com", "", nil)
+		assert.NoError(t, err)
+
+		assert.NoError(t, repo.Disable(ctx, created.ID))
+
+		url, err := repo.GetByID(ctx, created.ID)
+		assert.NoError(t, err)
+		assert.False(t, url.IsActive())
+
+		assert.NoError(t, repo.Delete(ctx, created.ID))
+		assert.ErrorIs(t, repo.Delete(ctx, created.ID), db.ErrDBResourceNotFound)
+
+		_, err = repo.GetByID(ctx, created.ID)
+		assert.ErrorIs(t, err, db.ErrDBResourceNotFound)
+	})
+
+	t.Run("expire links past their expiry", func(t *testing.T) {
+		repo, client := newRepository(t)
+		now := time.Now()
+		soon, later := now.Add(time.Hour), now.Add(3*time.Hour)
+
+		expiring, err := repo.Create(ctx, "https://example.com/soon", "", &soon)
+		assert.NoError(t, err)
+		_, err = repo.Create(ctx, "https://example.com/later", "", &later)
+		assert.NoError(t, err)
+		_, err = repo.Create(ctx, "https://example.com/never", "", nil)
+		assert.NoError(t, err)
+
+		expired, err := repo.Expire(ctx, now, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, expired)
+
+		expired, err = repo.Expire(ctx, now.Add(2*time.Hour), 10)
+		assert.NoError(t, err)
+		assert.Len(t, expired, 1)
+		assert.Equal(t, expiring.ID, expired[0].ID)
+
+		url, err := repo.GetByID(ctx, expiring.ID)
+		assert.NoError(t, err)
+		assert.NotNil(t, url.DisabledAt)
+		assert.True(t, url.IsExpired(now.Add(2*time.Hour)))
+
+		expired, err = repo.Expire(ctx, now.Add(2*time.Hour), 10)
+		assert.NoError(t, err)
+		assert.Empty(t, expired)
+
+		var events []model.EventType
+		assert.NoError(t, client.Select(ctx, &events, "SELECT event_type FROM outbox WHERE link_id = $1 ORDER BY id", expiring.ID))
+		assert.Equal(t, []model.EventType{model.EventLinkCreated, model.EventLinkExpired}, events)
+	})
+
+	t.Run("expire links in batches", func(t *testing.T) {
+		repo, _ := newRepository(t)
+		expiresAt := time.Now().Add(time.Hour)
+
+		for range 3 {
+			_, err := repo.Create(ctx, "https://example.com", "", &expiresAt)
+			assert.NoError(t, err)
+		}
+
+		expired, err := repo.Expire(ctx, expiresAt, 2)
+		assert.NoError(t, err)
+		assert.Len(t, expired, 2)
+
+		expired, err = repo.Expire(ctx, expiresAt, 2)
+		assert.NoError(t, err)
+		assert.Len(t, expired, 1)
+	})
+
+	t.Run("purge links deleted before the retention", func(t *testing.T) {
+		repo, _ := newRepository(t)
+
+		deleted, err := repo.Create(ctx, "https://example.com/deleted", "", nil)
+		assert.NoError(t, err)
+		kept, err := repo.Create(ctx, "https://example.com/kept", "", nil)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Delete(ctx, deleted.ID))
+
+		purged, err := repo.Purge(ctx, time.Now().Add(-time.Hour), 10)
+		assert.NoError(t, err)
+		assert.Empty(t, purged)
+
+		purged, err = repo.Purge(ctx, time.Now().Add(time.Second), 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{deleted.ID}, purged)
+
+		_, err = repo.GetByID(ctx, kept.ID)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, repo.Delete(ctx, deleted.ID), db.ErrDBResourceNotFound)
+	})
+
+	t.Run("search urls with filters", func(t *testing.T) {
+		repo, client := newRepository(t)
+		expiresAt := time.Now().Add(time.Hour)
+
+		for _, link := range []struct{ target, owner string }{
+			{"https://Example.com/docs/Guide", "acme"},
+			{"https://blog.example.com/posts/a_b", "acme"},
+			{"https://example.org/docs", "globex"},
+			{"https://notexample.com/", "globex"},
+		} {
+			_, err := repo.Create(ctx, link.target, link.owner, &expiresAt)
+			assert.NoError(t, err)
+		}
+
+		assert.NoError(t, repo.Disable(ctx, 3))
+		assert.NoError(t, client.Exec(ctx, "UPDATE urls SET created_at = $1, expires_at = $1 WHERE id = 4", time.Now().Add(-48*time.Hour).UTC()))
+
+		ids := func(filter model.URLFilter) []int64 {
+			urls, err := repo.List(ctx, pagination.Query{Limit: 10}, filter)
+			assert.NoError(t, err)
+
+			found := []int64{}
+
+			for _, url := range urls {
+				found = append(found, url.ID)
+			}
+
+			return found
+		}
+
+		dayAgo := time.Now().Add(-24 * time.Hour)
+
+		assert.Equal(t, []int64{3, 1}, ids(model.URLFilter{Query: "DOCS"}))
+		assert.Equal(t, []int64{2}, ids(model.URLFilter{Query: "a_b"}))
+		assert.Empty(t, ids(model.URLFilter{Query: "s_a"}), "wildcards match literally")
+		assert.Equal(t, []int64{2, 1}, ids(model.URLFilter{Domain: "Example.com"}))
+		assert.Equal(t, []int64{2}, ids(model.URLFilter{Domain: "blog.example.com"}))
+		assert.Equal(t, []int64{1}, ids(model.URLFilter{CodePrefix: "1"}))
+		assert.Equal(t, []int64{4, 3}, ids(model.URLFilter{Owner: "globex"}))
+		assert.Equal(t, []int64{3, 2, 1}, ids(model.URLFilter{CreatedAfter: &dayAgo}))
+		assert.Equal(t, []int64{4}, ids(model.URLFilter{CreatedBefore: &dayAgo}))
+		assert.Equal(t, []int64{2, 1}, ids(model.URLFilter{Status: model.URLActive}))
+		assert.Equal(t, []int64{3}, ids(model.URLFilter{Status: model.URLDisabled}))
+		assert.Equal(t, []int64{4}, ids(model.URLFilter{Status: model.URLExpired}))
+		assert.Equal(t, []int64{3}, ids(model.URLFilter{Owner: "globex", Query: "docs", Status: model.URLDisabled}))
+	})
+}
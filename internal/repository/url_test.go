@@ -6,20 +6,24 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/zeon-code/tiny-url/internal/db"
 	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/pagination"
 	"github.com/zeon-code/tiny-url/internal/pkg/test"
 	"github.com/zeon-code/tiny-url/internal/repository"
 )
 
 func TestUrlRepository(t *testing.T) {
 	ctx := context.Background()
+	outboxQuery := "INSERT INTO outbox (link_id, event_type, payload) VALUES ($1, $2, $3)"
+	linkColumns := []string{"id", "code", "target", "created_at", "updated_at", "disabled_at"}
 
 	t.Run("list urls", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
-		query := "SELECT id, code, target FROM urls ORDER BY id DESC LIMIT $1"
+		query := "SELECT id, code, target FROM urls WHERE deleted_at IS NULL ORDER BY id DESC LIMIT $1"
 
 		rows := sqlmock.NewRows([]string{"id", "code", "target"}).
 			AddRow(int64(5), "5", "target5").
@@ -28,8 +32,8 @@ func TestUrlRepository(t *testing.T) {
 			AddRow(int64(2), "2", "target2").
 			AddRow(int64(1), "1", "target1")
 
-		fake.DBMock.ExpectQuery(query).WithArgs(5).WillReturnRows(rows)
-		urls, err := repo.List(ctx, 5, ">", nil)
+		fake.DBMock.ExpectQuery(query).WithArgs(6).WillReturnRows(rows)
+		urls, err := repo.List(ctx, pagination.Query{Limit: 5}, model.URLFilter{})
 
 		assert.NoError(t, err)
 		assert.Len(t, urls, 5)
@@ -38,15 +42,15 @@ func TestUrlRepository(t *testing.T) {
 	t.Run("list urls with no matches", func(t *testing.T) {
 		limit := 5
 		cursor := int64(8888)
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
-		query := "SELECT id, code, target FROM urls WHERE id > $1 ORDER BY id DESC LIMIT $2"
+		query := "SELECT id, code, target FROM urls WHERE deleted_at IS NULL AND id > $1 ORDER BY id ASC LIMIT $2"
 
 		rows := sqlmock.NewRows([]string{"id", "code", "target"})
 
-		fake.DBMock.ExpectQuery(query).WithArgs(cursor, limit).WillReturnRows(rows)
-		urls, err := repo.List(ctx, limit, ">", &cursor)
+		fake.DBMock.ExpectQuery(query).WithArgs(cursor, limit+1).WillReturnRows(rows)
+		urls, err := repo.List(ctx, pagination.Query{Limit: limit, Direction: pagination.Backward, Key: &cursor}, model.URLFilter{})
 
 		assert.NoError(t, err)
 		assert.Len(t, urls, 0)
@@ -54,59 +58,106 @@ func TestUrlRepository(t *testing.T) {
 
 	t.Run("list urls with cursor", func(t *testing.T) {
 		cursor := int64(1)
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
-		query := "SELECT id, code, target FROM urls WHERE id > $1 ORDER BY id DESC LIMIT $2"
+		query := "SELECT id, code, target FROM urls WHERE deleted_at IS NULL AND id > $1 ORDER BY id ASC LIMIT $2"
 
 		rows := sqlmock.NewRows([]string{"id", "code", "target"}).
-			AddRow(int64(1), "6", "target6").
-			AddRow(int64(5), "5", "target5").
-			AddRow(int64(4), "4", "target4").
+			AddRow(int64(2), "2", "target2").
 			AddRow(int64(3), "3", "target3").
-			AddRow(int64(2), "2", "target2")
+			AddRow(int64(4), "4", "target4").
+			AddRow(int64(5), "5", "target5").
+			AddRow(int64(6), "6", "target6")
 
-		fake.DBMock.ExpectQuery(query).WithArgs(&cursor, 5).WillReturnRows(rows)
-		urls, err := repo.List(ctx, 5, ">", &cursor)
+		fake.DBMock.ExpectQuery(query).WithArgs(&cursor, 6).WillReturnRows(rows)
+		urls, err := repo.List(ctx, pagination.Query{Limit: 5, Direction: pagination.Backward, Key: &cursor}, model.URLFilter{})
 
 		assert.NoError(t, err)
 		assert.Len(t, urls, 5)
 	})
 
+	t.Run("list urls by domain", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
+
+		// Both branches are served by an index: the equality by
+		// idx_urls_domain and the subdomain suffix by idx_urls_domain_trgm.
+		query := "SELECT id, code, target FROM urls WHERE deleted_at IS NULL AND (domain = $1 OR domain LIKE $2 ESCAPE '\\') ORDER BY id DESC LIMIT $3"
+
+		fake.DBMock.ExpectQuery(query).
+			WithArgs("example.com", "%.example.com", 6).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "code", "target"}).AddRow(int64(1), "1", "https://www.example.com"))
+		urls, err := repo.List(ctx, pagination.Query{Limit: 5}, model.URLFilter{Domain: "Example.com"})
+
+		assert.NoError(t, err)
+		assert.Len(t, urls, 1)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
 	t.Run("create url", func(t *testing.T) {
 		now := time.Now()
 		target := "target"
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
 
 		updateQuery := "UPDATE urls SET code = $1 WHERE id = $2"
-		insertQuery := "INSERT INTO urls (target, code) VALUES ($1, '') RETURNING id, target, code, created_at, updated_at"
+		insertQuery := "INSERT INTO urls (target, code, owner, expires_at, domain) VALUES ($1, '', $2, $3, $4) RETURNING id, target, code, owner, created_at, updated_at, expires_at"
 
 		rows := sqlmock.NewRows([]string{"id", "target", "code", "created_at", "updated_at"}).
 			AddRow(int64(1), target, "", now, now)
 
 		fake.DBMock.ExpectBegin()
-		fake.DBMock.ExpectQuery(insertQuery).WithArgs(target).WillReturnRows(rows)
+		fake.DBMock.ExpectQuery(insertQuery).WithArgs(target, "", nil, "").WillReturnRows(rows)
 		fake.DBMock.ExpectExec(updateQuery).WithArgs("1", int64(1)).WillReturnResult(sqlmock.NewResult(1, 1))
+		fake.DBMock.ExpectExec(outboxQuery).WithArgs(int64(1), model.EventLinkCreated, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
 		fake.DBMock.ExpectCommit()
 
-		url, err := repo.Create(ctx, target)
+		url, err := repo.Create(ctx, target, "", nil)
 
 		assert.NoError(t, err)
 		assert.Equal(t, model.URL{ID: 1, Code: "1", Target: target, CreatedAt: url.CreatedAt, UpdatedAt: url.UpdatedAt}, *url)
 	})
 
+	t.Run("create url retries the transaction on serialization failure", func(t *testing.T) {
+		now := time.Now()
+		target := "target"
+		fake := test.NewFakeDependencies(t)
+		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
+
+		updateQuery := "UPDATE urls SET code = $1 WHERE id = $2"
+		insertQuery := "INSERT INTO urls (target, code, owner, expires_at, domain) VALUES ($1, '', $2, $3, $4) RETURNING id, target, code, owner, created_at, updated_at, expires_at"
+
+		rows := sqlmock.NewRows([]string{"id", "target", "code", "created_at", "updated_at"}).
+			AddRow(int64(2), target, "", now, now)
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(insertQuery).WithArgs(target, "", nil, "").WillReturnError(&pq.Error{Code: "40001"})
+		fake.DBMock.ExpectRollback()
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(insertQuery).WithArgs(target, "", nil, "").WillReturnRows(rows)
+		fake.DBMock.ExpectExec(updateQuery).WithArgs("2", int64(2)).WillReturnResult(sqlmock.NewResult(1, 1))
+		fake.DBMock.ExpectExec(outboxQuery).WithArgs(int64(2), model.EventLinkCreated, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+		fake.DBMock.ExpectCommit()
+
+		url, err := repo.Create(ctx, target, "", nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2", url.Code)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
 	t.Run("create url with insert error should rollback", func(t *testing.T) {
 		target := "target"
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
-		insertQuery := "INSERT INTO urls (target, code) VALUES ($1, '') RETURNING id, target, code, created_at, updated_at"
+		insertQuery := "INSERT INTO urls (target, code, owner, expires_at, domain) VALUES ($1, '', $2, $3, $4) RETURNING id, target, code, owner, created_at, updated_at, expires_at"
 
 		fake.DBMock.ExpectBegin()
-		fake.DBMock.ExpectQuery(insertQuery).WithArgs(target).WillReturnError(db.ErrDBInvalidBackend)
+		fake.DBMock.ExpectQuery(insertQuery).WithArgs(target, "", nil, "").WillReturnError(db.ErrDBInvalidBackend)
 		fake.DBMock.ExpectRollback()
 
-		url, err := repo.Create(ctx, target)
+		url, err := repo.Create(ctx, target, "", nil)
 
 		assert.Equal(t, db.ErrDBInvalidBackend, err)
 		assert.Nil(t, url)
@@ -115,55 +166,56 @@ func TestUrlRepository(t *testing.T) {
 	t.Run("create url with update error should rollback", func(t *testing.T) {
 		now := time.Now()
 		target := "target"
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
 		updateQuery := "UPDATE urls SET code = $1 WHERE id = $2"
-		insertQuery := "INSERT INTO urls (target, code) VALUES ($1, '') RETURNING id, target, code, created_at, updated_at"
+		insertQuery := "INSERT INTO urls (target, code, owner, expires_at, domain) VALUES ($1, '', $2, $3, $4) RETURNING id, target, code, owner, created_at, updated_at, expires_at"
 
 		rows := sqlmock.NewRows([]string{"id", "target", "code", "created_at", "updated_at"}).
 			AddRow(int64(9999), target, "", now, now)
 
 		fake.DBMock.ExpectBegin()
-		fake.DBMock.ExpectQuery(insertQuery).WithArgs(target).WillReturnRows(rows)
+		fake.DBMock.ExpectQuery(insertQuery).WithArgs(target, "", nil, "").WillReturnRows(rows)
 		fake.DBMock.ExpectExec(updateQuery).WithArgs("2bH", int64(9999)).WillReturnError(db.ErrDBInvalidBackend)
 		fake.DBMock.ExpectRollback()
 
-		url, err := repo.Create(ctx, target)
+		url, err := repo.Create(ctx, target, "", nil)
 
 		assert.Equal(t, db.ErrDBInvalidBackend, err)
 		assert.Nil(t, url)
 	})
 
-	t.Run("create url with commit error should rollback", func(t *testing.T) {
+	t.Run("create url with commit error is not rolled back again", func(t *testing.T) {
 		now := time.Now()
 		target := "target"
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 
 		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
 		updateQuery := "UPDATE urls SET code = $1 WHERE id = $2"
-		insertQuery := "INSERT INTO urls (target, code) VALUES ($1, '') RETURNING id, target, code, created_at, updated_at"
+		insertQuery := "INSERT INTO urls (target, code, owner, expires_at, domain) VALUES ($1, '', $2, $3, $4) RETURNING id, target, code, owner, created_at, updated_at, expires_at"
 
 		rows := sqlmock.NewRows([]string{"id", "target", "code", "created_at", "updated_at"}).
 			AddRow(int64(9999), target, "", now, now)
 
 		fake.DBMock.ExpectBegin()
-		fake.DBMock.ExpectQuery(insertQuery).WithArgs(target).WillReturnRows(rows)
+		fake.DBMock.ExpectQuery(insertQuery).WithArgs(target, "", nil, "").WillReturnRows(rows)
 		fake.DBMock.ExpectExec(updateQuery).WithArgs("2bH", int64(9999)).WillReturnResult(sqlmock.NewResult(1, 1))
+		fake.DBMock.ExpectExec(outboxQuery).WithArgs(int64(9999), model.EventLinkCreated, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
 		fake.DBMock.ExpectCommit().WillReturnError(db.ErrDBInvalidBackend)
-		fake.DBMock.ExpectRollback()
 
-		url, err := repo.Create(ctx, target)
+		url, err := repo.Create(ctx, target, "", nil)
 
 		assert.Equal(t, db.ErrDBInvalidBackend, err)
 		assert.Nil(t, url)
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
 	})
 
 	t.Run("get by id", func(t *testing.T) {
 		now := time.Now()
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
-		query := "SELECT * FROM urls WHERE id = $1"
+		query := "SELECT id, code, target, domain, owner, created_at, updated_at, disabled_at, expires_at, deleted_at FROM urls WHERE id = $1 AND deleted_at IS NULL"
 
 		rows := sqlmock.NewRows([]string{"id", "code", "target", "created_at", "updated_at"}).
 			AddRow(int64(1), "1", "target1", now, now)
@@ -176,9 +228,9 @@ func TestUrlRepository(t *testing.T) {
 	})
 
 	t.Run("get by id when not found", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
-		query := "SELECT * FROM urls WHERE id = $1"
+		query := "SELECT id, code, target, domain, owner, created_at, updated_at, disabled_at, expires_at, deleted_at FROM urls WHERE id = $1 AND deleted_at IS NULL"
 
 		rows := sqlmock.NewRows([]string{"id", "code", "target", "created_at", "updated_at"})
 
@@ -188,4 +240,59 @@ func TestUrlRepository(t *testing.T) {
 		assert.Nil(t, url)
 		assert.Equal(t, db.ErrDBResourceNotFound, err)
 	})
+	t.Run("disable url", func(t *testing.T) {
+		now := time.Now()
+		fake := test.NewFakeDependencies(t)
+		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
+		query := "UPDATE urls SET disabled_at = now(), updated_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING id, code, target, owner, created_at, updated_at, disabled_at, expires_at"
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(query).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows(linkColumns).AddRow(int64(1), "1", "target", now, now, now))
+		fake.DBMock.ExpectExec(outboxQuery).WithArgs(int64(1), model.EventLinkUpdated, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+		fake.DBMock.ExpectCommit()
+
+		assert.NoError(t, repo.Disable(ctx, int64(1)))
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("disable url when not found", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
+		query := "UPDATE urls SET disabled_at = now(), updated_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING id, code, target, owner, created_at, updated_at, disabled_at, expires_at"
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(query).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows(linkColumns))
+		fake.DBMock.ExpectRollback()
+
+		assert.Equal(t, db.ErrDBResourceNotFound, repo.Disable(ctx, int64(1)))
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("delete url", func(t *testing.T) {
+		now := time.Now()
+		fake := test.NewFakeDependencies(t)
+		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
+		query := "UPDATE urls SET deleted_at = now(), updated_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING id, code, target, owner, created_at, updated_at, disabled_at, expires_at"
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(query).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows(linkColumns).AddRow(int64(1), "1", "target", now, now, nil))
+		fake.DBMock.ExpectExec(outboxQuery).WithArgs(int64(1), model.EventLinkDeleted, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+		fake.DBMock.ExpectCommit()
+
+		assert.NoError(t, repo.Delete(ctx, int64(1)))
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("delete url when not found", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		repo := repository.NewURLRepository(fake.DB(), fake.Memory(), fake.Observer())
+		query := "UPDATE urls SET deleted_at = now(), updated_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING id, code, target, owner, created_at, updated_at, disabled_at, expires_at"
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(query).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows(linkColumns))
+		fake.DBMock.ExpectRollback()
+
+		assert.Equal(t, db.ErrDBResourceNotFound, repo.Delete(ctx, int64(1)))
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
 }
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+)
+
+// WebhookRepository stores webhook subscriptions and the queue of
+// deliveries made to them.
+type WebhookRepository interface {
+	Subscribe(context.Context, model.WebhookSubscription) (*model.WebhookSubscription, error)
+	Subscriptions(context.Context, string) ([]model.WebhookSubscription, error)
+	Unsubscribe(context.Context, string, int64) error
+	Enqueue(context.Context, model.Event) (int, error)
+	EnqueueTx(context.Context, db.SQLTX, model.Event) (int, error)
+	Claim(context.Context, time.Time, time.Time, int) ([]model.WebhookDelivery, error)
+	Record(context.Context, model.WebhookDelivery, time.Time) error
+	DeadLetters(context.Context, string, int64) ([]model.WebhookDelivery, error)
+	Replay(context.Context, string, int64, int64) error
+	ReplayAll(context.Context, string, int64) (int, error)
+}
+
+// WebhookStore keeps subscriptions and deliveries in the primary database,
+// as deliveries are claimed and updated by the dispatcher.
+type WebhookStore struct {
+	db     db.SQLClient
+	logger observability.Logger
+}
+
+func NewWebhookRepository(database db.SQLClient, observer observability.Observer) WebhookRepository {
+	return WebhookStore{
+		db:     database,
+		logger: observer.Logger().With("repository", "webhook"),
+	}
+}
+
+// deliveryColumns lists the columns describing a delivery in the
+// dead-letter list.
+const deliveryColumns = "id, subscription_id, event_id, event_type, body, status, attempts, last_error, next_attempt_at, created_at, delivered_at"
+
+// ownedSubscription restricts deliveries to a subscription of the owner
+// bound to the given placeholder, so one owner cannot reach another's
+// subscriptions by ID.
+func ownedSubscription(placeholder string) string {
+	return fmt.Sprintf("subscription_id IN (SELECT id FROM webhook_subscriptions WHERE owner = %s)", placeholder)
+}
+
+func (s WebhookStore) Subscribe(ctx context.Context, subscription model.WebhookSubscription) (*model.WebhookSubscription, error) {
+	var created model.WebhookSubscription
+	query := "INSERT INTO webhook_subscriptions (owner, url, secret, events) VALUES ($1, $2, $3, $4) RETURNING id, owner, url, secret, events, created_at"
+
+	if err := s.db.Get(ctx, &created, query, subscription.Owner, subscription.URL, subscription.Secret, subscription.Events); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// Subscriptions lists the subscriptions of owner, without their secrets.
+func (s WebhookStore) Subscriptions(ctx context.Context, owner string) ([]model.WebhookSubscription, error) {
+	subscriptions := []model.WebhookSubscription{}
+	query := "SELECT id, owner, url, events, created_at FROM webhook_subscriptions WHERE owner = $1 ORDER BY id"
+
+	if err := s.db.Select(ctx, &subscriptions, query, owner); err != nil {
+		return subscriptions, err
+	}
+
+	return subscriptions, nil
+}
+
+// Unsubscribe deletes the subscription of owner along with its pending and
+// dead deliveries.
+//
+// Returns db.ErrDBResourceNotFound when owner has no such subscription.
+func (s WebhookStore) Unsubscribe(ctx context.Context, owner string, id int64) error {
+	return s.db.WithTx(ctx, nil, func(tx db.SQLTX) error {
+		var deleted int64
+
+		if err := tx.Get(ctx, &deleted, "DELETE FROM webhook_subscriptions WHERE id = $1 AND owner = $2 RETURNING id", id, owner); err != nil {
+			return err
+		}
+
+		return tx.Exec(ctx, "DELETE FROM webhook_deliveries WHERE subscription_id = $1", id)
+	})
+}
+
+// Enqueue queues the event for the subscriptions of its link owner in a
+// transaction of its own.
+func (s WebhookStore) Enqueue(ctx context.Context, event model.Event) (int, error) {
+	queued := 0
+
+	err := s.db.WithTx(ctx, nil, func(tx db.SQLTX) error {
+		var err error
+		queued, err = s.EnqueueTx(ctx, tx, event)
+		return err
+	})
+
+	return queued, err
+}
+
+// EnqueueTx queues a delivery of the event, due right away, for every
+// subscription of its link owner accepting its type, and returns how many
+// were queued. Events of links without an owner are not delivered.
+func (s WebhookStore) EnqueueTx(ctx context.Context, tx db.SQLTX, event model.Event) (int, error) {
+	var link model.URL
+
+	if err := json.Unmarshal(event.Payload, &link); err != nil {
+		return 0, fmt.Errorf("decoding event %d payload: %w", event.ID, err)
+	}
+
+	if link.Owner == "" {
+		return 0, nil
+	}
+
+	subscriptions := []model.WebhookSubscription{}
+	query := "SELECT id, owner, url, events, created_at FROM webhook_subscriptions WHERE owner = $1 ORDER BY id"
+
+	if err := tx.Select(ctx, &subscriptions, query, link.Owner); err != nil {
+		return 0, err
+	}
+
+	body, err := json.Marshal(event)
+
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	now := time.Now().UTC()
+	query = "INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, body, next_attempt_at) VALUES ($1, $2, $3, $4, $5)"
+
+	for _, subscription := range subscriptions {
+		if !subscription.Accepts(event.Type) {
+			continue
+		}
+
+		if err := tx.Exec(ctx, query, subscription.ID, event.ID, event.Type, model.EventPayload(body), now); err != nil {
+			return queued, err
+		}
+
+		queued++
+	}
+
+	return queued, nil
+}
+
+// Claim returns up to limit pending deliveries due at now, oldest first,
+// with the endpoint and secret of their subscription. Claimed deliveries
+// are postponed until leaseUntil, so other dispatchers skip them while
+// they are attempted, and they are attempted again if the dispatcher
+// stops before recording the outcome.
+func (s WebhookStore) Claim(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	deliveries := []model.WebhookDelivery{}
+
+	err := s.db.WithTx(ctx, nil, func(tx db.SQLTX) error {
+		deliveries = []model.WebhookDelivery{}
+		query := fmt.Sprintf(
+			"SELECT d.id, d.subscription_id, d.event_id, d.event_type, d.body, d.status, d.attempts, d.last_error, d.next_attempt_at, d.created_at, d.delivered_at, s.url, s.secret "+
+				"FROM webhook_deliveries d JOIN webhook_subscriptions s ON s.id = d.subscription_id "+
+				"WHERE d.status = 'pending' AND d.next_attempt_at <= $1 ORDER BY d.id LIMIT $2%s",
+			s.db.Dialect().ForUpdate(),
+		)
+
+		if err := tx.Select(ctx, &deliveries, query, now.UTC(), limit); err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			query = "UPDATE webhook_deliveries SET next_attempt_at = $1 WHERE id = $2"
+
+			if err := tx.Exec(ctx, query, leaseUntil.UTC(), delivery.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return deliveries, err
+}
+
+// Record stores the outcome of an attempt at a delivery claimed until
+// leaseUntil.
+//
+// Returns ErrClaimLost when the lease expired and another dispatcher
+// claimed the delivery, whose outcome is then left to it.
+func (s WebhookStore) Record(ctx context.Context, delivery model.WebhookDelivery, leaseUntil time.Time) error {
+	var deliveredAt *time.Time
+	var id int64
+
+	if delivery.DeliveredAt != nil {
+		at := delivery.DeliveredAt.UTC()
+		deliveredAt = &at
+	}
+
+	query := "UPDATE webhook_deliveries SET status = $1, attempts = $2, last_error = $3, next_attempt_at = $4, delivered_at = $5 WHERE id = $6 AND status = 'pending' AND next_attempt_at = $7 RETURNING id"
+	err := s.db.Get(ctx, &id, query, delivery.Status, delivery.Attempts, delivery.LastError, delivery.NextAttemptAt.UTC(), deliveredAt, delivery.ID, leaseUntil.UTC())
+
+	if errors.Is(err, db.ErrDBResourceNotFound) {
+		return ErrClaimLost
+	}
+
+	return err
+}
+
+// DeadLetters lists the deliveries of owner's subscription that exhausted
+// their attempts, oldest first.
+func (s WebhookStore) DeadLetters(ctx context.Context, owner string, subscriptionID int64) ([]model.WebhookDelivery, error) {
+	deliveries := []model.WebhookDelivery{}
+	query := fmt.Sprintf("SELECT %s FROM webhook_deliveries WHERE subscription_id = $1 AND status = 'dead' AND %s ORDER BY id", deliveryColumns, ownedSubscription("$2"))
+
+	if err := s.db.Select(ctx, &deliveries, query, subscriptionID, owner); err != nil {
+		return deliveries, err
+	}
+
+	return deliveries, nil
+}
+
+// Replay queues a dead delivery of owner's subscription again, with a
+// fresh set of attempts.
+//
+// Returns db.ErrDBResourceNotFound when owner's subscription has no such
+// dead delivery.
+func (s WebhookStore) Replay(ctx context.Context, owner string, subscriptionID int64, id int64) error {
+	var replayed int64
+	query := fmt.Sprintf("UPDATE webhook_deliveries SET status = 'pending', attempts = 0, last_error = NULL, next_attempt_at = $1 WHERE id = $2 AND subscription_id = $3 AND status = 'dead' AND %s RETURNING id", ownedSubscription("$4"))
+
+	return s.db.Get(ctx, &replayed, query, time.Now().UTC(), id, subscriptionID, owner)
+}
+
+// ReplayAll queues every dead delivery of owner's subscription again and
+// returns how many were replayed.
+func (s WebhookStore) ReplayAll(ctx context.Context, owner string, subscriptionID int64) (int, error) {
+	replayed := []int64{}
+	query := fmt.Sprintf("UPDATE webhook_deliveries SET status = 'pending', attempts = 0, last_error = NULL, next_attempt_at = $1 WHERE subscription_id = $2 AND status = 'dead' AND %s RETURNING id", ownedSubscription("$3"))
+
+	if err := s.db.Select(ctx, &replayed, query, time.Now().UTC(), subscriptionID, owner); err != nil {
+		return 0, err
+	}
+
+	return len(replayed), nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/repository"
+)
+
+const (
+	// clickBuffer bounds how many clicks wait for the next flush; clicks
+	// arriving while it is full are dropped.
+	clickBuffer = 1 << 16
+	// clickFlushInterval is how often buffered clicks are written.
+	clickFlushInterval = time.Second
+	// clickFlushTimeout bounds each flush, which runs detached from the
+	// requests that counted the clicks.
+	clickFlushTimeout = 10 * time.Second
+)
+
+// ClickCounter counts redirects off the request path. Clicks are buffered
+// and written in the background, one update per link, so a redirect never
+// waits for the database. Counting is best effort: clicks dropped from a
+// full buffer or lost to an unavailable database only delay a threshold
+// event.
+type ClickCounter struct {
+	repo    repository.URLRepository
+	clicks  chan int64
+	dropped atomic.Int64
+
+	started atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	logger  observability.Logger
+}
+
+func NewClickCounter(repositories repository.Repositories, observer observability.Observer) *ClickCounter {
+	return &ClickCounter{
+		repo:   repositories.Url,
+		clicks: make(chan int64, clickBuffer),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		logger: observer.Logger().With("worker", "click-counter"),
+	}
+}
+
+// Count records a click of the link without blocking.
+func (c *ClickCounter) Count(id int64) {
+	select {
+	case c.clicks <- id:
+	default:
+		c.dropped.Add(1)
+	}
+}
+
+// Start writes buffered clicks in the background until Close is called.
+func (c *ClickCounter) Start() {
+	if c.started.CompareAndSwap(false, true) {
+		go c.run()
+	}
+}
+
+// Close stops the background writes and flushes the clicks still buffered.
+func (c *ClickCounter) Close() error {
+	c.once.Do(func() { close(c.stop) })
+
+	if c.started.Load() {
+		<-c.done
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clickFlushTimeout)
+	defer cancel()
+
+	return c.Flush(ctx)
+}
+
+// Flush writes the buffered clicks, adding them up by link, and returns
+// the errors met. Clicks of links deleted since are discarded.
+func (c *ClickCounter) Flush(ctx context.Context) error {
+	counts := map[int64]int64{}
+	ids := []int64{}
+
+	for drained := false; !drained; {
+		select {
+		case id := <-c.clicks:
+			if counts[id] == 0 {
+				ids = append(ids, id)
+			}
+
+			counts[id]++
+		default:
+			drained = true
+		}
+	}
+
+	if dropped := c.dropped.Swap(0); dropped > 0 {
+		c.logger.Warn(ctx, "click buffer full, clicks dropped", slog.Int64("clicks", dropped))
+	}
+
+	var err error
+
+	for _, id := range ids {
+		if _, clickErr := c.repo.Click(ctx, id, counts[id]); clickErr != nil && !errors.Is(clickErr, db.ErrDBResourceNotFound) {
+			c.logger.Warn(ctx, "error counting link clicks", slog.Int64("id", id), slog.Int64("clicks", counts[id]), slog.Any("error", clickErr))
+			err = errors.Join(err, clickErr)
+		}
+	}
+
+	return err
+}
+
+func (c *ClickCounter) run() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(clickFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), clickFlushTimeout)
+		c.Flush(ctx)
+		cancel()
+	}
+}
@@ -0,0 +1,71 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+	"github.com/zeon-code/tiny-url/internal/service"
+)
+
+func TestClickCounter(t *testing.T) {
+	ctx := context.Background()
+	clickQuery := "UPDATE urls SET clicks = clicks + $1 WHERE id = $2 AND deleted_at IS NULL RETURNING id, code, target, owner, created_at, updated_at, disabled_at, expires_at, clicks"
+
+	t.Run("writes the clicks of each link at once", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		counter := service.NewClickCounter(fake.Repositories(), fake.Observer())
+
+		counter.Count(1)
+		counter.Count(2)
+		counter.Count(1)
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(clickQuery).WithArgs(int64(2), int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "clicks"}).AddRow(int64(1), int64(2)))
+		fake.DBMock.ExpectCommit()
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(clickQuery).WithArgs(int64(1), int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "clicks"}).AddRow(int64(2), int64(1)))
+		fake.DBMock.ExpectCommit()
+
+		assert.NoError(t, counter.Flush(ctx))
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+
+		assert.NoError(t, counter.Flush(ctx), "flushed clicks are not written again")
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("discards clicks of deleted links", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		counter := service.NewClickCounter(fake.Repositories(), fake.Observer())
+
+		counter.Count(42)
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(clickQuery).WithArgs(int64(1), int64(42)).WillReturnError(sql.ErrNoRows)
+		fake.DBMock.ExpectRollback()
+
+		assert.NoError(t, counter.Flush(ctx))
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+
+	t.Run("flushes the buffered clicks when closed", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		counter := service.NewClickCounter(fake.Repositories(), fake.Observer())
+
+		counter.Start()
+		counter.Count(1)
+
+		fake.DBMock.ExpectBegin()
+		fake.DBMock.ExpectQuery(clickQuery).WithArgs(int64(1), int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "clicks"}).AddRow(int64(1), int64(1)))
+		fake.DBMock.ExpectCommit()
+
+		assert.NoError(t, counter.Close())
+		assert.NoError(t, fake.DBMock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/repository"
+)
+
+type ConsistencyService interface {
+	Token(ctx context.Context) (string, error)
+}
+
+type ConsistencySvc struct {
+	repo   repository.ConsistencyRepository
+	logger observability.Logger
+}
+
+func NewConsistencyService(repositories repository.Repositories, observer observability.Observer) ConsistencyService {
+	return ConsistencySvc{
+		repo:   repositories.Consistency,
+		logger: observer.Logger().With("service", "consistency"),
+	}
+}
+
+func (s ConsistencySvc) Token(ctx context.Context) (string, error) {
+	return s.repo.Token(ctx)
+}
@@ -3,12 +3,15 @@ package service
 import (
 	"context"
 
+	"github.com/zeon-code/tiny-url/internal/model"
 	"github.com/zeon-code/tiny-url/internal/pkg/observability"
 	"github.com/zeon-code/tiny-url/internal/repository"
 )
 
 type HealthService interface {
 	Ping(ctx context.Context) (string, error)
+	Degraded(ctx context.Context) []string
+	Replicas(ctx context.Context) []model.ReplicaHealth
 }
 
 type HealthSvc struct {
@@ -26,3 +29,11 @@ func NewHealthService(repositories repository.Repositories, observer observabili
 func (s HealthSvc) Ping(ctx context.Context) (string, error) {
 	return s.repo.Ping(ctx)
 }
+
+func (s HealthSvc) Degraded(ctx context.Context) []string {
+	return s.repo.Degraded(ctx)
+}
+
+func (s HealthSvc) Replicas(ctx context.Context) []model.ReplicaHealth {
+	return s.repo.Replicas(ctx)
+}
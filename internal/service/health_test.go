@@ -11,7 +11,7 @@ import (
 
 func TestHealthService(t *testing.T) {
 	t.Run("ping", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		svc := service.NewHealthService(fake.Repositories(), fake.Observer())
 
 		reason, err := svc.Ping(context.Background())
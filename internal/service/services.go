@@ -6,13 +6,19 @@ import (
 )
 
 type Services struct {
-	Url    URLService
-	Health HealthService
+	Url         URLService
+	Health      HealthService
+	Consistency ConsistencyService
+	Webhook     WebhookService
+	Clicks      *ClickCounter
 }
 
 func NewServices(repo repository.Repositories, observer observability.Observer) Services {
 	return Services{
-		Url:    NewUrlService(repo, observer),
-		Health: NewHealthService(repo, observer),
+		Url:         NewUrlService(repo, observer),
+		Health:      NewHealthService(repo, observer),
+		Consistency: NewConsistencyService(repo, observer),
+		Webhook:     NewWebhookService(repo, observer),
+		Clicks:      NewClickCounter(repo, observer),
 	}
 }
@@ -10,7 +10,7 @@ import (
 
 func TestServices(t *testing.T) {
 	t.Run("Should define url service", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		services := service.NewServices(fake.Repositories(), fake.Observer())
 
 		assert.NotNil(t, services.Url)
@@ -2,24 +2,35 @@ package service
 
 import (
 	"context"
+	"log/slog"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/zeon-code/tiny-url/internal/model"
 	"github.com/zeon-code/tiny-url/internal/pkg/base62"
 	"github.com/zeon-code/tiny-url/internal/pkg/cache"
 	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/pkg/pagination"
 	"github.com/zeon-code/tiny-url/internal/repository"
 )
 
 type URLService interface {
-	Create(context.Context, string) (*model.URL, error)
-	List(context.Context, int, string, *int64) ([]model.URL, error)
+	Create(context.Context, string, string, *time.Time) (*model.URL, error)
+	List(context.Context, pagination.Query, model.URLFilter) ([]model.URL, error)
 	GetByID(context.Context, int64) (*model.URL, error)
 	GetByCode(ctx context.Context, code string) (*model.URL, error)
+	Expire(context.Context, time.Time, int) (int, error)
+	Purge(context.Context, time.Time, int) (int, error)
 }
 
+// URLCacheKey is the root of every cache key written by the URL service.
+// Tools that mutate links outside the service use it to evict entries.
+var URLCacheKey = cache.NewCacheKey("url", "service")
+
 type UrlSvc struct {
 	repo     repository.URLRepository
+	cache    repository.CacheRepository
 	cacheKey cache.CacheKey
 	logger   observability.Logger
 }
@@ -27,38 +38,71 @@ type UrlSvc struct {
 func NewUrlService(repositories repository.Repositories, observer observability.Observer) URLService {
 	return UrlSvc{
 		repo:     repositories.Url,
-		cacheKey: cache.NewCacheKey("url", "service"),
+		cache:    repositories.Cache,
+		cacheKey: URLCacheKey,
 		logger:   observer.Logger().With("service", "url"),
 	}
 }
 
-func (s UrlSvc) Create(ctx context.Context, target string) (*model.URL, error) {
-	return s.repo.Create(ctx, target)
+func (s UrlSvc) Create(ctx context.Context, target string, owner string, expiresAt *time.Time) (*model.URL, error) {
+	return s.repo.Create(ctx, target, owner, expiresAt)
 }
 
-func (s UrlSvc) List(ctx context.Context, limit int, direction string, cursor *int64) ([]model.URL, error) {
+func (s UrlSvc) List(ctx context.Context, page pagination.Query, filter model.URLFilter) ([]model.URL, error) {
 	return s.repo.List(
 		cache.WithCachePolicy(
 			ctx,
-			cache.CachePolicy{
-				TTL: 5 * time.Minute,
-				Key: s.cacheKey.With("list", direction, cursor).String(),
-			},
+			cache.NewCachePolicy(listCacheKey(s.cacheKey.With("list", page.Order, page.Direction, page.Limit), page, filter), 5*time.Minute),
 		),
-		limit,
-		direction,
-		cursor,
+		page,
+		filter,
 	)
 }
 
+// listCacheKey appends the cursor key of page and the set fields of
+// filter to the key of a listing. Filtered listings stay in the list
+// family, so evicting the family evicts them too.
+func listCacheKey(key cache.CacheKey, page pagination.Query, filter model.URLFilter) cache.CacheKey {
+	if page.Key != nil {
+		key = key.With("key", *page.Key)
+	}
+
+	if filter.Query != "" {
+		key = key.With("q", strconv.Quote(filter.Query))
+	}
+
+	if filter.Domain != "" {
+		key = key.With("domain", strconv.Quote(strings.ToLower(filter.Domain)))
+	}
+
+	if filter.CodePrefix != "" {
+		key = key.With("code", filter.CodePrefix)
+	}
+
+	if filter.Owner != "" {
+		key = key.With("owner", strconv.Quote(filter.Owner))
+	}
+
+	if filter.CreatedAfter != nil {
+		key = key.With("after", filter.CreatedAfter.UTC().Format(time.RFC3339Nano))
+	}
+
+	if filter.CreatedBefore != nil {
+		key = key.With("before", filter.CreatedBefore.UTC().Format(time.RFC3339Nano))
+	}
+
+	if filter.Status != "" {
+		key = key.With("status", filter.Status)
+	}
+
+	return key
+}
+
 func (s UrlSvc) GetByID(ctx context.Context, id int64) (*model.URL, error) {
 	return s.repo.GetByID(
 		cache.WithCachePolicy(
 			ctx,
-			cache.CachePolicy{
-				TTL: 5 * time.Minute,
-				Key: s.cacheKey.With("id", id).String(),
-			},
+			cache.NewCachePolicy(s.cacheKey.With("id", id), 5*time.Minute),
 		),
 		id,
 	)
@@ -67,3 +111,57 @@ func (s UrlSvc) GetByID(ctx context.Context, id int64) (*model.URL, error) {
 func (s UrlSvc) GetByCode(ctx context.Context, code string) (*model.URL, error) {
 	return s.GetByID(ctx, base62.Decode(code))
 }
+
+// Expire disables up to limit links whose expiry passed at now and evicts
+// their cached entries, returning how many were expired.
+func (s UrlSvc) Expire(ctx context.Context, now time.Time, limit int) (int, error) {
+	urls, err := s.repo.Expire(ctx, now, limit)
+
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]int64, 0, len(urls))
+
+	for _, url := range urls {
+		ids = append(ids, url.ID)
+	}
+
+	s.evict(ctx, ids)
+	return len(ids), nil
+}
+
+// Purge permanently removes up to limit links soft-deleted before the given
+// time and evicts their cached entries, returning how many were purged.
+func (s UrlSvc) Purge(ctx context.Context, before time.Time, limit int) (int, error) {
+	ids, err := s.repo.Purge(ctx, before, limit)
+
+	if err != nil {
+		return 0, err
+	}
+
+	s.evict(ctx, ids)
+	return len(ids), nil
+}
+
+// evict drops the cached entries of the given links and every cached
+// listing. Failures are only logged: the entries expire with their TTL.
+func (s UrlSvc) evict(ctx context.Context, ids []int64) {
+	if len(ids) == 0 {
+		return
+	}
+
+	keys := make([]cache.CacheKey, 0, len(ids))
+
+	for _, id := range ids {
+		keys = append(keys, s.cacheKey.With("id", id))
+	}
+
+	if err := s.cache.Evict(ctx, keys...); err != nil {
+		s.logger.Warn(ctx, "error evicting cached links", slog.Int("links", len(ids)), slog.Any("error", err))
+	}
+
+	if _, err := s.cache.EvictFamily(ctx, s.cacheKey.With("list")); err != nil {
+		s.logger.Warn(ctx, "error evicting cached link listings", slog.Any("error", err))
+	}
+}
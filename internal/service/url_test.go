@@ -3,10 +3,13 @@ package service_test
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/zeon-code/tiny-url/internal/model"
 	"github.com/zeon-code/tiny-url/internal/pkg/cache"
+	"github.com/zeon-code/tiny-url/internal/pkg/pagination"
 	"github.com/zeon-code/tiny-url/internal/pkg/test"
 	"github.com/zeon-code/tiny-url/internal/service"
 )
@@ -15,22 +18,22 @@ func TestUrlService(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("create url", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		svc := service.NewUrlService(fake.Repositories(), fake.Observer())
 
 		fake.MockUrlCreate()
-		url, err := svc.Create(ctx, "target")
+		url, err := svc.Create(ctx, "target", "", nil)
 
 		assert.NoError(t, err)
 		assert.Equal(t, model.URL{ID: 1, Code: "1", Target: "target", CreatedAt: url.CreatedAt, UpdatedAt: url.UpdatedAt}, *url)
 	})
 
 	t.Run("list url", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		svc := service.NewUrlService(fake.Repositories(), fake.Observer())
 
 		fake.MockUrlList()
-		urls, err := svc.List(ctx, 5, ">", nil)
+		urls, err := svc.List(ctx, pagination.Query{Limit: 5}, model.URLFilter{})
 
 		assert.NoError(t, err)
 		assert.Len(t, urls, 5)
@@ -38,29 +41,82 @@ func TestUrlService(t *testing.T) {
 
 	t.Run("list paginated url", func(t *testing.T) {
 		cursor := int64(1)
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		svc := service.NewUrlService(fake.Repositories(), fake.Observer())
 
 		fake.MockPaginatedUrlList()
-		urls, err := svc.List(ctx, 5, ">", &cursor)
+		urls, err := svc.List(ctx, pagination.Query{Limit: 5, Direction: pagination.Backward, Key: &cursor}, model.URLFilter{})
 
 		assert.NoError(t, err)
 		assert.Len(t, urls, 5)
 	})
 
 	t.Run("list url from cache", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		svc := service.NewUrlService(fake.Repositories(), fake.Observer())
 
-		fake.CacheBackend.Value = `[]`
-		urls, err := svc.List(cache.WithCache(ctx), 5, ">", nil)
+		fake.CacheClient.Set(ctx, `[]`, service.URLCacheKey.With("list", "desc", "next", 5).String(), time.Minute)
+		urls, err := svc.List(cache.WithCache(ctx), pagination.Query{Limit: 5, Order: pagination.Descending, Direction: pagination.Forward}, model.URLFilter{})
 
 		assert.NoError(t, err)
 		assert.Len(t, urls, 0)
 	})
 
+	t.Run("list url pages from their own cache entries", func(t *testing.T) {
+		cursor := int64(9)
+		fake := test.NewFakeDependencies(t)
+		svc := service.NewUrlService(fake.Repositories(), fake.Observer())
+		key := service.URLCacheKey.With("list", "desc", "next", 5)
+
+		fake.CacheClient.Set(ctx, `[]`, key.String(), time.Minute)
+		fake.CacheClient.Set(ctx, `[{"id":8,"code":"8","target":"target8"}]`, key.With("key", 9).String(), time.Minute)
+		urls, err := svc.List(cache.WithCache(ctx), pagination.Query{Limit: 5, Order: pagination.Descending, Direction: pagination.Forward, Key: &cursor}, model.URLFilter{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []model.URL{{ID: 8, Code: "8", Target: "target8"}}, urls)
+
+		fake.MockUrlList()
+		urls, err = svc.List(cache.WithCache(ctx), pagination.Query{Limit: 10, Order: pagination.Descending, Direction: pagination.Forward}, model.URLFilter{})
+
+		assert.NoError(t, err)
+		assert.Len(t, urls, 5)
+	})
+
+	t.Run("list filtered url from its own cache entry", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		svc := service.NewUrlService(fake.Repositories(), fake.Observer())
+		key := service.URLCacheKey.With("list", "desc", "next", 5)
+
+		fake.CacheClient.Set(ctx, `[]`, key.String(), time.Minute)
+		fake.CacheClient.Set(ctx, `[{"id":7,"code":"7","target":"https://example.com"}]`, key.With("domain", `"example.com"`).With("status", "active").String(), time.Minute)
+		urls, err := svc.List(cache.WithCache(ctx), pagination.Query{Limit: 5, Order: pagination.Descending, Direction: pagination.Forward}, model.URLFilter{Domain: "Example.com", Status: model.URLActive})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []model.URL{{ID: 7, Code: "7", Target: "https://example.com"}}, urls)
+	})
+
+	t.Run("list filtered url does not share a cache entry with a crafted domain", func(t *testing.T) {
+		fake := test.NewFakeDependencies(t)
+		svc := service.NewUrlService(fake.Repositories(), fake.Observer())
+		page := pagination.Query{Limit: 5, Order: pagination.Descending, Direction: pagination.Forward}
+
+		fake.DBMock.ExpectQuery("SELECT id, code, target FROM urls WHERE deleted_at IS NULL AND (domain = $1 OR domain LIKE $2 ESCAPE '\\') ORDER BY id DESC LIMIT $3").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "code", "target"}))
+		urls, err := svc.List(cache.WithCache(ctx), page, model.URLFilter{Domain: `x:owner:"acme"`})
+
+		assert.NoError(t, err)
+		assert.Empty(t, urls)
+
+		fake.DBMock.ExpectQuery("SELECT id, code, target FROM urls WHERE deleted_at IS NULL AND (domain = $1 OR domain LIKE $2 ESCAPE '\\') AND owner = $3 ORDER BY id DESC LIMIT $4").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "code", "target"}).AddRow(int64(1), "1", "https://x/1"))
+		urls, err = svc.List(cache.WithCache(ctx), page, model.URLFilter{Domain: "x", Owner: "acme"})
+
+		assert.NoError(t, err)
+		assert.Len(t, urls, 1)
+	})
+
 	t.Run("url get by id", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		svc := service.NewUrlService(fake.Repositories(), fake.Observer())
 
 		fake.MockUrlGetById()
@@ -71,10 +127,10 @@ func TestUrlService(t *testing.T) {
 	})
 
 	t.Run("url get by id from cache", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		svc := service.NewUrlService(fake.Repositories(), fake.Observer())
 
-		fake.CacheBackend.Value = `{"id": 1, "Code": "1", "target": "target1", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"}`
+		fake.CacheClient.Set(ctx, `{"id": 1, "Code": "1", "target": "target1", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"}`, service.URLCacheKey.With("id", 1).String(), time.Minute)
 		url, err := svc.GetByID(cache.WithCache(ctx), int64(1))
 
 		assert.NoError(t, err)
@@ -82,7 +138,7 @@ func TestUrlService(t *testing.T) {
 	})
 
 	t.Run("url get by code", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		svc := service.NewUrlService(fake.Repositories(), fake.Observer())
 
 		fake.MockUrlGetById()
@@ -93,10 +149,10 @@ func TestUrlService(t *testing.T) {
 	})
 
 	t.Run("url get by code from cache", func(t *testing.T) {
-		fake := test.NewFakeDependencies()
+		fake := test.NewFakeDependencies(t)
 		svc := service.NewUrlService(fake.Repositories(), fake.Observer())
 
-		fake.CacheBackend.Value = `{"id": 1, "Code": "1", "target": "target1", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"}`
+		fake.CacheClient.Set(ctx, `{"id": 1, "Code": "1", "target": "target1", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"}`, service.URLCacheKey.With("id", 1).String(), time.Minute)
 		url, err := svc.GetByCode(cache.WithCache(ctx), "1")
 
 		assert.NoError(t, err)
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/repository"
+)
+
+type WebhookService interface {
+	Subscribe(context.Context, string, string, []model.EventType) (*model.WebhookSubscription, error)
+	Subscriptions(context.Context, string) ([]model.WebhookSubscription, error)
+	Unsubscribe(context.Context, string, int64) error
+	DeadLetters(context.Context, string, int64) ([]model.WebhookDelivery, error)
+	Replay(context.Context, string, int64, int64) error
+	ReplayAll(context.Context, string, int64) (int, error)
+}
+
+type WebhookSvc struct {
+	repo   repository.WebhookRepository
+	logger observability.Logger
+}
+
+func NewWebhookService(repositories repository.Repositories, observer observability.Observer) WebhookService {
+	return WebhookSvc{
+		repo:   repositories.Webhook,
+		logger: observer.Logger().With("service", "webhook"),
+	}
+}
+
+// Subscribe registers endpoint for the given events of owner's links, or
+// for all of them when events is empty. The returned subscription carries
+// the secret signing its deliveries, which is never disclosed again.
+func (s WebhookSvc) Subscribe(ctx context.Context, owner string, endpoint string, events []model.EventType) (*model.WebhookSubscription, error) {
+	secret := make([]byte, 32)
+
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Subscribe(ctx, model.WebhookSubscription{
+		Owner:  owner,
+		URL:    endpoint,
+		Secret: hex.EncodeToString(secret),
+		Events: events,
+	})
+}
+
+func (s WebhookSvc) Subscriptions(ctx context.Context, owner string) ([]model.WebhookSubscription, error) {
+	return s.repo.Subscriptions(ctx, owner)
+}
+
+func (s WebhookSvc) Unsubscribe(ctx context.Context, owner string, id int64) error {
+	return s.repo.Unsubscribe(ctx, owner, id)
+}
+
+func (s WebhookSvc) DeadLetters(ctx context.Context, owner string, subscriptionID int64) ([]model.WebhookDelivery, error) {
+	return s.repo.DeadLetters(ctx, owner, subscriptionID)
+}
+
+func (s WebhookSvc) Replay(ctx context.Context, owner string, subscriptionID int64, id int64) error {
+	return s.repo.Replay(ctx, owner, subscriptionID, id)
+}
+
+func (s WebhookSvc) ReplayAll(ctx context.Context, owner string, subscriptionID int64) (int, error) {
+	return s.repo.ReplayAll(ctx, owner, subscriptionID)
+}
@@ -0,0 +1,303 @@
+// Package webhook delivers link events to the endpoints subscribed by link
+// owners, signing every request and retrying failed deliveries until they
+// succeed or move to the dead-letter list.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/pkg/config"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/repository"
+)
+
+// RetryPolicy controls how failed deliveries are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts before a delivery moves
+	// to the dead-letter list.
+	MaxAttempts int
+	// Backoff is the wait after the first failed attempt; it doubles after
+	// every failure up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// delay returns the wait before the attempt following the given number of
+// failed ones.
+func (p RetryPolicy) delay(failed int) time.Duration {
+	delay := p.Backoff
+
+	for i := 1; i < failed && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.MaxBackoff)
+}
+
+// Dispatcher polls the queued deliveries and posts them to their
+// subscription endpoint. Any 2xx response acknowledges a delivery; other
+// responses and network errors schedule another attempt with exponential
+// backoff, until the policy is exhausted.
+//
+// Deliveries are at least once and unordered: receivers should deduplicate
+// on the X-Webhook-ID header and rely on the event timestamps for ordering.
+type Dispatcher struct {
+	repo     repository.WebhookRepository
+	client   *http.Client
+	policy   RetryPolicy
+	interval time.Duration
+	batch    int
+
+	started atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	metric  observability.MetricClient
+	logger  observability.Logger
+}
+
+func NewDispatcherFromConfig(conf config.WebhookConfiguration, repo repository.WebhookRepository, observer observability.Observer) (*Dispatcher, error) {
+	interval, err := conf.PollInterval()
+
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := conf.BatchSize()
+
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := conf.Timeout()
+
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := conf.MaxAttempts()
+
+	if err != nil {
+		return nil, err
+	}
+
+	backoff, err := conf.Backoff()
+
+	if err != nil {
+		return nil, err
+	}
+
+	maxBackoff, err := conf.MaxBackoff()
+
+	if err != nil {
+		return nil, err
+	}
+
+	policy := RetryPolicy{MaxAttempts: attempts, Backoff: backoff, MaxBackoff: maxBackoff}
+	return NewDispatcher(repo, NewClient(timeout), policy, interval, batch, observer), nil
+}
+
+func NewDispatcher(repo repository.WebhookRepository, client *http.Client, policy RetryPolicy, interval time.Duration, batch int, observer observability.Observer) *Dispatcher {
+	logger := observer.Logger().With("worker", "webhook-dispatcher")
+	metric, err := observer.Metric()
+
+	if err != nil {
+		logger.Error(context.Background(), "error building metric client", slog.Any("error", err))
+		metric = observability.NewNoopMetricClient()
+	}
+
+	return &Dispatcher{
+		repo:     repo,
+		client:   client,
+		policy:   policy,
+		interval: interval,
+		batch:    batch,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		metric:   metric,
+		logger:   logger,
+	}
+}
+
+// Start delivers due deliveries in the background until Close is called.
+func (d *Dispatcher) Start() {
+	if d.started.CompareAndSwap(false, true) {
+		go d.run()
+	}
+}
+
+// Close stops delivering. Attempts in progress are canceled and left
+// unrecorded; their deliveries are attempted again once their claim
+// expires.
+func (d *Dispatcher) Close() error {
+	d.once.Do(func() { close(d.stop) })
+
+	if d.started.Load() {
+		<-d.done
+	}
+
+	d.client.CloseIdleConnections()
+	return nil
+}
+
+// Flush attempts every due delivery until none is left or an error occurs,
+// returning how many were attempted.
+func (d *Dispatcher) Flush(ctx context.Context) (int, error) {
+	total := 0
+
+	for {
+		attempted, err := d.dispatch(ctx)
+		total += attempted
+
+		if err != nil || attempted < d.batch {
+			return total, err
+		}
+	}
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-d.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := d.Flush(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			d.logger.Error(ctx, "error dispatching webhooks", slog.Any("error", err))
+		}
+	}
+}
+
+// dispatch claims a batch of due deliveries and attempts each of them in
+// turn. The claim lasts long enough for every attempt to time out; an
+// outcome recorded after it expired is dropped, so it cannot overwrite a
+// newer attempt by another dispatcher.
+func (d *Dispatcher) dispatch(ctx context.Context) (int, error) {
+	now := time.Now()
+	leaseUntil := now.Add(time.Duration(d.batch) * max(d.client.Timeout, time.Second))
+	deliveries, err := d.repo.Claim(ctx, now, leaseUntil, d.batch)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for i, delivery := range deliveries {
+		delivery, attempted := d.attempt(ctx, delivery)
+
+		if !attempted {
+			return i, ctx.Err()
+		}
+
+		if err := d.repo.Record(ctx, delivery, leaseUntil); errors.Is(err, repository.ErrClaimLost) {
+			d.logger.Warn(ctx, "webhook delivery claimed by another dispatcher, leaving its outcome to it",
+				slog.Int64("delivery", delivery.ID),
+				slog.Int64("subscription", delivery.SubscriptionID),
+			)
+		} else if err != nil {
+			return i, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// attempt posts the delivery and returns it updated with the outcome. It
+// reports false when ctx was canceled, as the endpoint did not fail.
+func (d *Dispatcher) attempt(ctx context.Context, delivery model.WebhookDelivery) (model.WebhookDelivery, bool) {
+	startAt := time.Now()
+	err := d.post(ctx, delivery)
+
+	if ctx.Err() != nil {
+		return delivery, false
+	}
+
+	delivery.Attempts++
+	now := time.Now()
+
+	if err == nil {
+		delivery.Status = model.WebhookDelivered
+		delivery.LastError = nil
+		delivery.DeliveredAt = &now
+		d.metric.WebhookDelivery(ctx, observability.WebhookDelivered, time.Since(startAt))
+		return delivery, true
+	}
+
+	cause := err.Error()
+	delivery.LastError = &cause
+
+	if delivery.Attempts >= d.policy.MaxAttempts {
+		delivery.Status = model.WebhookDead
+		d.metric.WebhookDelivery(ctx, observability.WebhookDead, time.Since(startAt))
+		d.logger.Warn(ctx, "webhook delivery exhausted its attempts, moving it to dead letters",
+			slog.Int64("delivery", delivery.ID),
+			slog.Int64("subscription", delivery.SubscriptionID),
+			slog.Int("attempts", delivery.Attempts),
+			slog.Any("error", err),
+		)
+
+		return delivery, true
+	}
+
+	delivery.NextAttemptAt = now.Add(d.policy.delay(delivery.Attempts))
+	d.metric.WebhookDelivery(ctx, observability.WebhookFailed, time.Since(startAt))
+	return delivery, true
+}
+
+func (d *Dispatcher) post(ctx context.Context, delivery model.WebhookDelivery) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Endpoint, bytes.NewReader(delivery.Body))
+
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HeaderDeliveryID, strconv.FormatInt(delivery.ID, 10))
+	request.Header.Set(HeaderEventType, string(delivery.EventType))
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Body))
+
+	response, err := d.client.Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("endpoint answered %d", response.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,384 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/outbox"
+	"github.com/zeon-code/tiny-url/internal/pkg/observability"
+	"github.com/zeon-code/tiny-url/internal/pkg/test"
+	"github.com/zeon-code/tiny-url/internal/repository"
+	"github.com/zeon-code/tiny-url/internal/webhook"
+)
+
+// receiver records the requests of an httptest endpoint, answering with
+// the status it is set to.
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	w.WriteHeader(r.status)
+}
+
+func (r *receiver) answer(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.status = status
+}
+
+func (r *receiver) received() ([]*http.Request, [][]byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*http.Request(nil), r.requests...), append([][]byte(nil), r.bodies...)
+}
+
+type stores struct {
+	urls     repository.URLRepository
+	webhooks repository.WebhookRepository
+	relay    *outbox.Relay
+	metric   *test.FakeMetric
+	observer observability.Observer
+}
+
+func newStores(t *testing.T) stores {
+	metric := test.NewFakeMetric()
+	observer := test.NewFakeObserver(metric)
+	client := test.NewSQLiteClient(t)
+
+	webhooks := repository.NewWebhookRepository(client, observer)
+	sink := webhook.NewSink(webhooks)
+
+	return stores{
+		urls:     repository.NewURLRepository(client, client, observer),
+		webhooks: webhooks,
+		relay:    outbox.NewRelay(repository.NewOutboxRepository(client, observer), sink, outbox.RetryPolicy{MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Second}, time.Hour, 10, observer),
+		metric:   metric,
+		observer: observer,
+	}
+}
+
+func (s stores) dispatcher(policy webhook.RetryPolicy) *webhook.Dispatcher {
+	return webhook.NewDispatcher(s.webhooks, &http.Client{Timeout: time.Second}, policy, time.Hour, 10, s.observer)
+}
+
+func subscribe(t *testing.T, s stores, owner string, endpoint string, events ...model.EventType) *model.WebhookSubscription {
+	subscription, err := s.webhooks.Subscribe(context.Background(), model.WebhookSubscription{
+		Owner:  owner,
+		URL:    endpoint,
+		Secret: "secret-" + owner,
+		Events: events,
+	})
+	require.NoError(t, err)
+
+	return subscription
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+	policy := webhook.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	t.Run("delivers signed events of the owner's links", func(t *testing.T) {
+		s := newStores(t)
+		endpoint := &receiver{status: http.StatusNoContent}
+		server := httptest.NewServer(endpoint)
+		defer server.Close()
+
+		subscription := subscribe(t, s, "acme", server.URL)
+
+		link, err := s.urls.Create(ctx, "https://example.com", "acme", nil)
+		require.NoError(t, err)
+		require.NoError(t, s.urls.Disable(ctx, link.ID))
+
+		_, err = s.relay.Flush(ctx)
+		require.NoError(t, err)
+
+		attempted, err := s.dispatcher(policy).Flush(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, attempted)
+
+		requests, bodies := endpoint.received()
+		require.Len(t, requests, 2)
+
+		for i, request := range requests {
+			timestamp, err := strconv.ParseInt(request.Header.Get(webhook.HeaderTimestamp), 10, 64)
+			require.NoError(t, err)
+
+			assert.WithinDuration(t, time.Now(), time.Unix(timestamp, 0), time.Minute)
+			assert.Equal(t, webhook.Sign(subscription.Secret, timestamp, bodies[i]), request.Header.Get(webhook.HeaderSignature))
+			assert.NotEmpty(t, request.Header.Get(webhook.HeaderDeliveryID))
+		}
+
+		var event struct {
+			LinkID  int64           `json:"link_id"`
+			Type    model.EventType `json:"type"`
+			Payload model.URL       `json:"payload"`
+		}
+
+		require.NoError(t, json.Unmarshal(bodies[0], &event))
+		assert.Equal(t, model.EventLinkCreated, event.Type)
+		assert.Equal(t, link.ID, event.LinkID)
+		assert.Equal(t, "acme", event.Payload.Owner)
+		assert.Equal(t, string(model.EventLinkUpdated), requests[1].Header.Get(webhook.HeaderEventType))
+		assert.Equal(t, []observability.WebhookOutcome{observability.WebhookDelivered, observability.WebhookDelivered}, s.metric.Webhooks())
+
+		attempted, err = s.dispatcher(policy).Flush(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, attempted)
+	})
+
+	t.Run("only delivers subscribed events of the subscribed owner", func(t *testing.T) {
+		s := newStores(t)
+		endpoint := &receiver{status: http.StatusOK}
+		server := httptest.NewServer(endpoint)
+		defer server.Close()
+
+		subscribe(t, s, "acme", server.URL, model.EventLinkDeleted)
+
+		link, err := s.urls.Create(ctx, "https://example.com/acme", "acme", nil)
+		require.NoError(t, err)
+		_, err = s.urls.Create(ctx, "https://example.com/other", "other", nil)
+		require.NoError(t, err)
+		_, err = s.urls.Create(ctx, "https://example.com/anonymous", "", nil)
+		require.NoError(t, err)
+		require.NoError(t, s.urls.Delete(ctx, link.ID))
+
+		published, err := s.relay.Flush(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 4, published)
+
+		_, err = s.dispatcher(policy).Flush(ctx)
+		require.NoError(t, err)
+
+		requests, _ := endpoint.received()
+		require.Len(t, requests, 1)
+		assert.Equal(t, string(model.EventLinkDeleted), requests[0].Header.Get(webhook.HeaderEventType))
+	})
+
+	t.Run("delivers click thresholds reached by the owner's links", func(t *testing.T) {
+		s := newStores(t)
+		endpoint := &receiver{status: http.StatusOK}
+		server := httptest.NewServer(endpoint)
+		defer server.Close()
+
+		subscribe(t, s, "acme", server.URL, model.EventLinkClickThreshold)
+
+		link, err := s.urls.Create(ctx, "https://example.com", "acme", nil)
+		require.NoError(t, err)
+
+		_, err = s.urls.Click(ctx, link.ID, model.ClickThresholds[0])
+		require.NoError(t, err)
+
+		_, err = s.relay.Flush(ctx)
+		require.NoError(t, err)
+		_, err = s.dispatcher(policy).Flush(ctx)
+		require.NoError(t, err)
+
+		requests, bodies := endpoint.received()
+		require.Len(t, requests, 1)
+		assert.Equal(t, string(model.EventLinkClickThreshold), requests[0].Header.Get(webhook.HeaderEventType))
+		assert.Contains(t, string(bodies[0]), `"clicks":10`)
+	})
+
+	t.Run("retries with backoff and moves exhausted deliveries to dead letters", func(t *testing.T) {
+		s := newStores(t)
+		endpoint := &receiver{status: http.StatusServiceUnavailable}
+		server := httptest.NewServer(endpoint)
+		defer server.Close()
+
+		subscription := subscribe(t, s, "acme", server.URL)
+		dispatcher := s.dispatcher(webhook.RetryPolicy{MaxAttempts: 2, Backoff: 50 * time.Millisecond, MaxBackoff: time.Second})
+
+		_, err := s.urls.Create(ctx, "https://example.com", "acme", nil)
+		require.NoError(t, err)
+		_, err = s.relay.Flush(ctx)
+		require.NoError(t, err)
+
+		attempted, err := dispatcher.Flush(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempted)
+
+		attempted, err = dispatcher.Flush(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, attempted, "the next attempt waits for the backoff")
+		assert.Empty(t, mustDeadLetters(t, s, subscription.ID))
+
+		assert.Eventually(t, func() bool {
+			attempted, err := dispatcher.Flush(ctx)
+			return err == nil && attempted == 1
+		}, time.Second, 10*time.Millisecond)
+
+		dead := mustDeadLetters(t, s, subscription.ID)
+		require.Len(t, dead, 1)
+		assert.Equal(t, model.WebhookDead, dead[0].Status)
+		assert.Equal(t, 2, dead[0].Attempts)
+		assert.Equal(t, "endpoint answered 503", *dead[0].LastError)
+		assert.Equal(t, []observability.WebhookOutcome{observability.WebhookFailed, observability.WebhookDead}, s.metric.Webhooks())
+
+		endpoint.answer(http.StatusAccepted)
+		require.NoError(t, s.webhooks.Replay(ctx, "acme", subscription.ID, dead[0].ID))
+
+		attempted, err = dispatcher.Flush(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempted)
+		assert.Empty(t, mustDeadLetters(t, s, subscription.ID))
+
+		requests, _ := endpoint.received()
+		assert.Len(t, requests, 3)
+		assert.Equal(t, observability.WebhookDelivered, s.metric.Webhooks()[2])
+	})
+
+	t.Run("replays every dead letter of a subscription", func(t *testing.T) {
+		s := newStores(t)
+		endpoint := &receiver{status: http.StatusInternalServerError}
+		server := httptest.NewServer(endpoint)
+		defer server.Close()
+
+		subscription := subscribe(t, s, "acme", server.URL)
+		dispatcher := s.dispatcher(webhook.RetryPolicy{MaxAttempts: 1, Backoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+		for range 3 {
+			_, err := s.urls.Create(ctx, "https://example.com", "acme", nil)
+			require.NoError(t, err)
+		}
+
+		_, err := s.relay.Flush(ctx)
+		require.NoError(t, err)
+		_, err = dispatcher.Flush(ctx)
+		require.NoError(t, err)
+		require.Len(t, mustDeadLetters(t, s, subscription.ID), 3)
+
+		err = s.webhooks.Replay(ctx, "acme", subscription.ID+1, 1)
+		assert.ErrorIs(t, err, db.ErrDBResourceNotFound)
+
+		err = s.webhooks.Replay(ctx, "other", subscription.ID, 1)
+		assert.ErrorIs(t, err, db.ErrDBResourceNotFound)
+
+		replayed, err := s.webhooks.ReplayAll(ctx, "other", subscription.ID)
+		require.NoError(t, err)
+		assert.Zero(t, replayed, "other owners cannot replay the subscription")
+
+		other, err := s.webhooks.DeadLetters(ctx, "other", subscription.ID)
+		require.NoError(t, err)
+		assert.Empty(t, other, "other owners cannot read the dead letters")
+
+		endpoint.answer(http.StatusOK)
+		replayed, err = s.webhooks.ReplayAll(ctx, "acme", subscription.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, replayed)
+
+		attempted, err := dispatcher.Flush(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, attempted)
+		assert.Empty(t, mustDeadLetters(t, s, subscription.ID))
+	})
+
+	t.Run("unsubscribing drops queued deliveries", func(t *testing.T) {
+		s := newStores(t)
+		subscription := subscribe(t, s, "acme", "http://127.0.0.1:1")
+
+		_, err := s.urls.Create(ctx, "https://example.com", "acme", nil)
+		require.NoError(t, err)
+		_, err = s.relay.Flush(ctx)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, s.webhooks.Unsubscribe(ctx, "other", subscription.ID), db.ErrDBResourceNotFound)
+		require.NoError(t, s.webhooks.Unsubscribe(ctx, "acme", subscription.ID))
+		assert.ErrorIs(t, s.webhooks.Unsubscribe(ctx, "acme", subscription.ID), db.ErrDBResourceNotFound)
+
+		attempted, err := s.dispatcher(policy).Flush(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, attempted)
+	})
+
+	t.Run("stops in the background when closed", func(t *testing.T) {
+		s := newStores(t)
+		endpoint := &receiver{status: http.StatusOK}
+		server := httptest.NewServer(endpoint)
+		defer server.Close()
+
+		subscribe(t, s, "acme", server.URL)
+
+		_, err := s.urls.Create(ctx, "https://example.com", "acme", nil)
+		require.NoError(t, err)
+		_, err = s.relay.Flush(ctx)
+		require.NoError(t, err)
+
+		dispatcher := webhook.NewDispatcher(s.webhooks, &http.Client{Timeout: time.Second}, policy, 5*time.Millisecond, 10, s.observer)
+		dispatcher.Start()
+
+		assert.Eventually(t, func() bool {
+			requests, _ := endpoint.received()
+			return len(requests) == 1
+		}, time.Second, 5*time.Millisecond)
+
+		assert.NoError(t, dispatcher.Close())
+		assert.NoError(t, dispatcher.Close())
+	})
+
+	t.Run("drops an outcome recorded after another dispatcher took over the claim", func(t *testing.T) {
+		s := newStores(t)
+		subscribe(t, s, "acme", "https://hooks.example.com")
+
+		_, err := s.urls.Create(ctx, "https://example.com", "acme", nil)
+		require.NoError(t, err)
+		_, err = s.relay.Flush(ctx)
+		require.NoError(t, err)
+
+		now := time.Now()
+		expired := now.Add(time.Second)
+		claimed, err := s.webhooks.Claim(ctx, now, expired, 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+
+		later := expired.Add(time.Second)
+		reclaimed, err := s.webhooks.Claim(ctx, later, later.Add(time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, reclaimed, 1)
+
+		delivered := reclaimed[0]
+		delivered.Status = model.WebhookDelivered
+		delivered.Attempts = 1
+		delivered.DeliveredAt = &later
+		require.NoError(t, s.webhooks.Record(ctx, delivered, later.Add(time.Minute)))
+
+		stale := claimed[0]
+		stale.Attempts = 1
+		stale.NextAttemptAt = later.Add(time.Hour)
+		assert.ErrorIs(t, s.webhooks.Record(ctx, stale, expired), repository.ErrClaimLost)
+
+		attempted, err := s.dispatcher(policy).Flush(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, attempted, "the delivery stays delivered")
+	})
+}
+
+func mustDeadLetters(t *testing.T, s stores, subscriptionID int64) []model.WebhookDelivery {
+	dead, err := s.webhooks.DeadLetters(context.Background(), "acme", subscriptionID)
+	require.NoError(t, err)
+
+	return dead
+}
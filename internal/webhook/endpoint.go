@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenEndpoint is returned for endpoints on loopback, private,
+// link-local, multicast or unspecified addresses, which would let a
+// subscription reach the service's own network.
+var ErrForbiddenEndpoint = errors.New("webhook endpoint address is not public")
+
+// CheckEndpoint reports whether raw is an absolute http(s) URL whose host
+// is not a forbidden address. Host names are only rejected when they name
+// the local host; the addresses they resolve to are checked again by the
+// client returned from NewClient on every connection.
+func CheckEndpoint(raw string) error {
+	endpoint, err := url.Parse(raw)
+
+	if err != nil {
+		return err
+	}
+
+	if (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return fmt.Errorf("webhook endpoint %q is not an absolute http(s) URL", raw)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(endpoint.Hostname(), "."))
+
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenEndpoint
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return checkAddr(addr)
+	}
+
+	return nil
+}
+
+// NewClient returns the HTTP client delivering webhooks. It refuses to
+// connect to forbidden addresses after name resolution, so an endpoint
+// whose name later resolves to an internal address is still not reached,
+// and it ignores proxy settings, which would hide the dialed address.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+
+			if err != nil {
+				return err
+			}
+
+			return checkAddr(addrPort.Addr())
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+func checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrForbiddenEndpoint, addr)
+	}
+
+	return nil
+}
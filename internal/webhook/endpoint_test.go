@@ -0,0 +1,54 @@
+package webhook_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeon-code/tiny-url/internal/webhook"
+)
+
+func TestEndpoint(t *testing.T) {
+	t.Run("accepts public endpoints", func(t *testing.T) {
+		for _, endpoint := range []string{
+			"https://hooks.example.com/events",
+			"http://203.0.113.7:8080",
+			"https://[2001:db8::1]/hook",
+		} {
+			assert.NoError(t, webhook.CheckEndpoint(endpoint), endpoint)
+		}
+	})
+
+	t.Run("rejects internal endpoints", func(t *testing.T) {
+		for _, endpoint := range []string{
+			"http://localhost:8081/debug/pprof/",
+			"http://api.localhost",
+			"http://127.0.0.1",
+			"http://[::1]:8080",
+			"http://10.1.2.3",
+			"http://192.168.0.10",
+			"http://169.254.169.254/latest/meta-data/",
+			"http://[::ffff:127.0.0.1]",
+			"http://0.0.0.0",
+		} {
+			assert.ErrorIs(t, webhook.CheckEndpoint(endpoint), webhook.ErrForbiddenEndpoint, endpoint)
+		}
+	})
+
+	t.Run("rejects non http endpoints", func(t *testing.T) {
+		assert.Error(t, webhook.CheckEndpoint("ftp://hooks.example.com"))
+		assert.Error(t, webhook.CheckEndpoint("hooks.example.com"))
+	})
+
+	t.Run("client refuses to connect to internal addresses", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("the internal endpoint was reached")
+		}))
+		defer server.Close()
+
+		_, err := webhook.NewClient(time.Second).Post(server.URL, "application/json", nil)
+		assert.ErrorIs(t, err, webhook.ErrForbiddenEndpoint)
+	})
+}
@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/zeon-code/tiny-url/internal/db"
+	"github.com/zeon-code/tiny-url/internal/model"
+	"github.com/zeon-code/tiny-url/internal/repository"
+)
+
+// Headers sent with every delivery. The signature covers the timestamp
+// and the body, so receivers can reject replayed or tampered requests.
+const (
+	HeaderDeliveryID = "X-Webhook-ID"
+	HeaderEventType  = "X-Webhook-Event"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderSignature  = "X-Webhook-Signature"
+)
+
+// Sign returns the signature of a delivery: "sha256=" followed by the hex
+// HMAC-SHA256, keyed with the subscription secret, of the Unix timestamp,
+// a dot and the body.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sink is the outbox sink queuing a delivery of every event for the
+// subscriptions of its link owner. The relay publishes to it within the
+// transaction marking the event published, so each event is queued exactly
+// once.
+type Sink struct {
+	repo repository.WebhookRepository
+}
+
+func NewSink(repo repository.WebhookRepository) Sink {
+	return Sink{repo: repo}
+}
+
+func (s Sink) Publish(ctx context.Context, event model.Event) error {
+	_, err := s.repo.Enqueue(ctx, event)
+	return err
+}
+
+func (s Sink) PublishTx(ctx context.Context, tx db.SQLTX, event model.Event) error {
+	_, err := s.repo.EnqueueTx(ctx, tx, event)
+	return err
+}
+
+func (s Sink) Close() error {
+	return nil
+}
@@ -0,0 +1,3 @@
+ALTER TABLE urls
+    ADD COLUMN disabled_at TIMESTAMPTZ NULL,
+    ADD COLUMN deleted_at TIMESTAMPTZ NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_urls_deleted_at;
+DROP INDEX IF EXISTS idx_urls_expires_at;
+
+ALTER TABLE urls DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,4 @@
+ALTER TABLE urls ADD COLUMN expires_at TIMESTAMPTZ NULL;
+
+CREATE INDEX idx_urls_expires_at ON urls (expires_at) WHERE disabled_at IS NULL AND deleted_at IS NULL;
+CREATE INDEX idx_urls_deleted_at ON urls (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_urls_created_at;
+DROP INDEX IF EXISTS idx_urls_owner_created_at;
+DROP INDEX IF EXISTS idx_urls_code_prefix;
+DROP INDEX IF EXISTS idx_urls_domain;
+DROP INDEX IF EXISTS idx_urls_target_trgm;
+
+ALTER TABLE urls DROP COLUMN IF EXISTS domain;
@@ -0,0 +1,12 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE urls ADD COLUMN domain VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE urls
+SET domain = lower(coalesce(substring(target from '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^/?#@]*@)?([^/?#:]*)'), ''));
+
+CREATE INDEX idx_urls_target_trgm ON urls USING gin (target gin_trgm_ops);
+CREATE INDEX idx_urls_domain ON urls (domain);
+CREATE INDEX idx_urls_code_prefix ON urls (code text_pattern_ops);
+CREATE INDEX idx_urls_owner_created_at ON urls (owner, created_at);
+CREATE INDEX idx_urls_created_at ON urls (created_at);
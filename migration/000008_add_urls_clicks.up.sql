@@ -0,0 +1 @@
+ALTER TABLE urls ADD COLUMN clicks BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- Domain searches match the domain itself or any of its subdomains. The
+-- subdomain branch is a LIKE with a leading wildcard, which the btree index
+-- cannot serve; with this index both branches are answered by a BitmapOr.
+CREATE INDEX idx_urls_domain_trgm ON urls USING gin (domain gin_trgm_ops);
@@ -0,0 +1,37 @@
+// Package migration embeds the SQL schema migrations so the service binary
+// can apply them without the external migrate tool.
+//
+// Files follow the <version>_<name>.up.sql / <version>_<name>.down.sql
+// naming used by golang-migrate; see db.Migrator. The sqlite directory holds
+// the same schema for the embedded development backend, sharing version
+// numbers with the Postgres migrations it mirrors.
+package migration
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.sql
+var Postgres embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+var SQLite = mustSub(sqliteFiles, "sqlite")
+
+// mustSub returns the embedded directory dir, panicking at init when it is
+// missing so a broken build fails at startup rather than when migrating.
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	if _, err := fs.Stat(fsys, dir); err != nil {
+		panic("missing embedded migrations directory " + dir + ": " + err.Error())
+	}
+
+	sub, err := fs.Sub(fsys, dir)
+
+	if err != nil {
+		panic("error opening embedded migrations directory " + dir + ": " + err.Error())
+	}
+
+	return sub
+}
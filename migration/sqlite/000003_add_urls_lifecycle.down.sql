@@ -0,0 +1,2 @@
+ALTER TABLE urls DROP COLUMN disabled_at;
+ALTER TABLE urls DROP COLUMN deleted_at;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_urls_created_at;
+DROP INDEX IF EXISTS idx_urls_owner_created_at;
+DROP INDEX IF EXISTS idx_urls_code_prefix;
+DROP INDEX IF EXISTS idx_urls_domain;
+
+ALTER TABLE urls DROP COLUMN domain;
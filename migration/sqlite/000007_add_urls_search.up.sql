@@ -0,0 +1,23 @@
+ALTER TABLE urls ADD COLUMN domain VARCHAR(255) NOT NULL DEFAULT '';
+
+-- SQLite has no regular expressions: the host is what follows "://" up to
+-- the first "/", "?" or "#", without user information and port.
+UPDATE urls
+SET domain = (
+    WITH authority(value) AS (
+        SELECT replace(replace(substr(target, instr(target, '://') + 3), '?', '/'), '#', '/')
+    ), host_port(value) AS (
+        SELECT substr(value, 1, CASE WHEN instr(value, '/') = 0 THEN length(value) ELSE instr(value, '/') - 1 END) FROM authority
+    ), host(value) AS (
+        SELECT substr(value, instr(value, '@') + 1) FROM host_port
+    )
+    SELECT lower(CASE WHEN instr(value, ':') = 0 THEN value ELSE substr(value, 1, instr(value, ':') - 1) END) FROM host
+)
+WHERE instr(target, '://') > 0;
+
+-- Without trigram indexes, substring searches scan the table, which is
+-- acceptable for the development backend.
+CREATE INDEX idx_urls_domain ON urls (domain);
+CREATE INDEX idx_urls_code_prefix ON urls (code);
+CREATE INDEX idx_urls_owner_created_at ON urls (owner, created_at);
+CREATE INDEX idx_urls_created_at ON urls (created_at);
@@ -0,0 +1,8 @@
+-- locked_until leases an event to the relay publishing it, or postpones
+-- its next attempt after a failure. dead_at marks the events that
+-- exhausted their attempts, which no longer hold back their link.
+ALTER TABLE outbox ADD COLUMN locked_until TIMESTAMP NULL;
+ALTER TABLE outbox ADD COLUMN dead_at TIMESTAMP NULL;
+
+DROP INDEX IF EXISTS idx_outbox_pending;
+CREATE INDEX idx_outbox_pending ON outbox (id) WHERE published_at IS NULL AND dead_at IS NULL;